@@ -1,33 +1,54 @@
 package main
 
 import (
+	"math"
 	"os"
-	"sort"
 	"sync"
 	"time"
 
 	json "github.com/goccy/go-json"
 )
 
-// Time threshold for recent/stale device separation
-const recentDeviceThreshold = 10 * time.Second
+// Default time threshold for recent/stale device separation
+const defaultStaleAfter = 10 * time.Second
+
+// How often devices past -prune-after are looked for
+const pruneCheckInterval = 5 * time.Second
+
+// Number of recent RSSI readings kept per device
+const rssiHistoryCapacity = 30
+
+// Number of readings averaged by SmoothedRSSI (at most rssiHistoryCapacity)
+const rssiSmoothingWindow = 10
 
 // SortedDevices holds recently seen and stale devices separately
 type SortedDevices struct {
-	Recent []*BLEDevice
-	Stale  []*BLEDevice
+	Recent     []*BLEDevice
+	Stale      []*BLEDevice
+	StaleAfter time.Duration // Threshold used to split Recent from Stale
+	Now        time.Time     // Clock the split (and age coloring) is relative to
+}
+
+// All returns the recent devices followed by the stale ones
+func (s *SortedDevices) All() []*BLEDevice {
+	all := make([]*BLEDevice, 0, len(s.Recent)+len(s.Stale))
+	all = append(all, s.Recent...)
+	return append(all, s.Stale...)
 }
 
 // Message represents both notification and BLE device messages
 type Message struct {
-	Notification *string  `json:"notification,omitempty"`
-	Protocol     string   `json:"protocol,omitempty"`
-	MacAddress   string   `json:"mac_address,omitempty"`
-	RSSI         int      `json:"rssi,omitempty"`
-	MfrCode      int      `json:"mfr_code,omitempty"`
-	MfrData      string   `json:"mfr_data,omitempty"`
-	DeviceName   string   `json:"device_name,omitempty"`
-	ServiceUUIDs []string `json:"service_uuids,omitempty"`
+	Notification *string           `json:"notification,omitempty"`
+	Protocol     string            `json:"protocol,omitempty"`
+	MacAddress   string            `json:"mac_address,omitempty"`
+	RSSI         int               `json:"rssi,omitempty"`
+	MfrCode      int               `json:"mfr_code,omitempty"`
+	MfrData      string            `json:"mfr_data,omitempty"`
+	DeviceName   string            `json:"device_name,omitempty"`
+	ServiceUUIDs []string          `json:"service_uuids,omitempty"`
+	ServiceData  map[string]string `json:"service_data,omitempty"` // Service UUID -> payload (hex or base64)
+	Connectable  *bool             `json:"connectable,omitempty"`  // From the advertising PDU type; absent in older firmware
+	Source       string            `json:"source,omitempty"`       // Receiver that heard it; defaults to the input's port path
 }
 
 // BLEDevice represents a Bluetooth LE device
@@ -38,45 +59,323 @@ type BLEDevice struct {
 	MfrCode      int
 	MfrData      string
 	ServiceUUIDs []string
+	ServiceData  map[string]string // Latest payload per service UUID
+	Connectable  *bool             `json:",omitempty"` // nil when the firmware doesn't report it
+	Protocol     string            `json:",omitempty"` // Firmware-defined, e.g. advertising PDU type or classic vs LE
+	Source       string            `json:",omitempty"` // Input that heard the latest advertisement; empty for a single input
+	SourceRSSI   map[string]int    `json:",omitempty"` // Latest RSSI per input, only when inputs are tagged
+	Aliases      []string          `json:",omitempty"` // Earlier MACs merged in by -correlate-rpa; only set on view copies
+	FirstSeen    time.Time
 	LastSeen     time.Time
 	Count        int              // Number of times device has been observed
+	RSSIMin      int              // Weakest RSSI observed
+	RSSIMax      int              // Strongest RSSI observed
+	RSSIMean     float64          // Running mean of every RSSI observed
 	GeoData      *RSSILocationMap // Geographic data keyed by all RSSIs
+	RSSIHistory  *RingBuffer[int] `json:"-"` // Most recent RSSI readings (oldest first)
+	rate         rateWindow       // Advertisements per second
+
+	// Distinct Mfr Data values in the order seen; nil unless -mfr-history
+	MfrHistory *RingBuffer[mfrDataSample] `json:"-"`
+}
+
+// mfrDataSample is one manufacturer data value and when the device started sending it
+type mfrDataSample struct {
+	At   time.Time
+	Data string
+}
+
+// rateWindow counts events in whole-second buckets
+// It is updated under the aggregator lock, so counting costs no extra synchronization
+type rateWindow struct {
+	second  int64 // Unix second of the current bucket
+	current int   // Events so far in the current second
+	last    int   // Events in the previous second
+}
+
+// Add records one event at now
+func (r *rateWindow) Add(now time.Time) {
+	sec := now.Unix()
+	if sec != r.second {
+		if sec == r.second+1 {
+			r.last = r.current
+		} else {
+			r.last = 0
+		}
+		r.second = sec
+		r.current = 0
+	}
+	r.current++
+}
+
+// Rate returns the number of events in the last complete second before now
+func (r *rateWindow) Rate(now time.Time) int {
+	switch now.Unix() {
+	case r.second:
+		return r.last
+	case r.second + 1:
+		return r.current
+	}
+	return 0
+}
+
+// AdvRate returns how many advertisements the device sent in the last complete second
+func (d *BLEDevice) AdvRate() int {
+	return d.rate.Rate(time.Now())
+}
+
+// SmoothedRSSI returns the moving average of the device's recent RSSI readings
+// Falls back to the instantaneous RSSI when no history is available
+func (d *BLEDevice) SmoothedRSSI() int {
+	if d.RSSIHistory == nil || d.RSSIHistory.Size() == 0 {
+		return d.RSSI
+	}
+	return int(math.Round(smoothRSSI(d.RSSIHistory.Peek(rssiSmoothingWindow), rssiSmoothingWindow)))
+}
+
+// smoothRSSI returns the mean of the last n readings
+func smoothRSSI(history []int, n int) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	if len(history) > n {
+		history = history[len(history)-n:]
+	}
+	sum := 0
+	for _, rssi := range history {
+		sum += rssi
+	}
+	return float64(sum) / float64(len(history))
 }
 
 // Aggregator stores BLE devices indexed by MAC address
 type Aggregator struct {
-	mu      sync.RWMutex
-	devices map[string]*BLEDevice
+	mu           sync.RWMutex
+	devices      map[string]*BLEDevice
+	staleAfter   time.Duration         // Devices not seen within this window are considered stale
+	rate         rateWindow            // Advertisements per second across all devices
+	version      uint64                // Incremented on every change, so readers can detect updates
+	cleared      map[string]*BLEDevice // Devices removed by the last Clear, kept for a one-level undo
+	maxDevices   int                   // Evict the least-recently-seen device beyond this many (0 = unlimited)
+	onEvict      func(*BLEDevice)      // Called (outside the lock) with each evicted device
+	pruneAfter   time.Duration         // Remove devices not seen for this long (0 = never)
+	onPrune      func(*BLEDevice)      // Called (outside the lock) with each pruned device
+	onNew        func(*BLEDevice)      // Called (outside the lock) when a MAC is seen for the first time
+	geoTopN      int                   // RSSIs kept per device in GeoData (0 = all)
+	geoCapacity  int                   // Locations kept per RSSI in GeoData
+	mfrHistory   int                   // Distinct Mfr Data values kept per device (0 = none)
+	observations int                   // Advertisements added this session
+	peak         int                   // Most devices tracked at once since the last Clear
+	clearedPeak  int                   // peak before the last Clear, brought back by UndoClear
+	geoTagged    int                   // Of those, how many were tagged with a GPS fix
+	staleVersion uint64                // Bumped when a device can leave the stale set (update, eviction, clear)
+	staleCache   staleSortCache        // Last sorted stale slice, reused while the stale set is unchanged
 }
 
-func NewAggregator() *Aggregator {
+// staleSortCache keeps the sorted stale devices between refreshes
+// Stale devices aren't updated, so their order only changes when the set itself does; this holds
+// for every key, including RSSI, which for a stale device is its last-known reading. Devices
+// leave the set only through changes that bump staleVersion, and otherwise it can only grow by
+// aging, so an unchanged version and size mean the same members
+type staleSortCache struct {
+	mu      sync.Mutex
+	valid   bool
+	version uint64
+	order   SortOrder
+	sorted  []*BLEDevice
+}
+
+// get returns stale sorted by order, reusing the cached ordering when the set is unchanged
+// The returned slice is the caller's to modify
+func (c *staleSortCache) get(stale []*BLEDevice, order SortOrder, version uint64) []*BLEDevice {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.valid || c.version != version || c.order != order || len(c.sorted) != len(stale) {
+		sortDevices(stale, order)
+		c.valid, c.version, c.order = true, version, order
+		c.sorted = append(c.sorted[:0], stale...)
+		return stale
+	}
+	return append(stale[:0], c.sorted...)
+}
+
+// NewAggregator creates an aggregator using the given recent/stale threshold
+// Non-positive thresholds fall back to defaultStaleAfter
+func NewAggregator(staleAfter time.Duration) *Aggregator {
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
 	return &Aggregator{
-		devices: make(map[string]*BLEDevice),
+		devices:     make(map[string]*BLEDevice),
+		staleAfter:  staleAfter,
+		geoTopN:     defaultGeoTopN,
+		geoCapacity: defaultGeoCapacity,
+	}
+}
+
+// SetGeoLimits sets how many RSSIs (0 for all) and locations per RSSI are kept for devices added from now on
+func (a *Aggregator) SetGeoLimits(topN, capacity int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.geoTopN = topN
+	a.geoCapacity = capacity
+}
+
+// SetMfrHistory keeps the last n distinct Mfr Data values of each device added from now on; 0 keeps none
+func (a *Aggregator) SetMfrHistory(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mfrHistory = n
+}
+
+// SetMaxDevices caps the number of tracked devices; 0 means unlimited
+// onEvict, if non-nil, receives each device evicted to make room
+func (a *Aggregator) SetMaxDevices(maxDevices int, onEvict func(*BLEDevice)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxDevices = maxDevices
+	a.onEvict = onEvict
+}
+
+// SetPruneAfter removes devices not seen within pruneAfter on each RunPruner pass; 0 keeps them forever
+// onPrune, if non-nil, receives each pruned device
+func (a *Aggregator) SetPruneAfter(pruneAfter time.Duration, onPrune func(*BLEDevice)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pruneAfter = pruneAfter
+	a.onPrune = onPrune
+}
+
+// RunPruner prunes devices every pruneCheckInterval until done is closed
+func (a *Aggregator) RunPruner(done <-chan struct{}) {
+	ticker := time.NewTicker(pruneCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if pruned := a.Prune(now); pruned > 0 {
+				logger.Info("pruned devices", "count", pruned, "prune_after", a.pruneAfter)
+			}
+		}
 	}
 }
 
+// Prune removes every device last seen more than pruneAfter before now and returns how many were removed
+func (a *Aggregator) Prune(now time.Time) int {
+	a.mu.Lock()
+	if a.pruneAfter <= 0 {
+		a.mu.Unlock()
+		return 0
+	}
+	var pruned []*BLEDevice
+	for mac, dev := range a.devices {
+		if now.Sub(dev.LastSeen) > a.pruneAfter {
+			pruned = append(pruned, dev)
+			delete(a.devices, mac)
+		}
+	}
+	if len(pruned) > 0 {
+		a.version++
+		a.staleVersion++
+	}
+	onPrune := a.onPrune
+	a.mu.Unlock()
+
+	// Report after the lock is released, as with evictions
+	if onPrune != nil {
+		for _, dev := range pruned {
+			onPrune(dev)
+		}
+	}
+	return len(pruned)
+}
+
+// SetOnNewDevice registers fn to be called whenever a MAC not currently tracked is added
+func (a *Aggregator) SetOnNewDevice(fn func(*BLEDevice)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onNew = fn
+}
+
 func (a *Aggregator) AddOrUpdate(device *BLEDevice) {
+	// Report evictions and new devices after the lock is released (deferred calls run in reverse order)
+	var evicted, added *BLEDevice
+	var onEvict, onNew func(*BLEDevice)
+	defer func() {
+		if evicted != nil && onEvict != nil {
+			onEvict(evicted)
+		}
+		if added != nil && onNew != nil {
+			onNew(added)
+		}
+	}()
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	now := time.Now()
+	a.rate.Add(now)
+	a.version++
+	a.observations++
+
 	existing, exists := a.devices[device.MacAddress]
 	if !exists {
+		// Make room if at the cap
+		if a.maxDevices > 0 && len(a.devices) >= a.maxDevices {
+			evicted, onEvict = a.evictOldestLocked(), a.onEvict
+			a.staleVersion++
+		}
+
 		// New device, initialize count to 1
 		device.Count = 1
+		device.FirstSeen = device.LastSeen
+		device.RSSIMin, device.RSSIMax, device.RSSIMean = device.RSSI, device.RSSI, float64(device.RSSI)
+		device.RSSIHistory = NewRingBuffer[int](rssiHistoryCapacity)
+		device.RSSIHistory.Push(device.RSSI)
+		device.GeoData = NewRSSILocationMap(a.geoTopN, a.geoCapacity)
+		if a.mfrHistory > 0 {
+			device.MfrHistory = NewRingBuffer[mfrDataSample](a.mfrHistory)
+			if device.MfrData != "" {
+				device.MfrHistory.Push(mfrDataSample{At: device.LastSeen, Data: device.MfrData})
+			}
+		}
+		if device.Source != "" {
+			device.SourceRSSI = map[string]int{device.Source: device.RSSI}
+		}
+		device.rate.Add(now)
 		a.devices[device.MacAddress] = device
+		a.peak = max(a.peak, len(a.devices))
+		added, onNew = device, a.onNew
 		return
 	}
 
-	// Device exists - increment observation count
+	// Device exists - an update to a stale device moves it back to recent
+	if now.Sub(existing.LastSeen) > a.staleAfter {
+		a.staleVersion++
+	}
+
+	// Increment observation count
 	existing.Count++
+	existing.rate.Add(now)
 
 	// Apply update rules for each field:
 	// - If existing field is empty, update it
 	// - If existing field is not empty and new field is not empty, update it
 	// - If existing field is not empty and new field is empty, keep existing
 
-	// Update RSSI (always update, it's an int)
+	// Update RSSI (always update, it's an int) and its range and running mean
 	existing.RSSI = device.RSSI
+	existing.RSSIMin = min(existing.RSSIMin, device.RSSI)
+	existing.RSSIMax = max(existing.RSSIMax, device.RSSI)
+	existing.RSSIMean += (float64(device.RSSI) - existing.RSSIMean) / float64(existing.Count)
+	if existing.RSSIHistory == nil {
+		existing.RSSIHistory = NewRingBuffer[int](rssiHistoryCapacity)
+	}
+	existing.RSSIHistory.Push(device.RSSI)
 
 	// Update LastSeen (always update)
 	existing.LastSeen = device.LastSeen
@@ -91,7 +390,10 @@ func (a *Aggregator) AddOrUpdate(device *BLEDevice) {
 		existing.MfrCode = device.MfrCode
 	}
 
-	// Update MfrData
+	// Update MfrData, recording each change when -mfr-history is on
+	if device.MfrData != "" && device.MfrData != existing.MfrData && existing.MfrHistory != nil {
+		existing.MfrHistory.Push(mfrDataSample{At: device.LastSeen, Data: device.MfrData})
+	}
 	if existing.MfrData == "" || device.MfrData != "" {
 		existing.MfrData = device.MfrData
 	}
@@ -101,13 +403,90 @@ func (a *Aggregator) AddOrUpdate(device *BLEDevice) {
 		existing.ServiceUUIDs = device.ServiceUUIDs
 	}
 
+	// Update ServiceData (merge per UUID so frames of different types are all kept)
+	if len(device.ServiceData) > 0 {
+		if existing.ServiceData == nil {
+			existing.ServiceData = make(map[string]string, len(device.ServiceData))
+		}
+		for uuid, data := range device.ServiceData {
+			existing.ServiceData[uuid] = data
+		}
+	}
+
+	// Update Connectable (only when reported, so older firmware lines don't erase it)
+	if device.Connectable != nil {
+		existing.Connectable = device.Connectable
+	}
+
+	// Update Protocol (only when reported)
+	if device.Protocol != "" {
+		existing.Protocol = device.Protocol
+	}
+
+	// Update Source and keep the latest reading from each input
+	if device.Source != "" {
+		existing.Source = device.Source
+		if existing.SourceRSSI == nil {
+			existing.SourceRSSI = make(map[string]int)
+		}
+		existing.SourceRSSI[device.Source] = device.RSSI
+	}
+
 	// Ensure GeoData exists (initialize if needed)
 	if existing.GeoData == nil {
-		existing.GeoData = NewRSSILocationMap()
+		existing.GeoData = NewRSSILocationMap(a.geoTopN, a.geoCapacity)
+	}
+}
+
+// evictOldestLocked removes and returns the least-recently-seen device
+// This is an O(n) scan, but it only runs when inserting a new device over the cap
+// Caller must hold a.mu for writing
+func (a *Aggregator) evictOldestLocked() *BLEDevice {
+	var oldest *BLEDevice
+	for _, dev := range a.devices {
+		if oldest == nil || dev.LastSeen.Before(oldest.LastSeen) {
+			oldest = dev
+		}
+	}
+	if oldest != nil {
+		delete(a.devices, oldest.MacAddress)
 	}
+	return oldest
+}
+
+// Get returns the stored device for the given MAC address, or nil if unknown
+func (a *Aggregator) Get(mac string) *BLEDevice {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.devices[mac]
 }
 
+// GetRSSIHistory returns a copy of a device's recent RSSI readings (oldest first) and when it was last seen
+// Returns false if the device is unknown
+func (a *Aggregator) GetRSSIHistory(mac string) ([]int, time.Time, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	dev, exists := a.devices[mac]
+	if !exists || dev.RSSIHistory == nil {
+		return nil, time.Time{}, false
+	}
+	return dev.RSSIHistory.GetAll(), dev.LastSeen, true
+}
+
+// Stats returns the number of known devices and advertisements received in the last complete second
+func (a *Aggregator) Stats() (devices int, advPerSec int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.devices), a.rate.Rate(time.Now())
+}
+
+// GetSorted returns devices split into recent and stale using the default orderings
 func (a *Aggregator) GetSorted() *SortedDevices {
+	return a.GetSortedBy(defaultRecentSort, defaultStaleSort)
+}
+
+// GetSortedBy returns devices split into recent and stale, each sorted by the given order
+func (a *Aggregator) GetSortedBy(recentOrder, staleOrder SortOrder) *SortedDevices {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -117,79 +496,198 @@ func (a *Aggregator) GetSorted() *SortedDevices {
 		devices = append(devices, dev)
 	}
 
+	// Recent devices change every frame; the stale ordering is cached
 	now := time.Now().UTC()
+	recentDevices, staleDevices := splitDevices(devices, now, a.staleAfter)
+	sortDevices(recentDevices, recentOrder)
+	staleDevices = a.staleCache.get(staleDevices, staleOrder, a.staleVersion)
 
-	// Pre-allocate with capacity hints (estimate 50/50 split)
-	recentDevices := make([]*BLEDevice, 0, totalDevices/2)
-	staleDevices := make([]*BLEDevice, 0, totalDevices/2)
+	return &SortedDevices{
+		Recent:     recentDevices,
+		Stale:      staleDevices,
+		StaleAfter: a.staleAfter,
+		Now:        now,
+	}
+}
 
-	// Separate devices by last seen time
-	for _, dev := range devices {
-		if now.Sub(dev.LastSeen) <= recentDeviceThreshold {
-			recentDevices = append(recentDevices, dev)
-		} else {
-			staleDevices = append(staleDevices, dev)
-		}
+// GetSnapshotBy is like GetSortedBy but returns copies of the devices as of now,
+// so the result does not change as new observations arrive (used to freeze the display and for exports)
+func (a *Aggregator) GetSnapshotBy(recentOrder, staleOrder SortOrder) *SortedDevices {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	devices := make([]*BLEDevice, 0, len(a.devices))
+	for _, dev := range a.devices {
+		devices = append(devices, snapshotDevice(dev))
 	}
 
-	// Sort recent devices alphabetically by MAC address
-	sort.Slice(recentDevices, func(i, j int) bool {
-		return recentDevices[i].MacAddress < recentDevices[j].MacAddress
-	})
+	return partitionDevices(devices, time.Now().UTC(), a.staleAfter, recentOrder, staleOrder)
+}
 
-	// Pre-compute truncated times for stale devices to avoid repeated Truncate() calls
-	type cachedTime struct {
-		dev       *BLEDevice
-		truncTime time.Time
+// GetSnapshot returns a copy of a single device as of now, or nil if it is unknown
+func (a *Aggregator) GetSnapshot(mac string) *BLEDevice {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	dev, exists := a.devices[mac]
+	if !exists {
+		return nil
 	}
-	cached := make([]cachedTime, len(staleDevices))
-	for i, dev := range staleDevices {
-		cached[i] = cachedTime{
-			dev:       dev,
-			truncTime: dev.LastSeen.Truncate(time.Second),
+	return snapshotDevice(dev)
+}
+
+// snapshotDevice copies a device along with its mutable collections; the caller must hold the aggregator lock
+func snapshotDevice(dev *BLEDevice) *BLEDevice {
+	snapshot := *dev
+	if dev.ServiceData != nil {
+		snapshot.ServiceData = make(map[string]string, len(dev.ServiceData))
+		for uuid, data := range dev.ServiceData {
+			snapshot.ServiceData[uuid] = data
 		}
 	}
-
-	// Sort stale devices by truncated LastSeen descending, then by MAC address
-	sort.Slice(cached, func(i, j int) bool {
-		if cached[i].truncTime.Equal(cached[j].truncTime) {
-			return cached[i].dev.MacAddress < cached[j].dev.MacAddress
+	if dev.SourceRSSI != nil {
+		snapshot.SourceRSSI = make(map[string]int, len(dev.SourceRSSI))
+		for source, rssi := range dev.SourceRSSI {
+			snapshot.SourceRSSI[source] = rssi
 		}
-		return cached[i].truncTime.After(cached[j].truncTime)
-	})
-
-	// Extract sorted devices back to staleDevices slice
-	for i, c := range cached {
-		staleDevices[i] = c.dev
 	}
+	if dev.RSSIHistory != nil {
+		snapshot.RSSIHistory = dev.RSSIHistory.Clone()
+	}
+	if dev.MfrHistory != nil {
+		snapshot.MfrHistory = dev.MfrHistory.Clone()
+	}
+	if dev.GeoData != nil {
+		snapshot.GeoData = dev.GeoData.Snapshot()
+	}
+	return &snapshot
+}
+
+// partitionDevices splits devices into recent and stale relative to now, each sorted by the given order
+func partitionDevices(devices []*BLEDevice, now time.Time, staleAfter time.Duration, recentOrder, staleOrder SortOrder) *SortedDevices {
+	recentDevices, staleDevices := splitDevices(devices, now, staleAfter)
+
+	// Apply the requested orderings
+	sortDevices(recentDevices, recentOrder)
+	sortDevices(staleDevices, staleOrder)
 
 	return &SortedDevices{
-		Recent: recentDevices,
-		Stale:  staleDevices,
+		Recent:     recentDevices,
+		Stale:      staleDevices,
+		StaleAfter: staleAfter,
+		Now:        now,
 	}
 }
 
+// splitDevices separates devices seen within staleAfter of now from the rest, preserving order
+func splitDevices(devices []*BLEDevice, now time.Time, staleAfter time.Duration) (recentDevices, staleDevices []*BLEDevice) {
+	totalDevices := len(devices)
+
+	// Pre-allocate with capacity hints (estimate 50/50 split)
+	recentDevices = make([]*BLEDevice, 0, totalDevices/2)
+	staleDevices = make([]*BLEDevice, 0, totalDevices/2)
+
+	// Separate devices by last seen time
+	for _, dev := range devices {
+		if now.Sub(dev.LastSeen) <= staleAfter {
+			recentDevices = append(recentDevices, dev)
+		} else {
+			staleDevices = append(staleDevices, dev)
+		}
+	}
+	return recentDevices, staleDevices
+}
+
+// ExportJSON exports a snapshot of every device to a JSON file
 func (a *Aggregator) ExportJSON(filename string) error {
-	sorted := a.GetSorted()
+	return exportDevicesJSON(filename, a.GetSnapshotBy(defaultRecentSort, defaultStaleSort).All())
+}
 
-	// Combine for export (recent first, then stale)
-	allDevices := make([]*BLEDevice, 0, len(sorted.Recent)+len(sorted.Stale))
-	allDevices = append(allDevices, sorted.Recent...)
-	allDevices = append(allDevices, sorted.Stale...)
+// exportedDevice is a device as written by exportDevicesJSON, with the altitude of its averaged location
+type exportedDevice struct {
+	*BLEDevice
+	Elevation *float64 `json:",omitempty"` // Meters; omitted without geo data or altitude (RMC-only fixes)
+}
 
+// exportDevicesJSON writes devices to filename as an indented JSON array
+func exportDevicesJSON(filename string, devices []*BLEDevice) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	exported := make([]exportedDevice, len(devices))
+	for i, dev := range devices {
+		exported[i].BLEDevice = dev
+		if elevation, ok := deviceElevation(dev); ok {
+			exported[i].Elevation = &elevation
+		}
+	}
+
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(allDevices)
+	return encoder.Encode(exported)
 }
 
+// Clear removes all devices, keeping them aside so UndoClear can bring them back
 func (a *Aggregator) Clear() {
 	a.mu.Lock()
+	a.cleared = a.devices
 	a.devices = make(map[string]*BLEDevice)
+	a.clearedPeak, a.peak = a.peak, 0
+	a.version++
+	a.staleVersion++
 	a.mu.Unlock()
 }
+
+// UndoClear restores the devices removed by the last Clear
+// Devices observed again since the clear keep their latest state but regain their earlier count and first-seen time
+// Returns false if there is nothing to undo
+func (a *Aggregator) UndoClear() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cleared == nil {
+		return false
+	}
+
+	for mac, old := range a.cleared {
+		if current, exists := a.devices[mac]; exists {
+			total := current.Count + old.Count
+			current.RSSIMean = (current.RSSIMean*float64(current.Count) + old.RSSIMean*float64(old.Count)) / float64(total)
+			current.RSSIMin = min(current.RSSIMin, old.RSSIMin)
+			current.RSSIMax = max(current.RSSIMax, old.RSSIMax)
+			current.Count = total
+			current.FirstSeen = old.FirstSeen
+			continue
+		}
+		a.devices[mac] = old
+	}
+	a.cleared = nil
+	a.peak = max(max(a.peak, a.clearedPeak), len(a.devices))
+	a.version++
+	a.staleVersion++
+	return true
+}
+
+// Peak returns the most devices tracked at once since the last Clear
+func (a *Aggregator) Peak() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.peak
+}
+
+// CanUndoClear reports whether a cleared device set is available to restore
+func (a *Aggregator) CanUndoClear() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cleared != nil
+}
+
+// Version returns a counter that changes whenever the device set is modified
+func (a *Aggregator) Version() uint64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.version
+}
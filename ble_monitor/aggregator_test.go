@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddOrUpdateEvictsLeastRecentlySeen(t *testing.T) {
+	agg := NewAggregator(defaultStaleAfter)
+	var evicted []string
+	agg.SetMaxDevices(2, func(dev *BLEDevice) { evicted = append(evicted, dev.MacAddress) })
+
+	start := time.Now()
+	for i, mac := range []string{"AA:00:00:00:00:01", "AA:00:00:00:00:02", "AA:00:00:00:00:01", "AA:00:00:00:00:03"} {
+		agg.AddOrUpdate(&BLEDevice{MacAddress: mac, RSSI: -60, LastSeen: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	if !slices.Equal(evicted, []string{"AA:00:00:00:00:02"}) {
+		t.Errorf("evicted %v, want the least recently seen AA:00:00:00:00:02", evicted)
+	}
+	if count, _ := agg.Stats(); count != 2 {
+		t.Errorf("%d devices tracked, want the cap of 2", count)
+	}
+}
+
+// Run with -race: the eviction callback must be read under the lock SetMaxDevices writes it under
+func TestSetMaxDevicesWhileEvicting(t *testing.T) {
+	agg := NewAggregator(defaultStaleAfter)
+	agg.SetMaxDevices(1, func(*BLEDevice) {})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 200 {
+			agg.AddOrUpdate(&BLEDevice{MacAddress: fmt.Sprintf("AA:00:00:00:%02X:%02X", i/256, i%256), LastSeen: time.Now()})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			agg.SetMaxDevices(1, func(*BLEDevice) {})
+		}
+	}()
+	wg.Wait()
+}
+
+func TestPeakAcrossClearAndUndo(t *testing.T) {
+	agg := NewAggregator(defaultStaleAfter)
+	add := func(macs ...string) {
+		for _, mac := range macs {
+			agg.AddOrUpdate(&BLEDevice{MacAddress: mac, RSSI: -60, LastSeen: time.Now()})
+		}
+	}
+
+	// Counted as devices arrive, with no redraw in between
+	add("AA:00:00:00:00:01", "AA:00:00:00:00:02", "AA:00:00:00:00:03", "AA:00:00:00:00:01")
+	if got := agg.Peak(); got != 3 {
+		t.Errorf("peak = %d, want 3", got)
+	}
+
+	agg.Clear()
+	if got := agg.Peak(); got != 0 {
+		t.Errorf("peak after clear = %d, want 0", got)
+	}
+	add("AA:00:00:00:00:04")
+
+	agg.UndoClear()
+	if got := agg.Peak(); got != 4 {
+		t.Errorf("peak after undo = %d, want the 4 devices now tracked", got)
+	}
+}
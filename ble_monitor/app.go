@@ -0,0 +1,157 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// How long a transient status message such as an export result stays visible
+const statusMessageDuration = 4 * time.Second
+
+// App bundles the shared state used by the TUI event loop and its input handlers
+type App struct {
+	screen            tcell.Screen
+	agg               *Aggregator
+	paused            bool
+	pauseMu           sync.RWMutex
+	frozen            *SortedDevices // Display frozen at pause time
+	snapshot          *SortedDevices // Devices captured for snapshot mode, nil when live
+	connState         *ConnectionSet
+	locState          *LocationState
+	tableState        *TableState
+	exportModal       *ExportModalState
+	detailModal       *DetailModalState
+	watchModal        *WatchModalState
+	watchlist         *Watchlist
+	proximity         *ProximityState
+	autosaver         *Autosaver // nil when -autosave is not set
+	clearModal        *ClearModalState
+	stream            *JSONLStream // nil when -jsonl-out is not set
+	filter            DeviceFilter
+	heatmapCellMeters float64 // Grid cell size for heatmap exports
+	exports           ExportPaths
+	trackers          *TrackerDetector
+	session           *SessionStats
+	density           *DensityGraph
+	notices           *NotificationLog
+	correlator        *Correlator // nil unless -correlate-rpa is set
+	exporter          *Exporter   // nil runs exports inline
+	noticesModal      *NoticesModalState
+	helpModal         *HelpModalState
+	mfrModal          *MfrFilterModalState
+	statusMessage     string    // Transient notice shown at the start of the status line
+	statusExpiry      time.Time // When statusMessage stops being shown
+}
+
+// IsPaused returns the current pause state
+func (app *App) IsPaused() bool {
+	app.pauseMu.RLock()
+	defer app.pauseMu.RUnlock()
+	return app.paused
+}
+
+// InSnapshot returns whether the tables show a captured snapshot rather than live data
+func (app *App) InSnapshot() bool {
+	return app.snapshot != nil
+}
+
+// view returns the devices as displayed, honoring each table's chosen sort order and the active filter
+// In snapshot mode this is the snapshot, and while paused the devices as of the pause
+func (app *App) view() *SortedDevices {
+	return app.filter.Apply(app.unfilteredView())
+}
+
+// unfilteredView returns the sorted devices before the display filter is applied
+func (app *App) unfilteredView() *SortedDevices {
+	origin := app.locState.GetCurrent()
+	nearSort := app.tableState.nearSort.withOrigin(origin)
+	farSort := app.tableState.farSort.withOrigin(origin)
+	sorted := app.snapshot
+	if sorted == nil && app.IsPaused() {
+		sorted = app.frozen
+	}
+	if sorted != nil {
+		// Sort keys may still be changed while frozen
+		sortDevices(sorted.Recent, nearSort)
+		sortDevices(sorted.Stale, farSort)
+	} else {
+		sorted = app.agg.GetSortedBy(nearSort, farSort)
+	}
+	// Rotated addresses are merged after sorting so each merged row keeps its newest MAC's place
+	if app.correlator != nil {
+		sorted = app.correlator.Apply(sorted)
+	}
+	return sorted
+}
+
+// deviceSnapshot returns a copy of the device with this MAC, as captured in snapshot mode and live otherwise
+func (app *App) deviceSnapshot(mac string) *BLEDevice {
+	if app.snapshot == nil {
+		return app.agg.GetSnapshot(mac)
+	}
+	for _, dev := range app.snapshot.All() {
+		if dev.MacAddress == mac {
+			copied := *dev
+			return &copied
+		}
+	}
+	return nil
+}
+
+// selectedDevice returns the device under the cursor in the focused table, or nil if it is empty
+func (app *App) selectedDevice() *BLEDevice {
+	sorted := app.view()
+	devices, selected := sorted.Recent, app.tableState.nearSelected
+	if app.tableState.focusedTable == "far" {
+		devices, selected = sorted.Stale, app.tableState.farSelected
+	}
+	if selected < 0 || selected >= len(devices) {
+		return nil
+	}
+	return devices[selected]
+}
+
+// setStatusMessage shows msg in the status line for statusMessageDuration
+func (app *App) setStatusMessage(msg string) {
+	app.statusMessage = msg
+	app.statusExpiry = time.Now().Add(statusMessageDuration)
+}
+
+// activeStatusMessage returns the transient status message, or "" once it has expired
+func (app *App) activeStatusMessage() string {
+	if app.statusMessage == "" || time.Now().After(app.statusExpiry) {
+		return ""
+	}
+	return app.statusMessage
+}
+
+// exportDevices returns a snapshot of the devices to export, the captured one in snapshot mode
+// When filtered is set only the devices passing the display filter are included
+func (app *App) exportDevices(filtered bool) []*BLEDevice {
+	var sorted *SortedDevices
+	if app.snapshot != nil {
+		// Sorted on a copy so the snapshot tables keep their order
+		sorted = &SortedDevices{
+			Recent:     slices.Clone(app.snapshot.Recent),
+			Stale:      slices.Clone(app.snapshot.Stale),
+			StaleAfter: app.snapshot.StaleAfter,
+			Now:        app.snapshot.Now,
+		}
+		sortDevices(sorted.Recent, defaultRecentSort)
+		sortDevices(sorted.Stale, defaultStaleSort)
+	} else {
+		sorted = app.agg.GetSnapshotBy(defaultRecentSort, defaultStaleSort)
+	}
+	if filtered {
+		sorted = app.filter.Apply(sorted)
+	}
+	return sorted.All()
+}
+
+// redraw renders the current aggregator contents and any open modals
+func (app *App) redraw() {
+	drawTable(app, app.view())
+}
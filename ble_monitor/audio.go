@@ -1,32 +1,113 @@
 package main
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/gen2brain/beeep"
 )
 
+// How long a beep may overrun its duration before the audio device is treated as hung
+const beepTimeout = 2 * time.Second
+
+// Minimum gap between new-device chirps, so a burst of arrivals makes one sound
+const newDeviceChirpInterval = 2 * time.Second
+
+// audioDisabled is set by -no-sound, or after the first beep fails, so a broken device isn't retried
+var audioDisabled atomic.Bool
+
+// lastNewDeviceChirp holds the UnixNano time of the last new-device chirp
+var lastNewDeviceChirp atomic.Int64
+
+// disableAudio turns off all sounds for the rest of the run
+func disableAudio() {
+	audioDisabled.Store(true)
+}
+
+// beep plays a tone and reports whether it played
+// Any error or a beep that never returns disables audio from then on
+func beep(freq float64, ms int) bool {
+	if audioDisabled.Load() {
+		return false
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- beeep.Beep(freq, ms)
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			disableAudio()
+			return false
+		}
+		return true
+	case <-time.After(time.Duration(ms)*time.Millisecond + beepTimeout):
+		disableAudio()
+		return false
+	}
+}
+
 // Sound notification functions - all run in goroutines to avoid blocking
 
 func playDisconnectSound() {
 	go func() {
 		// Low frequency, longer duration - ominous
-		beeep.Beep(400, 300)
+		beep(400, 300)
 	}()
 }
 
 func playReconnectAttemptSound() {
 	go func() {
 		// Mid frequency, short blip
-		beeep.Beep(600, 100)
+		beep(600, 100)
+	}()
+}
+
+func playAlertSound() {
+	go func() {
+		// High frequency triple chirp - distinct from connection tones
+		for i := 0; i < 3; i++ {
+			if !beep(1200, 80) {
+				return
+			}
+			time.Sleep(40 * time.Millisecond)
+		}
+	}()
+}
+
+func playProximityClick() {
+	go func() {
+		// Very short high tick - Geiger counter style
+		beep(1500, 15)
 	}()
 }
 
 func playConnectedSound() {
 	go func() {
 		// Ascending two-tone success melody
-		beeep.Beep(600, 150)
+		if !beep(600, 150) {
+			return
+		}
 		time.Sleep(50 * time.Millisecond)
-		beeep.Beep(800, 150)
+		beep(800, 150)
+	}()
+}
+
+// playNewDeviceSound chirps for a newly seen device, at most once per newDeviceChirpInterval
+func playNewDeviceSound() {
+	now := time.Now().UnixNano()
+	last := lastNewDeviceChirp.Load()
+	if now-last < int64(newDeviceChirpInterval) || !lastNewDeviceChirp.CompareAndSwap(last, now) {
+		return
+	}
+	go func() {
+		// Quick high double chirp - brighter than the alert and connection tones
+		if !beep(2000, 25) {
+			return
+		}
+		time.Sleep(25 * time.Millisecond)
+		beep(2400, 25)
 	}()
 }
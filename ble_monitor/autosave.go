@@ -0,0 +1,84 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Autosaver periodically exports the aggregator to timestamped files
+// so a crash during a long unattended capture doesn't lose everything
+type Autosaver struct {
+	agg      *Aggregator
+	interval time.Duration
+	kml      bool // Also write a KML export each time
+	exports  ExportPaths
+	exporter *Exporter // Counts each save as an export in progress, so quitting waits for it
+
+	mu          sync.RWMutex
+	lastSave    time.Time
+	lastVersion uint64
+	lastErr     error
+}
+
+// NewAutosaver creates an autosaver; call Run to start it
+func NewAutosaver(agg *Aggregator, interval time.Duration, kml bool, exports ExportPaths, exporter *Exporter) *Autosaver {
+	return &Autosaver{
+		agg:      agg,
+		interval: interval,
+		kml:      kml,
+		exports:  exports,
+		exporter: exporter,
+	}
+}
+
+// Run saves on every interval until done is closed
+func (as *Autosaver) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(as.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			as.save()
+		}
+	}
+}
+
+// save writes the exports unless nothing has changed since the last autosave
+func (as *Autosaver) save() {
+	version := as.agg.Version()
+
+	as.mu.RLock()
+	unchanged := version == as.lastVersion
+	as.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	if as.exporter != nil {
+		defer as.exporter.Track()()
+	}
+	err := as.agg.ExportJSON(as.exports.autosave(".json"))
+	if err == nil && as.kml {
+		err = as.agg.ExportKML(as.exports.autosave(".kml"))
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.lastErr = err
+	if err != nil {
+		logger.Error("autosave failed", "error", err)
+		return
+	}
+	as.lastSave = time.Now()
+	as.lastVersion = version
+}
+
+// Status returns when the last successful autosave happened and the most recent error, if any
+func (as *Autosaver) Status() (lastSave time.Time, err error) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	return as.lastSave, as.lastErr
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Bluetooth SIG company identifier for Apple, Inc.
+const appleCompanyID = 76
+
+// IBeacon holds the fields of an Apple iBeacon advertisement
+type IBeacon struct {
+	UUID          string
+	Major         uint16
+	Minor         uint16
+	MeasuredPower int8 // Calibrated RSSI at 1 meter
+}
+
+// String formats the beacon for compact table display
+func (b *IBeacon) String() string {
+	return fmt.Sprintf("iBeacon %s maj:%d min:%d", b.UUID, b.Major, b.Minor)
+}
+
+// decodeMfrData converts the Mfr Data string to raw bytes
+// Accepts hex (as sent by most sniffer firmwares) or base64 (as sent by the c6 firmware)
+func decodeMfrData(mfrData string) ([]byte, bool) {
+	if mfrData == "" {
+		return nil, false
+	}
+	if len(mfrData)%2 == 0 {
+		if data, err := hex.DecodeString(mfrData); err == nil {
+			return data, true
+		}
+	}
+	if data, err := base64.StdEncoding.DecodeString(mfrData); err == nil {
+		return data, true
+	}
+	return nil, false
+}
+
+// decodeIBeacon parses Apple manufacturer data as an iBeacon frame
+// The payload may optionally be prefixed by the little-endian company ID (4C 00)
+// Returns false if the data is not an iBeacon advertisement
+func decodeIBeacon(mfrData string) (*IBeacon, bool) {
+	data, ok := decodeMfrData(mfrData)
+	if !ok {
+		return nil, false
+	}
+
+	// Strip the company ID if the firmware included it
+	if len(data) >= 2 && data[0] == 0x4C && data[1] == 0x00 {
+		data = data[2:]
+	}
+
+	// Type 0x02, length 0x15 (21 bytes): UUID(16) + major(2) + minor(2) + power(1)
+	if len(data) < 23 || data[0] != 0x02 || data[1] != 0x15 {
+		return nil, false
+	}
+
+	u := strings.ToUpper(hex.EncodeToString(data[2:18]))
+	return &IBeacon{
+		UUID:          fmt.Sprintf("%s-%s-%s-%s-%s", u[0:8], u[8:12], u[12:16], u[16:20], u[20:32]),
+		Major:         binary.BigEndian.Uint16(data[18:20]),
+		Minor:         binary.BigEndian.Uint16(data[20:22]),
+		MeasuredPower: int8(data[22]),
+	}, true
+}
+
+// displayMfrData returns a human-readable Mfr Data value for the table
+// Known beacon formats are decoded; anything else is shown raw
+func displayMfrData(dev *BLEDevice) string {
+	if dev.MfrCode == appleCompanyID {
+		if beacon, ok := decodeIBeacon(dev.MfrData); ok {
+			return beacon.String()
+		}
+	}
+	return dev.MfrData
+}
+
+// Eddystone frame type identifiers (first byte of the FEAA service data)
+const (
+	eddystoneFrameUID = 0x00
+	eddystoneFrameURL = 0x10
+	eddystoneFrameTLM = 0x20
+	eddystoneFrameEID = 0x30
+)
+
+// Eddystone URL scheme prefixes, indexed by the scheme byte
+var eddystoneURLSchemes = []string{"http://www.", "https://www.", "http://", "https://"}
+
+// Eddystone URL compressed TLD expansions, indexed by the encoded byte
+var eddystoneURLExpansions = []string{
+	".com/", ".org/", ".edu/", ".net/", ".info/", ".biz/", ".gov/",
+	".com", ".org", ".edu", ".net", ".info", ".biz", ".gov",
+}
+
+// Eddystone holds a decoded Google Eddystone service data frame
+// Only the fields relevant to FrameType are populated
+type Eddystone struct {
+	FrameType string // "UID", "URL", "TLM", or "EID"
+	TxPower   int8   // Calibrated TX power at 0 m (UID/URL/EID)
+
+	// UID frame
+	Namespace string
+	Instance  string
+
+	// URL frame
+	URL string
+
+	// TLM frame
+	BatteryMV    uint16  // Battery voltage in millivolts (0 if unsupported)
+	TemperatureC float64 // Beacon temperature in °C
+	AdvCount     uint32  // Advertisements sent since boot
+	Uptime       float64 // Seconds since boot
+
+	// EID frame
+	EphemeralID string
+}
+
+// String formats the frame for display
+func (e *Eddystone) String() string {
+	switch e.FrameType {
+	case "UID":
+		return fmt.Sprintf("Eddystone-UID ns:%s inst:%s", e.Namespace, e.Instance)
+	case "URL":
+		return fmt.Sprintf("Eddystone-URL %s", e.URL)
+	case "TLM":
+		return fmt.Sprintf("Eddystone-TLM %.3fV %.1f°C adv:%d up:%.0fs", float64(e.BatteryMV)/1000, e.TemperatureC, e.AdvCount, e.Uptime)
+	case "EID":
+		return fmt.Sprintf("Eddystone-EID %s", e.EphemeralID)
+	}
+	return "Eddystone"
+}
+
+// isEddystoneUUID reports whether a service UUID is the Eddystone 16-bit UUID (FEAA)
+// Accepts short ("feaa", "0xFEAA") and full 128-bit base UUID forms
+func isEddystoneUUID(uuid string) bool {
+	u := strings.ToLower(uuid)
+	return u == "feaa" || u == "0xfeaa" || strings.HasPrefix(u, "0000feaa-")
+}
+
+// findEddystoneData returns the FEAA service data payload for a device, if any
+func findEddystoneData(dev *BLEDevice) (string, bool) {
+	for uuid, data := range dev.ServiceData {
+		if isEddystoneUUID(uuid) {
+			return data, true
+		}
+	}
+	return "", false
+}
+
+// decodeEddystone parses an Eddystone service data frame (hex or base64 encoded)
+// Returns false if the payload is not a recognized Eddystone frame
+func decodeEddystone(serviceData string) (*Eddystone, bool) {
+	data, ok := decodeMfrData(serviceData)
+	if !ok || len(data) < 2 {
+		return nil, false
+	}
+
+	switch data[0] {
+	case eddystoneFrameUID:
+		// TX power(1) + namespace(10) + instance(6)
+		if len(data) < 18 {
+			return nil, false
+		}
+		return &Eddystone{
+			FrameType: "UID",
+			TxPower:   int8(data[1]),
+			Namespace: strings.ToUpper(hex.EncodeToString(data[2:12])),
+			Instance:  strings.ToUpper(hex.EncodeToString(data[12:18])),
+		}, true
+
+	case eddystoneFrameURL:
+		// TX power(1) + scheme(1) + encoded URL
+		if len(data) < 3 || int(data[2]) >= len(eddystoneURLSchemes) {
+			return nil, false
+		}
+		var url strings.Builder
+		url.WriteString(eddystoneURLSchemes[data[2]])
+		for _, b := range data[3:] {
+			if int(b) < len(eddystoneURLExpansions) {
+				url.WriteString(eddystoneURLExpansions[b])
+			} else if b > 0x20 && b < 0x7F {
+				url.WriteByte(b)
+			}
+		}
+		return &Eddystone{
+			FrameType: "URL",
+			TxPower:   int8(data[1]),
+			URL:       url.String(),
+		}, true
+
+	case eddystoneFrameTLM:
+		// Version(1) + VBATT(2) + TEMP(2, signed 8.8 fixed point) + ADV_CNT(4) + SEC_CNT(4, 0.1 s)
+		if len(data) < 14 || data[1] != 0x00 {
+			return nil, false // Only unencrypted TLM is decodable
+		}
+		return &Eddystone{
+			FrameType:    "TLM",
+			BatteryMV:    binary.BigEndian.Uint16(data[2:4]),
+			TemperatureC: float64(int16(binary.BigEndian.Uint16(data[4:6]))) / 256,
+			AdvCount:     binary.BigEndian.Uint32(data[6:10]),
+			Uptime:       float64(binary.BigEndian.Uint32(data[10:14])) / 10,
+		}, true
+
+	case eddystoneFrameEID:
+		// TX power(1) + ephemeral ID(8)
+		if len(data) < 10 {
+			return nil, false
+		}
+		return &Eddystone{
+			FrameType:   "EID",
+			TxPower:     int8(data[1]),
+			EphemeralID: strings.ToUpper(hex.EncodeToString(data[2:10])),
+		}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Bluetooth SIG company identifiers used by the classification rules
+const (
+	microsoftCompanyID = 0x0006
+	samsungCompanyID   = 0x0075
+)
+
+// Apple Continuity message types (first byte of Apple manufacturer data after the company ID)
+const (
+	appleTypeIBeacon          = 0x02
+	appleTypeProximityPairing = 0x07 // AirPods and Beats
+	appleTypeNearbyInfo       = 0x10
+	appleTypeFindMy           = 0x12 // Offline finding, used by AirTags and lost Apple devices
+)
+
+// Class groups used to tint rows; each class label belongs to one
+const (
+	groupTracker    = "Tracker"
+	groupBeacon     = "Beacon"
+	groupWearable   = "Wearable"
+	groupPhone      = "Phone/computer"
+	groupPeripheral = "Peripheral"
+)
+
+// classGroupOrder lists the groups as shown in the legend
+var classGroupOrder = []string{groupTracker, groupBeacon, groupWearable, groupPhone, groupPeripheral}
+
+// classGroups maps each classRules label to its group
+var classGroups = map[string]string{
+	"AirTag-like":      groupTracker,
+	"Tile tracker":     groupTracker,
+	"SmartTag":         groupTracker,
+	"iBeacon":          groupBeacon,
+	"Eddystone beacon": groupBeacon,
+	"Generic beacon":   groupBeacon,
+	"AirPods/Beats":    groupWearable,
+	"Fitbit":           groupWearable,
+	"Fitness sensor":   groupWearable,
+	"Apple device":     groupPhone,
+	"Phone":            groupPhone,
+	"Fast Pair":        groupPhone,
+	"Windows device":   groupPhone,
+	"Samsung device":   groupPhone,
+	"HID device":       groupPeripheral,
+}
+
+// classRule labels devices that match a heuristic
+type classRule struct {
+	label string
+	match func(dev *BLEDevice) bool
+}
+
+// classRules are tried in order; the first match wins, so more specific rules come first
+var classRules = []classRule{
+	{"AirTag-like", func(dev *BLEDevice) bool { return appleType(dev) == appleTypeFindMy }},
+	{"Tile tracker", func(dev *BLEDevice) bool { return hasService(dev, 0xFEED) || hasService(dev, 0xFEEC) }},
+	{"SmartTag", func(dev *BLEDevice) bool { return hasService(dev, 0xFD5A) }},
+	{"iBeacon", func(dev *BLEDevice) bool { return appleType(dev) == appleTypeIBeacon }},
+	{"Eddystone beacon", func(dev *BLEDevice) bool { return hasService(dev, 0xFEAA) }},
+	{"AirPods/Beats", func(dev *BLEDevice) bool { return appleType(dev) == appleTypeProximityPairing }},
+	{"Apple device", func(dev *BLEDevice) bool { return dev.MfrCode == appleCompanyID }},
+	{"Fitbit", func(dev *BLEDevice) bool { return strings.Contains(strings.ToLower(dev.DeviceName), "fitbit") }},
+	{"Phone", func(dev *BLEDevice) bool { return hasService(dev, 0xFD6F) }}, // Exposure Notification
+	{"Fast Pair", func(dev *BLEDevice) bool { return hasService(dev, 0xFE2C) }},
+	{"Windows device", func(dev *BLEDevice) bool { return dev.MfrCode == microsoftCompanyID }},
+	{"Samsung device", func(dev *BLEDevice) bool { return dev.MfrCode == samsungCompanyID }},
+	{"HID device", func(dev *BLEDevice) bool { return hasService(dev, 0x1812) }},
+	{"Fitness sensor", func(dev *BLEDevice) bool {
+		return hasService(dev, 0x180D) || hasService(dev, 0x1816) || hasService(dev, 0x1818)
+	}},
+	{"Generic beacon", func(dev *BLEDevice) bool {
+		return dev.Connectable != nil && !*dev.Connectable && dev.DeviceName == "" && dev.MfrData != ""
+	}},
+}
+
+// classifyDevice guesses a device class from its manufacturer data, services and name
+// Returns "" when no rule matches
+func classifyDevice(dev *BLEDevice) string {
+	for _, rule := range classRules {
+		if rule.match(dev) {
+			return rule.label
+		}
+	}
+	return ""
+}
+
+// appleType returns the Continuity message type of an Apple advertisement, or -1 if there is none
+func appleType(dev *BLEDevice) int {
+	if dev.MfrCode != appleCompanyID {
+		return -1
+	}
+	data, ok := decodeMfrData(dev.MfrData)
+	if !ok {
+		return -1
+	}
+	// Strip the company ID if the firmware included it
+	if len(data) >= 2 && data[0] == 0x4C && data[1] == 0x00 {
+		data = data[2:]
+	}
+	if len(data) == 0 {
+		return -1
+	}
+	return int(data[0])
+}
+
+// hasService reports whether a device advertises the 16-bit service UUID, as a UUID or in service data
+func hasService(dev *BLEDevice, uuid uint16) bool {
+	for _, u := range dev.ServiceUUIDs {
+		if short, ok := shortServiceUUID(u); ok && short == uuid {
+			return true
+		}
+	}
+	for u := range dev.ServiceData {
+		if short, ok := shortServiceUUID(u); ok && short == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceClasses returns the distinct classes present across both tables, alphabetically
+func deviceClasses(sorted *SortedDevices) []string {
+	seen := make(map[string]bool)
+	for _, devices := range [][]*BLEDevice{sorted.Recent, sorted.Stale} {
+		for _, dev := range devices {
+			if class := classifyDevice(dev); class != "" {
+				seen[class] = true
+			}
+		}
+	}
+
+	classes := make([]string, 0, len(seen))
+	for class := range seen {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// deviceProtocols returns the distinct protocols reported across both tables, alphabetically
+func deviceProtocols(sorted *SortedDevices) []string {
+	seen := make(map[string]bool)
+	for _, dev := range sorted.All() {
+		if dev.Protocol != "" {
+			seen[dev.Protocol] = true
+		}
+	}
+
+	protocols := make([]string, 0, len(seen))
+	for protocol := range seen {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
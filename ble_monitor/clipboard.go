@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// How long a clipboard helper may run before it is abandoned
+const clipboardTimeout = time.Second
+
+// clipboardCommands are tried in order; the first that succeeds receives the text on stdin
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// errNoClipboard is returned when no clipboard helper is installed or none of them worked
+var errNoClipboard = errors.New("no clipboard available")
+
+// copyToClipboard places text on the system clipboard via the platform's clipboard helper
+func copyToClipboard(text string) error {
+	for _, args := range clipboardCommands {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		cmd := exec.CommandContext(ctx, path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		err = cmd.Run()
+		cancel()
+		if err == nil {
+			return nil
+		}
+		// Installed but unusable (e.g., xclip without a display); try the next one
+		logger.Debug("clipboard helper failed", "helper", args[0], "error", err)
+	}
+	return errNoClipboard
+}
+
+// yank copies text to the clipboard and reports the outcome in the status line
+// On headless systems the text goes to the log instead, so it isn't lost
+func yank(app *App, what, text string) {
+	if err := copyToClipboard(text); err == nil {
+		app.setStatusMessage("✓ Copied " + what)
+		return
+	}
+	if logger.Enabled(context.Background(), slog.LevelWarn) {
+		logger.Warn("clipboard unavailable, yanked text follows", "what", what, "text", text)
+		app.setStatusMessage("✗ No clipboard; wrote " + what + " to the log")
+		return
+	}
+	app.setStatusMessage("✗ No clipboard available (use -log to capture yanks)")
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// tableColumn describes one column of the device tables
+type tableColumn struct {
+	key    string // Name used by -hide-columns
+	header string
+	width  int
+	fill   bool // Takes the remaining width (never less than colWidthMfrDataMin) instead of width
+	gap    bool // Drawn one column short so long values keep a gap before the next column
+	text   func(dev *BLEDevice, r *rowContext) string
+	style  func(dev *BLEDevice, r *rowContext) tcell.Style // nil draws in the row's normal style
+}
+
+// rowContext carries what the column accessors need beyond the device itself
+type rowContext struct {
+	now         time.Time
+	staleAfter  time.Duration
+	recent      bool // Drawing the RECENT DEVICES table
+	relativeAge bool
+	fullUUIDs   bool
+	imperial    bool
+	origin      *GeoLocation // Current GPS fix, for the distance column
+	tracker     bool         // Device is a suspected tracker
+	class       string
+	baseStyle   tcell.Style
+	normalStyle tcell.Style
+}
+
+// tableColumns lists every device table column in display order
+// Text with several lines (one per service UUID) spans several screen rows; see deviceRowLines
+var tableColumns = []tableColumn{
+	{key: "seen", header: "Last Seen", width: colWidthLastSeen, text: lastSeenText, style: lastSeenStyle},
+	{key: "count", header: "Count", width: colWidthCount, text: func(dev *BLEDevice, r *rowContext) string {
+		return fmt.Sprintf("%d", dev.Count)
+	}},
+	{key: "mac", header: "MAC Address", width: colWidthMAC, text: func(dev *BLEDevice, r *rowContext) string {
+		// With how many rotated addresses were merged into it
+		if len(dev.Aliases) > 0 {
+			return fmt.Sprintf("%s+%d", dev.MacAddress, len(dev.Aliases))
+		}
+		return dev.MacAddress
+	}},
+	{key: "conn", header: "C", width: colWidthConnectable, text: func(dev *BLEDevice, r *rowContext) string {
+		return connectableFlag(dev.Connectable)
+	}},
+	{key: "signal", header: "Sig(avg)", width: colWidthSignal, text: func(dev *BLEDevice, r *rowContext) string {
+		indicator, _ := getSignalIndicator(dev.SmoothedRSSI())
+		return indicator
+	}, style: func(dev *BLEDevice, r *rowContext) tcell.Style {
+		// Smoothed so the bars don't flicker between advertisements
+		_, color := getSignalIndicator(dev.SmoothedRSSI())
+		return r.baseStyle.Foreground(color)
+	}},
+	{key: "rssi", header: "RSSI", width: colWidthRSSI, text: func(dev *BLEDevice, r *rowContext) string {
+		return fmt.Sprintf("%d", dev.RSSI)
+	}},
+	{key: "location", header: "Location", width: colWidthLocation, text: func(dev *BLEDevice, r *rowContext) string {
+		// Averaged from the highest RSSI's geo data, 5 decimal places (≈1.1m precision)
+		if loc := deviceLocation(dev); loc != nil {
+			return fmt.Sprintf("%.5f, %.5f", loc.Latitude, loc.Longitude)
+		}
+		return ""
+	}},
+	{key: "distance", header: "Dist", width: colWidthDistance, text: func(dev *BLEDevice, r *rowContext) string {
+		// Blank without both a current fix and a device location
+		if loc := deviceLocation(dev); loc != nil && r.origin != nil {
+			return formatDistanceUnits(haversineMeters(*r.origin, *loc), r.imperial)
+		}
+		return ""
+	}},
+	{key: "elevation", header: "Elev", width: colWidthElevation, text: func(dev *BLEDevice, r *rowContext) string {
+		if elevation, ok := deviceElevation(dev); ok {
+			if r.imperial {
+				return fmt.Sprintf("%.0f ft", elevation/metersPerFoot)
+			}
+			return fmt.Sprintf("%.0f m", elevation)
+		}
+		return ""
+	}},
+	{key: "name", header: "Device Name", width: colWidthName, text: func(dev *BLEDevice, r *rowContext) string {
+		return dev.DeviceName
+	}},
+	{key: "vendor", header: "Vendor", width: colWidthVendor, gap: true, text: func(dev *BLEDevice, r *rowContext) string {
+		return lookupVendor(dev.MacAddress)
+	}},
+	{key: "class", header: "Class", width: colWidthClass, gap: true, text: func(dev *BLEDevice, r *rowContext) string {
+		// Heuristic, blank when unknown, marking suspected trackers
+		if r.tracker {
+			return "⚠ " + r.class
+		}
+		return r.class
+	}},
+	{key: "uuids", header: "Service UUIDs", width: colWidthServiceUUIDs, text: serviceUUIDsText},
+	{key: "mfr-id", header: "Mfr ID", width: colWidthMfrCode, text: func(dev *BLEDevice, r *rowContext) string {
+		if dev.MfrCode != 0 {
+			return fmt.Sprintf("%d", dev.MfrCode)
+		}
+		return ""
+	}},
+	{key: "mfr-data", header: "Mfr Data", fill: true, text: func(dev *BLEDevice, r *rowContext) string {
+		return displayMfrData(dev)
+	}},
+}
+
+// columnHideOrder lists table columns from least to most important on a narrow terminal.
+// MAC, RSSI and Mfr Data are never hidden
+var columnHideOrder = []string{"elevation", "location", "uuids", "vendor", "class", "distance", "count", "conn", "mfr-id", "signal", "seen", "name"}
+
+// visibleColumns returns the table columns not in hidden, in display order
+func visibleColumns(hidden map[string]bool) []tableColumn {
+	columns := make([]tableColumn, 0, len(tableColumns))
+	for _, column := range tableColumns {
+		if !hidden[column.key] {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// parseHiddenColumns parses the -hide-columns list of column keys
+// The MAC column identifies each row and can't be hidden
+func parseHiddenColumns(spec string) (map[string]bool, error) {
+	hidden := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		key := strings.ToLower(strings.TrimSpace(part))
+		if key == "" {
+			continue
+		}
+		if key == "mac" {
+			return nil, fmt.Errorf("the mac column can't be hidden")
+		}
+		known := false
+		for _, column := range tableColumns {
+			known = known || column.key == key
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q (want one of %s)", key, strings.Join(columnKeys(), ", "))
+		}
+		hidden[key] = true
+	}
+	return hidden, nil
+}
+
+// columnKeys returns the key of every table column in display order
+func columnKeys() []string {
+	keys := make([]string, len(tableColumns))
+	for i, column := range tableColumns {
+		keys[i] = column.key
+	}
+	return keys
+}
+
+// columnWidths returns the drawn width of each column, hiding low-priority ones on a narrow terminal
+// The fill column is left at zero; the caller sizes it once the horizontal scroll is known
+func columnWidths(columns []tableColumn, width int) []int {
+	colWidths := make([]int, len(columns))
+	for i, column := range columns {
+		if !column.fill {
+			colWidths[i] = column.width
+		}
+	}
+	if width < compactLayoutWidth {
+		hideLowPriorityColumns(columns, colWidths, width)
+	}
+	return colWidths
+}
+
+// hideLowPriorityColumns zeroes column widths in columnHideOrder until the rest fit in width
+func hideLowPriorityColumns(columns []tableColumn, colWidths []int, width int) {
+	total := 0
+	for i, column := range columns {
+		if column.fill {
+			total += colWidthMfrDataMin
+		} else {
+			total += colWidths[i]
+		}
+	}
+	for _, key := range columnHideOrder {
+		if total <= width {
+			return
+		}
+		for i, column := range columns {
+			if column.key == key {
+				total -= colWidths[i]
+				colWidths[i] = 0
+			}
+		}
+	}
+}
+
+// deviceLocation returns the device's averaged location, or nil without geo data
+func deviceLocation(dev *BLEDevice) *GeoLocation {
+	if dev.GeoData == nil {
+		return nil
+	}
+	return dev.GeoData.GetLocation()
+}
+
+// deviceElevation returns the altitude of the device's averaged location in meters
+// ok is false without geo data or when it is zero, as RMC fixes carry no altitude
+func deviceElevation(dev *BLEDevice) (elevation float64, ok bool) {
+	loc := deviceLocation(dev)
+	if loc == nil || loc.Elevation == 0 {
+		return 0, false
+	}
+	return loc.Elevation, true
+}
+
+// lastSeenText formats Last Seen as a timestamp or relative age
+func lastSeenText(dev *BLEDevice, r *rowContext) string {
+	if r.relativeAge {
+		return formatAge(time.Since(dev.LastSeen))
+	}
+	return formatDisplayTime(dev.LastSeen, displayTimeLayout)
+}
+
+// lastSeenStyle colors Last Seen by age in the recent table
+// Thresholds are 40%/60%/80% of the stale window (4s/6s/8s at the 10s default)
+func lastSeenStyle(dev *BLEDevice, r *rowContext) tcell.Style {
+	if !r.recent {
+		return r.normalStyle
+	}
+	age := r.now.Sub(dev.LastSeen)
+	switch {
+	case age > r.staleAfter*8/10:
+		return r.baseStyle.Foreground(theme.AgeCriticalColor)
+	case age > r.staleAfter*6/10:
+		return r.baseStyle.Foreground(theme.AgeCautionColor)
+	case age > r.staleAfter*4/10:
+		return r.baseStyle.Foreground(theme.AgeWarningColor)
+	}
+	return r.normalStyle
+}
+
+// serviceUUIDsText lists the service UUIDs one per line, ellipsized to the column width
+func serviceUUIDsText(dev *BLEDevice, r *rowContext) string {
+	lines := make([]string, len(dev.ServiceUUIDs))
+	for i, uuid := range dev.ServiceUUIDs {
+		if !r.fullUUIDs {
+			uuid = shortUUID(uuid)
+		}
+		if len(uuid) > colWidthServiceUUIDs {
+			uuid = uuid[:colWidthServiceUUIDs-3] + "..."
+		}
+		lines[i] = uuid
+	}
+	return strings.Join(lines, "\n")
+}
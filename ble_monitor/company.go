@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// bluetoothCompanies maps common Bluetooth SIG company identifiers to names
+// Source: Bluetooth SIG Assigned Numbers, "Company Identifiers" (trimmed to vendors seen in the field)
+var bluetoothCompanies = map[int]string{
+	0x0000: "Ericsson",
+	0x0001: "Nokia",
+	0x0002: "Intel",
+	0x0003: "IBM",
+	0x0004: "Toshiba",
+	0x0005: "3Com",
+	0x0006: "Microsoft",
+	0x0007: "Lucent",
+	0x0008: "Motorola",
+	0x0009: "Infineon",
+	0x000A: "Qualcomm (CSR)",
+	0x000D: "Texas Instruments",
+	0x000F: "Broadcom",
+	0x001D: "Qualcomm",
+	0x0022: "NEC",
+	0x0025: "NXP Semiconductors",
+	0x0029: "Hitachi",
+	0x0030: "STMicroelectronics",
+	0x003F: "Bluetooth SIG",
+	0x0046: "MediaTek",
+	0x0047: "Bluegiga",
+	0x0048: "Marvell",
+	0x004C: "Apple, Inc.",
+	0x0056: "Sony Ericsson",
+	0x0057: "Harman International",
+	0x0058: "Vizio",
+	0x0059: "Nordic Semiconductor",
+	0x005D: "Realtek",
+	0x0065: "HP",
+	0x006B: "Polar Electro",
+	0x0075: "Samsung Electronics",
+	0x0076: "Creative Technology",
+	0x0077: "Laird Technologies",
+	0x0078: "Nike",
+	0x0087: "Garmin",
+	0x008A: "Jawbone",
+	0x009E: "Bose",
+	0x00C4: "LG Electronics",
+	0x00E0: "Google",
+	0x0118: "Radius Networks",
+	0x012D: "Sony",
+	0x0131: "Cypress Semiconductor",
+	0x0157: "Huami (Amazfit)",
+	0x015D: "Estimote",
+	0x0171: "Amazon",
+	0x01AB: "Meta Platforms",
+	0x027D: "Huawei",
+	0x02E5: "Espressif",
+	0x038F: "Xiaomi",
+	0x0499: "Ruuvi Innovations",
+	0x05A7: "Sonos",
+	0x067C: "Tile, Inc.",
+}
+
+// lookupCompany resolves a Bluetooth SIG company identifier to a name
+// Returns the numeric code as a string when the company is unknown
+func lookupCompany(code int) string {
+	if name, ok := bluetoothCompanies[code]; ok {
+		return name
+	}
+	return strconv.Itoa(code)
+}
+
+// formatMfrCode formats a company identifier with its name when known, e.g. "76 (Apple, Inc.)"
+func formatMfrCode(code int) string {
+	if name, ok := bluetoothCompanies[code]; ok {
+		return fmt.Sprintf("%d (%s)", code, name)
+	}
+	return strconv.Itoa(code)
+}
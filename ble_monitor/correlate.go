@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Address rotation matching (-correlate-rpa)
+const (
+	rotationMaxGap     = 30 * time.Second // Longest silence between the old MAC's last and the new MAC's first advertisement
+	rotationMaxOverlap = 2 * time.Second  // Both MACs may be heard briefly around the switch
+)
+
+// Correlator merges devices that appear to be one phone or laptop rotating its resolvable private address
+// It works on the sorted view, above the aggregator, so the stored devices are never altered
+// Only the TUI event loop touches it, so it needs no locking
+type Correlator struct {
+	aliases map[string][]string // Representative MAC -> earlier MACs merged into it, from the last Apply
+	merged  int                 // MACs folded into another device by the last Apply
+}
+
+// NewCorrelator creates a correlator with no merges yet
+func NewCorrelator() *Correlator {
+	return &Correlator{aliases: make(map[string][]string)}
+}
+
+// Aliases returns the earlier MACs merged into mac by the last Apply, oldest first
+func (c *Correlator) Aliases(mac string) []string {
+	return c.aliases[mac]
+}
+
+// Merged returns how many MACs the last Apply folded into other devices
+func (c *Correlator) Merged() int {
+	return c.merged
+}
+
+// Apply returns sorted with each chain of rotated addresses collapsed into its newest MAC, preserving order
+// The merged entries are copies carrying the chain's combined count, first-seen time and RSSI range
+// sorted itself is never modified
+func (c *Correlator) Apply(sorted *SortedDevices) *SortedDevices {
+	chains := rotationChains(sorted.All())
+
+	c.aliases = make(map[string][]string, len(chains))
+	c.merged = 0
+	replace := make(map[string]*BLEDevice) // Newest MAC of a chain -> merged copy
+	drop := make(map[string]bool)          // Older MACs of a chain
+	for _, chain := range chains {
+		head := mergeChain(chain)
+		replace[head.MacAddress] = head
+		c.aliases[head.MacAddress] = head.Aliases
+		c.merged += len(head.Aliases)
+		for _, mac := range head.Aliases {
+			drop[mac] = true
+		}
+	}
+	if len(replace) == 0 {
+		return sorted
+	}
+
+	result := *sorted
+	result.Recent = collapseChains(sorted.Recent, replace, drop)
+	result.Stale = collapseChains(sorted.Stale, replace, drop)
+	return &result
+}
+
+// collapseChains swaps each chain's newest device for its merged copy and drops the older ones
+func collapseChains(devices []*BLEDevice, replace map[string]*BLEDevice, drop map[string]bool) []*BLEDevice {
+	result := make([]*BLEDevice, 0, len(devices))
+	for _, dev := range devices {
+		if drop[dev.MacAddress] {
+			continue
+		}
+		if merged, ok := replace[dev.MacAddress]; ok {
+			dev = merged
+		}
+		result = append(result, dev)
+	}
+	return result
+}
+
+// rotationChains groups devices that look like one advertiser hopping between resolvable private addresses
+// Devices must share a payload fingerprint and follow one another in time: the next MAC appears
+// within rotationMaxGap of the previous one going quiet. Returns only chains of two or more, each oldest first
+func rotationChains(devices []*BLEDevice) [][]*BLEDevice {
+	groups := make(map[string][]*BLEDevice)
+	for _, dev := range devices {
+		if fp, ok := rotationFingerprint(dev); ok {
+			groups[fp] = append(groups[fp], dev)
+		}
+	}
+
+	var chains [][]*BLEDevice
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if !group[i].FirstSeen.Equal(group[j].FirstSeen) {
+				return group[i].FirstSeen.Before(group[j].FirstSeen)
+			}
+			return group[i].MacAddress < group[j].MacAddress
+		})
+
+		// Extend whichever open chain ended closest before this device started
+		var open [][]*BLEDevice
+		for _, dev := range group {
+			best := -1
+			var bestGap time.Duration
+			for i, chain := range open {
+				gap := dev.FirstSeen.Sub(chain[len(chain)-1].LastSeen)
+				if gap < -rotationMaxOverlap || gap > rotationMaxGap {
+					continue
+				}
+				if best < 0 || gap < bestGap {
+					best, bestGap = i, gap
+				}
+			}
+			if best < 0 {
+				open = append(open, []*BLEDevice{dev})
+			} else {
+				open[best] = append(open[best], dev)
+			}
+		}
+		for _, chain := range open {
+			if len(chain) > 1 {
+				chains = append(chains, chain)
+			}
+		}
+	}
+	return chains
+}
+
+// mergeChain returns a copy of the chain's newest device with the chain's combined history
+func mergeChain(chain []*BLEDevice) *BLEDevice {
+	newest := chain[len(chain)-1]
+	merged := *newest
+	merged.Aliases = make([]string, 0, len(chain)-1)
+	for _, dev := range chain[:len(chain)-1] {
+		merged.Aliases = append(merged.Aliases, dev.MacAddress)
+		merged.Count += dev.Count
+		merged.RSSIMin = min(merged.RSSIMin, dev.RSSIMin)
+		merged.RSSIMax = max(merged.RSSIMax, dev.RSSIMax)
+		if dev.FirstSeen.Before(merged.FirstSeen) {
+			merged.FirstSeen = dev.FirstSeen
+		}
+	}
+	return &merged
+}
+
+// rotationFingerprint summarizes the parts of a rotating device's advertisement that survive an address change
+// Only resolvable private addresses with Apple Continuity or Microsoft CDP manufacturer data qualify;
+// the per-rotation salts, hashes and auth tags are left out
+func rotationFingerprint(dev *BLEDevice) (string, bool) {
+	if !isResolvablePrivateAddress(dev.MacAddress) {
+		return "", false
+	}
+	data, ok := decodeMfrData(dev.MfrData)
+	if !ok {
+		return "", false
+	}
+
+	switch dev.MfrCode {
+	case appleCompanyID:
+		// Strip the company ID if the firmware included it
+		if len(data) >= 2 && data[0] == 0x4C && data[1] == 0x00 {
+			data = data[2:]
+		}
+		if len(data) < 2 {
+			return "", false
+		}
+		// Message type and length; Nearby Info adds its status flags and action code
+		fp := fmt.Sprintf("apple:%02x:%02x", data[0], data[1])
+		if data[0] == appleTypeNearbyInfo && len(data) >= 3 {
+			fp += fmt.Sprintf(":%02x", data[2])
+		}
+		return fp + "|" + dev.DeviceName, true
+	case microsoftCompanyID:
+		// Scenario type, device type and flags; the salt and hash that follow change with the address
+		if len(data) >= 2 && data[0] == 0x06 && data[1] == 0x00 {
+			data = data[2:]
+		}
+		if len(data) < 3 {
+			return "", false
+		}
+		return fmt.Sprintf("microsoft:%02x:%02x:%02x|%s", data[0], data[1], data[2], dev.DeviceName), true
+	}
+	return "", false
+}
+
+// isResolvablePrivateAddress reports whether mac is a BLE resolvable private address (top two bits 01)
+func isResolvablePrivateAddress(mac string) bool {
+	if mac == "" {
+		return false
+	}
+	ch := mac[0]
+	if ch >= 'a' && ch <= 'f' {
+		ch -= 'a' - 'A'
+	}
+	if !(ch >= '0' && ch <= '9' || ch >= 'A' && ch <= 'F') {
+		return false
+	}
+	return hexDigitValue(ch)>>2 == 0x1
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Device density graph tuning
+const (
+	densitySampleInterval  = 5 * time.Second
+	densityHistoryCapacity = 1440 // Two hours at densitySampleInterval
+	densityAxisWidth       = 6    // Columns reserved for the count labels
+)
+
+// densitySample is the device count at one moment, with whether GPS had a fix then
+type densitySample struct {
+	at      time.Time
+	devices int
+	gps     bool // A GPS receiver is configured
+	fix     bool // ...and had a usable fix
+}
+
+// DensityGraph keeps a time series of the tracked device count and the full-screen view that charts it
+// Only the TUI event loop touches it, so it needs no locking
+type DensityGraph struct {
+	samples    *RingBuffer[densitySample]
+	lastSample time.Time
+	showing    bool
+}
+
+// NewDensityGraph creates an empty density history
+func NewDensityGraph() *DensityGraph {
+	return &DensityGraph{samples: NewRingBuffer[densitySample](densityHistoryCapacity)}
+}
+
+// Sample records the current device count if densitySampleInterval has passed since the last sample
+func (g *DensityGraph) Sample(now time.Time, devices int, locState *LocationState) {
+	if !g.lastSample.IsZero() && now.Sub(g.lastSample) < densitySampleInterval {
+		return
+	}
+	g.lastSample = now
+	status, _, _, _, _ := locState.GetStatus()
+	g.samples.Push(densitySample{
+		at:      now,
+		devices: devices,
+		gps:     status != "no_gps",
+		fix:     locState.GetCurrent() != nil,
+	})
+}
+
+// Toggle shows or hides the graph view
+func (g *DensityGraph) Toggle() {
+	g.showing = !g.showing
+}
+
+// Hide leaves the graph view
+func (g *DensityGraph) Hide() {
+	g.showing = false
+}
+
+// IsShowing returns whether the graph view is active
+func (g *DensityGraph) IsShowing() bool {
+	return g.showing
+}
+
+// drawDensityView renders the device count history as a block chart filling the screen
+// The newest sample is at the right edge; a GPS fix row is drawn beneath when a receiver is configured
+func drawDensityView(s tcell.Screen, graph *DensityGraph) {
+	width, height := s.Size()
+	bgStyle := theme.Base
+	titleStyle := theme.Base.Reverse(true).Bold(true)
+
+	drawText(s, 0, 0, width, titleStyle, fmt.Sprintf(" DEVICE COUNT (every %v)", densitySampleInterval))
+	drawCenteredText(s, 0, height-1, width, bgStyle, "ESC/d: Back to table")
+
+	samples := graph.samples.GetAll()
+	if len(samples) == 0 {
+		drawCenteredText(s, 0, height/2, width, bgStyle, "Collecting samples...")
+		return
+	}
+
+	// Keep only as many samples as there are columns
+	plotWidth := width - densityAxisWidth
+	if plotWidth < 1 {
+		return
+	}
+	if len(samples) > plotWidth {
+		samples = samples[len(samples)-plotWidth:]
+	}
+
+	hasGPS := false
+	peak := 1
+	for _, sample := range samples {
+		peak = max(peak, sample.devices)
+		hasGPS = hasGPS || sample.gps
+	}
+
+	// Rows between the title and the time axis, less the GPS row if shown
+	chartTop := 2
+	chartHeight := height - chartTop - 3
+	if hasGPS {
+		chartHeight -= 2
+	}
+	if chartHeight < 1 {
+		return
+	}
+	chartBottom := chartTop + chartHeight - 1
+
+	// Count axis
+	drawText(s, 0, chartTop, densityAxisWidth, bgStyle, fmt.Sprintf("%5d", peak))
+	drawText(s, 0, chartBottom, densityAxisWidth, bgStyle, fmt.Sprintf("%5d", 0))
+
+	// Bars in eighths of a row, right-aligned so the newest sample is at the edge
+	bars := []rune(" ▁▂▃▄▅▆▇█")
+	barStyle := bgStyle.Foreground(theme.SignalRamp[1])
+	x0 := width - len(samples)
+	for i, sample := range samples {
+		eighths := sample.devices * chartHeight * 8 / peak
+		for row := 0; row < chartHeight; row++ {
+			level := min(max(eighths-row*8, 0), 8)
+			setCell(s, x0+i, chartBottom-row, bars[level], barStyle)
+		}
+	}
+
+	// GPS fix availability: solid where there was a fix, shaded where there wasn't
+	if hasGPS {
+		gpsY := chartBottom + 2
+		drawText(s, 0, gpsY, densityAxisWidth, bgStyle, "  GPS")
+		for i, sample := range samples {
+			switch {
+			case sample.fix:
+				setCell(s, x0+i, gpsY, '█', bgStyle.Foreground(theme.CloserColor))
+			case sample.gps:
+				setCell(s, x0+i, gpsY, '░', bgStyle.Foreground(theme.LostColor))
+			}
+		}
+	}
+
+	// Time axis: how far back the left edge of the data reaches
+	axisY := height - 2
+	span := samples[len(samples)-1].at.Sub(samples[0].at).Round(time.Second)
+	drawText(s, x0, axisY, width-x0, bgStyle, fmt.Sprintf("-%v", span))
+	drawText(s, width-3, axisY, 3, bgStyle, "now")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+
+	json "github.com/goccy/go-json"
+)
+
+// evictionLog appends devices dropped by the -max-devices cap to a JSON Lines file
+type evictionLog struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// openEvictionLog opens (or creates) the log file for appending
+func openEvictionLog(path string) (*evictionLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open eviction log: %w", err)
+	}
+	return &evictionLog{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Write appends one device as a single JSON line
+func (l *evictionLog) Write(dev *BLEDevice) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line, err := json.Marshal(dev)
+	if err != nil {
+		return
+	}
+	l.w.Write(line)
+	l.w.WriteByte('\n')
+}
+
+// Close flushes buffered lines and closes the file
+func (l *evictionLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.w.Flush(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Interactive exports waiting for the worker before new requests are turned away
+const exportQueueSize = 8
+
+// How long quitting waits for in-flight exports before giving up on them
+const exportDrainTimeout = 10 * time.Second
+
+// exportJob writes one export file
+type exportJob struct {
+	filename string
+	write    func() error
+}
+
+// exportResult is the outcome of an exportJob, reported back to the event loop
+type exportResult struct {
+	filename string
+	err      error
+}
+
+// Exporter writes interactive exports on a background worker so a slow disk never freezes the UI
+// It also counts every export in progress, autosaves included, so quitting can wait for them
+type Exporter struct {
+	jobs     chan exportJob
+	results  chan exportResult
+	inFlight sync.WaitGroup
+	busy     atomic.Int32
+}
+
+// NewExporter starts the export worker; call Close when no more exports will be submitted
+func NewExporter() *Exporter {
+	e := &Exporter{
+		jobs:    make(chan exportJob, exportQueueSize),
+		results: make(chan exportResult, exportQueueSize+1),
+	}
+	go e.run()
+	return e
+}
+
+// run writes queued exports one at a time until the queue is closed
+func (e *Exporter) run() {
+	for job := range e.jobs {
+		err := job.write()
+		select {
+		case e.results <- exportResult{filename: job.filename, err: err}:
+		default:
+			// Nobody is reading (we're quitting); the log still has the outcome
+		}
+		e.end()
+	}
+}
+
+// Submit queues an export; returns false if the queue is full
+// write runs on the worker, so it must only use data captured before Submit
+func (e *Exporter) Submit(filename string, write func() error) bool {
+	e.begin()
+	select {
+	case e.jobs <- exportJob{filename: filename, write: write}:
+		return true
+	default:
+		e.end()
+		return false
+	}
+}
+
+// Results delivers the outcome of each submitted export
+func (e *Exporter) Results() <-chan exportResult {
+	return e.results
+}
+
+// Track marks an export written elsewhere (e.g. an autosave) as in progress until the returned func is called
+func (e *Exporter) Track() func() {
+	e.begin()
+	return e.end
+}
+
+// Busy reports whether any export is queued or being written
+func (e *Exporter) Busy() bool {
+	return e.busy.Load() > 0
+}
+
+// Close stops accepting exports and lets the worker exit once the queue drains
+func (e *Exporter) Close() {
+	close(e.jobs)
+}
+
+// Wait blocks until every export in progress has finished, or timeout passes
+// Returns false on timeout, when a file may be left incomplete
+func (e *Exporter) Wait(timeout time.Duration) bool {
+	return waitTimeout(&e.inFlight, timeout)
+}
+
+// begin counts an export as in progress
+func (e *Exporter) begin() {
+	e.inFlight.Add(1)
+	e.busy.Add(1)
+}
+
+// end counts an export as finished
+func (e *Exporter) end() {
+	e.busy.Add(-1)
+	e.inFlight.Done()
+}
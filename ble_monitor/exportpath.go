@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default filename prefix for device exports
+const defaultExportPrefix = "ble_devices"
+
+// ExportPaths decides where export files are written
+// The zero value writes to the working directory with the default prefix
+type ExportPaths struct {
+	dir    string // Output directory; "" means the working directory
+	prefix string // Prefix for device export filenames; "" means defaultExportPrefix
+}
+
+// NewExportPaths creates the output directory if needed
+func NewExportPaths(dir, prefix string) (ExportPaths, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return ExportPaths{}, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return ExportPaths{dir: dir, prefix: prefix}, nil
+}
+
+// devicePrefix returns the prefix used for device export filenames
+func (p ExportPaths) devicePrefix() string {
+	if p.prefix == "" {
+		return defaultExportPrefix
+	}
+	return p.prefix
+}
+
+// timestamped returns a path in the output directory named name_<timestamp>ext that doesn't already exist
+func (p ExportPaths) timestamped(name, ext string) string {
+	timestamp := formatDisplayTime(time.Now(), "2006-01-02_15-04-05")
+	return findNonCollidingFilename(filepath.Join(p.dir, name+"_"+timestamp), ext)
+}
+
+// snapshot returns a new path named name_snapshot_<taken>ext for an export of a snapshot taken at taken
+func (p ExportPaths) snapshot(name string, taken time.Time, ext string) string {
+	name += "_snapshot_" + formatDisplayTime(taken, "2006-01-02_15-04-05")
+	return findNonCollidingFilename(filepath.Join(p.dir, name), ext)
+}
+
+// devices returns a new path for a device export with the given extension, e.g. "out/ble_devices_<timestamp>.json"
+func (p ExportPaths) devices(ext string) string {
+	return p.timestamped(p.devicePrefix(), ext)
+}
+
+// autosave returns a new path for an autosave with the given extension
+func (p ExportPaths) autosave(ext string) string {
+	return p.timestamped(p.devicePrefix()+"_autosave", ext)
+}
+
+// merged returns a new path for a merged KML file
+func (p ExportPaths) merged() string {
+	return findNonCollidingFilename(filepath.Join(p.dir, p.devicePrefix()+"-MERGE"), ".kml")
+}
+
+// QuitExports selects the formats written automatically when the TUI exits
+type QuitExports struct {
+	json bool
+	kml  bool
+}
+
+// parseQuitExports parses a comma-separated list of formats ("json", "kml"); "" selects none
+func parseQuitExports(spec string) (QuitExports, error) {
+	var q QuitExports
+	if spec == "" {
+		return q, nil
+	}
+	for _, format := range strings.Split(spec, ",") {
+		switch strings.ToLower(strings.TrimSpace(format)) {
+		case "json":
+			q.json = true
+		case "kml":
+			q.kml = true
+		default:
+			return q, fmt.Errorf("unknown export format %q (use json, kml, or json,kml)", format)
+		}
+	}
+	return q, nil
+}
+
+// write exports the selected formats and returns a line describing each result
+func (q QuitExports) write(agg *Aggregator, exports ExportPaths) []string {
+	var results []string
+	report := func(filename string, err error) {
+		if err != nil {
+			logger.Error("export on quit failed", "file", filename, "error", err)
+			results = append(results, fmt.Sprintf("Export to %s failed: %v", filename, err))
+			return
+		}
+		logger.Info("exported on quit", "file", filename)
+		results = append(results, "Exported "+filename)
+	}
+	if q.json {
+		filename := exports.devices(".json")
+		report(filename, agg.ExportJSON(filename))
+	}
+	if q.kml {
+		filename := exports.devices(".kml")
+		report(filename, agg.ExportKML(filename))
+	}
+	return results
+}
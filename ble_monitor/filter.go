@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Minimum observation count used by the live toggle when -min-count isn't set
+const defaultMinCount = 2
+
+// DeviceFilter narrows the devices shown in the tables without touching the aggregator
+// The zero value shows everything
+type DeviceFilter struct {
+	mfrCode         int
+	mfrActive       bool
+	connectableOnly bool
+	namedOnly       bool
+	class           string // Device class from classifyDevice; "" shows all
+	protocol        string // Protocol reported by the firmware; "" shows all
+	minCount        int    // Threshold for the persistence filter; 0 means defaultMinCount
+	minCountActive  bool
+}
+
+// SetMfrCode shows only devices advertising the given manufacturer code
+func (f *DeviceFilter) SetMfrCode(code int) {
+	f.mfrCode = code
+	f.mfrActive = true
+}
+
+// ClearMfrCode removes the manufacturer code filter
+func (f *DeviceFilter) ClearMfrCode() {
+	f.mfrActive = false
+}
+
+// ToggleConnectableOnly switches between showing all devices and only those known to be connectable
+func (f *DeviceFilter) ToggleConnectableOnly() {
+	f.connectableOnly = !f.connectableOnly
+}
+
+// ToggleNamedOnly switches between showing all devices and only those advertising a name
+func (f *DeviceFilter) ToggleNamedOnly() {
+	f.namedOnly = !f.namedOnly
+}
+
+// SetMinCount hides devices observed fewer than count times; 0 or less turns the filter off
+func (f *DeviceFilter) SetMinCount(count int) {
+	f.minCount = count
+	f.minCountActive = count > 0
+}
+
+// ToggleMinCount switches the persistence filter on and off, keeping its threshold
+func (f *DeviceFilter) ToggleMinCount() {
+	if f.minCount <= 0 {
+		f.minCount = defaultMinCount
+	}
+	f.minCountActive = !f.minCountActive
+}
+
+// CycleClass steps the class filter through classes, then back to showing all
+func (f *DeviceFilter) CycleClass(classes []string) {
+	f.class = nextInCycle(classes, f.class)
+}
+
+// CycleProtocol steps the protocol filter through protocols, then back to showing all
+func (f *DeviceFilter) CycleProtocol(protocols []string) {
+	f.protocol = nextInCycle(protocols, f.protocol)
+}
+
+// nextInCycle returns the value after current in values, "" after the last
+// When current is "" or no longer present, it starts again from the first
+func nextInCycle(values []string, current string) string {
+	for i, value := range values {
+		if value == current {
+			if i+1 < len(values) {
+				return values[i+1]
+			}
+			return ""
+		}
+	}
+	if len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// IsActive reports whether any filter is in effect
+func (f *DeviceFilter) IsActive() bool {
+	return f.mfrActive || f.connectableOnly || f.namedOnly || f.class != "" || f.protocol != "" || f.minCountActive
+}
+
+// Match reports whether a device passes every active filter
+func (f *DeviceFilter) Match(dev *BLEDevice) bool {
+	if f.mfrActive && dev.MfrCode != f.mfrCode {
+		return false
+	}
+	// Devices whose firmware doesn't report connectability are hidden, since they can't be confirmed
+	if f.connectableOnly && (dev.Connectable == nil || !*dev.Connectable) {
+		return false
+	}
+	if f.namedOnly && dev.DeviceName == "" {
+		return false
+	}
+	if f.class != "" && classifyDevice(dev) != f.class {
+		return false
+	}
+	if f.protocol != "" && dev.Protocol != f.protocol {
+		return false
+	}
+	// Count only grows, so a one-off device stays hidden after it goes quiet
+	if f.minCountActive && dev.Count < f.minCount {
+		return false
+	}
+	return true
+}
+
+// String describes the active filters for the status line, e.g. "mfr=76, connectable, named, count>=3"
+func (f *DeviceFilter) String() string {
+	var parts []string
+	if f.mfrActive {
+		parts = append(parts, fmt.Sprintf("mfr=%d", f.mfrCode))
+	}
+	if f.connectableOnly {
+		parts = append(parts, "connectable")
+	}
+	if f.namedOnly {
+		parts = append(parts, "named")
+	}
+	if f.class != "" {
+		parts = append(parts, "class="+f.class)
+	}
+	if f.protocol != "" {
+		parts = append(parts, "proto="+f.protocol)
+	}
+	if f.minCountActive {
+		parts = append(parts, fmt.Sprintf("count>=%d", f.minCount))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Apply returns the devices in sorted that match the filter, preserving order
+// sorted itself is never modified
+func (f *DeviceFilter) Apply(sorted *SortedDevices) *SortedDevices {
+	if !f.IsActive() {
+		return sorted
+	}
+	filtered := *sorted
+	filtered.Recent = f.filterSlice(sorted.Recent)
+	filtered.Stale = f.filterSlice(sorted.Stale)
+	return &filtered
+}
+
+// filterSlice returns a new slice holding the matching devices
+func (f *DeviceFilter) filterSlice(devices []*BLEDevice) []*BLEDevice {
+	matched := make([]*BLEDevice, 0, len(devices))
+	for _, dev := range devices {
+		if f.Match(dev) {
+			matched = append(matched, dev)
+		}
+	}
+	return matched
+}
+
+// mfrCodeCount is a manufacturer code and how many devices advertise it
+type mfrCodeCount struct {
+	Code  int
+	Count int
+}
+
+// countMfrCodes tallies the manufacturer codes present across both tables, most common first
+func countMfrCodes(sorted *SortedDevices) []mfrCodeCount {
+	counts := make(map[int]int)
+	for _, devices := range [][]*BLEDevice{sorted.Recent, sorted.Stale} {
+		for _, dev := range devices {
+			counts[dev.MfrCode]++
+		}
+	}
+
+	result := make([]mfrCodeCount, 0, len(counts))
+	for code, count := range counts {
+		result = append(result, mfrCodeCount{Code: code, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"math"
+	"sort"
 	"sync"
 	"time"
 )
 
+// Mean Earth radius used for great-circle distances
+const earthRadiusMeters = 6371008.8
+
 // GeoLocation represents a geographic position with accuracy and timestamp
 type GeoLocation struct {
 	Latitude  float64
@@ -22,8 +27,11 @@ type RingBuffer[T any] struct {
 	size     int
 }
 
-// NewRingBuffer creates a new ring buffer with the given capacity
+// NewRingBuffer creates a new ring buffer with the given capacity (at least 1)
 func NewRingBuffer[T any](capacity int) *RingBuffer[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
 	return &RingBuffer[T]{
 		data:     make([]T, capacity),
 		capacity: capacity,
@@ -62,38 +70,99 @@ func (rb *RingBuffer[T]) GetAll() []T {
 	return result
 }
 
+// Peek returns up to n of the most recent items (oldest to newest)
+func (rb *RingBuffer[T]) Peek(n int) []T {
+	if n > rb.size {
+		n = rb.size
+	}
+	if n <= 0 {
+		return []T{}
+	}
+
+	result := make([]T, n)
+	// The newest item is just before head; start n items back from it
+	start := (rb.head - n + rb.capacity) % rb.capacity
+	for i := range result {
+		result[i] = rb.data[(start+i)%rb.capacity]
+	}
+	return result
+}
+
+// Latest returns the most recently pushed item, or false if the buffer is empty
+func (rb *RingBuffer[T]) Latest() (T, bool) {
+	if rb.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return rb.data[(rb.head-1+rb.capacity)%rb.capacity], true
+}
+
+// Clone returns an independent copy of the ring buffer
+func (rb *RingBuffer[T]) Clone() *RingBuffer[T] {
+	clone := *rb
+	clone.data = make([]T, len(rb.data))
+	copy(clone.data, rb.data)
+	return &clone
+}
+
 // Size returns the current number of items in the buffer
 func (rb *RingBuffer[T]) Size() int {
 	return rb.size
 }
 
-// RSSILocationMap maintains geo locations for ALL observed RSSI values
-// Each RSSI gets a ring buffer of up to 13 recent locations
+// RSSILocationMap maintains geo locations for the strongest observed RSSI values
+// Each RSSI gets a ring buffer of recent locations
 type RSSILocationMap struct {
 	mu          sync.RWMutex
 	data        map[int]*RingBuffer[GeoLocation]
 	allRSSIs    []int // All RSSIs sorted descending (highest first)
 	highestRSSI int   // Cached highest RSSI for quick access
+	topN        int   // Most RSSIs kept; 0 keeps every RSSI
+	capacity    int   // Locations kept per RSSI
 }
 
-// NewRSSILocationMap creates a new RSSI location map
-func NewRSSILocationMap() *RSSILocationMap {
+// Defaults for NewRSSILocationMap, overridable with -geo-top-n and -geo-capacity
+const (
+	defaultGeoTopN     = 3
+	defaultGeoCapacity = 3
+)
+
+// NewRSSILocationMap creates a new RSSI location map keeping the topN strongest RSSIs
+// (0 for all) with up to capacity locations each (defaultGeoCapacity if not positive)
+func NewRSSILocationMap(topN, capacity int) *RSSILocationMap {
+	if topN < 0 {
+		topN = 0
+	}
+	if capacity <= 0 {
+		capacity = defaultGeoCapacity
+	}
 	return &RSSILocationMap{
 		data:        make(map[int]*RingBuffer[GeoLocation]),
 		allRSSIs:    make([]int, 0),
 		highestRSSI: -2147483648, // Min int32
+		topN:        topN,
+		capacity:    capacity,
 	}
 }
 
 // Push adds a location for the given RSSI
-// All RSSIs are kept, not just top 3
+// When topN RSSIs are already kept, a new stronger RSSI evicts the weakest and a weaker one is dropped
 func (rlm *RSSILocationMap) Push(rssi int, loc GeoLocation) {
 	rlm.mu.Lock()
 	defer rlm.mu.Unlock()
 
 	// Create buffer if this RSSI doesn't exist yet
 	if _, exists := rlm.data[rssi]; !exists {
-		rlm.data[rssi] = NewRingBuffer[GeoLocation](13) // Capacity of 13 per RSSI
+		if rlm.topN > 0 && len(rlm.allRSSIs) >= rlm.topN {
+			weakest := rlm.allRSSIs[len(rlm.allRSSIs)-1]
+			if rssi < weakest {
+				return
+			}
+			delete(rlm.data, weakest)
+			rlm.allRSSIs = rlm.allRSSIs[:len(rlm.allRSSIs)-1]
+		}
+
+		rlm.data[rssi] = NewRingBuffer[GeoLocation](rlm.capacity)
 
 		// Add to sorted list
 		// Find insertion position
@@ -120,6 +189,46 @@ func (rlm *RSSILocationMap) Push(rssi int, loc GeoLocation) {
 	rlm.data[rssi].Push(loc)
 }
 
+// Snapshot returns a deep copy taken under the map's lock, unaffected by later pushes
+func (rlm *RSSILocationMap) Snapshot() *RSSILocationMap {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	snapshot := &RSSILocationMap{
+		data:        make(map[int]*RingBuffer[GeoLocation], len(rlm.data)),
+		allRSSIs:    make([]int, len(rlm.allRSSIs)),
+		highestRSSI: rlm.highestRSSI,
+		topN:        rlm.topN,
+		capacity:    rlm.capacity,
+	}
+	copy(snapshot.allRSSIs, rlm.allRSSIs)
+	for rssi, buffer := range rlm.data {
+		snapshot.data[rssi] = buffer.Clone()
+	}
+	return snapshot
+}
+
+// RSSIs returns every RSSI with stored locations, strongest first
+func (rlm *RSSILocationMap) RSSIs() []int {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	rssis := make([]int, len(rlm.allRSSIs))
+	copy(rssis, rlm.allRSSIs)
+	return rssis
+}
+
+// LocationsAt returns the locations stored for one RSSI (oldest first), or nil if there are none
+func (rlm *RSSILocationMap) LocationsAt(rssi int) []GeoLocation {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	if buffer := rlm.data[rssi]; buffer != nil && buffer.Size() > 0 {
+		return buffer.GetAll()
+	}
+	return nil
+}
+
 // GetLocation returns the mean location of all entries in the highest RSSI's buffer
 // If the highest RSSI has no data, falls back to the next available RSSI
 // Returns nil if no location data exists at all
@@ -145,35 +254,192 @@ func (rlm *RSSILocationMap) GetLocation() *GeoLocation {
 			continue // Try next RSSI
 		}
 
-		// Calculate means
-		var sumLat, sumLon, sumEl, sumAcc float64
-		for _, loc := range locations {
-			sumLat += loc.Latitude
-			sumLon += loc.Longitude
-			sumEl += loc.Elevation
-			sumAcc += loc.Accuracy
+		mean := meanLocation(locations)
+		return &mean
+	}
+
+	// No RSSI has any location data
+	return nil
+}
+
+// GetAllLocations returns every stored location across all RSSIs in chronological order
+func (rlm *RSSILocationMap) GetAllLocations() []GeoLocation {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	var locations []GeoLocation
+	for _, rssi := range rlm.allRSSIs {
+		if buffer := rlm.data[rssi]; buffer != nil {
+			locations = append(locations, buffer.GetAll()...)
+		}
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].Timestamp.Before(locations[j].Timestamp)
+	})
+	return locations
+}
+
+// RSSIByLocation maps every stored location to the RSSI it was recorded at
+func (rlm *RSSILocationMap) RSSIByLocation() map[GeoLocation]int {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	rssiAt := make(map[GeoLocation]int)
+	for rssi, buffer := range rlm.data {
+		for _, loc := range buffer.GetAll() {
+			rssiAt[loc] = rssi
 		}
+	}
+	return rssiAt
+}
+
+// Path-loss model used by EstimatePosition
+const (
+	pathLossRefRSSI  = -59.0 // Typical RSSI at 1 m from a BLE advertiser
+	pathLossExponent = 2.0   // Free-space propagation
+)
+
+// EstimatePosition returns a best-guess device position as a weighted centroid of every stored location
+// Each location is weighted by the inverse square of the distance implied by its RSSI under a
+// log-distance path-loss model: d = 10^((refRSSI - rssi) / (10 * n)), with refRSSI -59 dBm at 1 m and n = 2.
+// Stronger readings therefore dominate, while weaker ones still pull the estimate toward the side they
+// were taken on, which helps when a stationary device was circled. This is an experimental estimate:
+// real-world attenuation varies widely, so treat it as a rough placement rather than a fix.
+// Returns nil if no location data exists
+func (rlm *RSSILocationMap) EstimatePosition() *GeoLocation {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
 
-		count := float64(len(locations))
-		return &GeoLocation{
-			Latitude:  sumLat / count,
-			Longitude: sumLon / count,
-			Elevation: sumEl / count,
-			Accuracy:  sumAcc / count,
-			// Timestamp is omitted (not averaged)
+	var sumWeight, sumLat, sumLon, sumEl float64
+	for _, rssi := range rlm.allRSSIs {
+		buffer := rlm.data[rssi]
+		if buffer == nil || buffer.Size() == 0 {
+			continue
+		}
+
+		distance := rssiToMeters(rssi, pathLossRefRSSI)
+		weight := 1 / (distance * distance)
+		for _, loc := range buffer.GetAll() {
+			sumWeight += weight
+			sumLat += weight * loc.Latitude
+			sumLon += weight * loc.Longitude
+			sumEl += weight * loc.Elevation
 		}
 	}
 
-	// No RSSI has any location data
-	return nil
+	if sumWeight == 0 {
+		return nil
+	}
+	return &GeoLocation{
+		Latitude:  sumLat / sumWeight,
+		Longitude: sumLon / sumWeight,
+		Elevation: sumEl / sumWeight,
+	}
 }
 
+// rssiToMeters estimates the distance implied by rssi under the log-distance path-loss model,
+// given txPower, the RSSI expected at 1 m. Only a rough range: walls, bodies and antennas shift it a lot
+func rssiToMeters(rssi, txPower int) float64 {
+	return math.Pow(10, float64(txPower-rssi)/(10*pathLossExponent))
+}
+
+// RSSILocation pairs an RSSI value with the mean location observed at that strength
+type RSSILocation struct {
+	RSSI     int
+	Location GeoLocation
+	Samples  int
+}
+
+// GetTopLocations returns the mean location for each of the n strongest RSSIs that have data
+func (rlm *RSSILocationMap) GetTopLocations(n int) []RSSILocation {
+	rlm.mu.RLock()
+	defer rlm.mu.RUnlock()
+
+	result := make([]RSSILocation, 0, n)
+	for _, rssi := range rlm.allRSSIs {
+		if len(result) >= n {
+			break
+		}
+
+		buffer := rlm.data[rssi]
+		if buffer == nil || buffer.Size() == 0 {
+			continue
+		}
+
+		locations := buffer.GetAll()
+		result = append(result, RSSILocation{
+			RSSI:     rssi,
+			Location: meanLocation(locations),
+			Samples:  len(locations),
+		})
+	}
+
+	return result
+}
+
+// meanLocation averages position, elevation, and accuracy over a non-empty set of locations
+// Timestamp is omitted (not averaged)
+func meanLocation(locations []GeoLocation) GeoLocation {
+	var sumLat, sumLon, sumEl, sumAcc float64
+	for _, loc := range locations {
+		sumLat += loc.Latitude
+		sumLon += loc.Longitude
+		sumEl += loc.Elevation
+		sumAcc += loc.Accuracy
+	}
+
+	count := float64(len(locations))
+	return GeoLocation{
+		Latitude:  sumLat / count,
+		Longitude: sumLon / count,
+		Elevation: sumEl / count,
+		Accuracy:  sumAcc / count,
+	}
+}
+
+// haversineMeters returns the great-circle distance between two locations in meters
+func haversineMeters(a, b GeoLocation) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// pathLengthMeters returns the cumulative distance along the locations in order
+func pathLengthMeters(locations []GeoLocation) float64 {
+	total := 0.0
+	for i := 1; i < len(locations); i++ {
+		total += haversineMeters(locations[i-1], locations[i])
+	}
+	return total
+}
+
+// spreadMeters returns the largest distance between any two of the locations
+func spreadMeters(locations []GeoLocation) float64 {
+	spread := 0.0
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			spread = math.Max(spread, haversineMeters(locations[i], locations[j]))
+		}
+	}
+	return spread
+}
+
+// Number of GPS fixes retained for track export (one day at 1 Hz)
+const gpsTrackCapacity = 86400
+
 // LocationState manages the current GPS/GNSS location in a thread-safe manner
 type LocationState struct {
 	mu                    sync.RWMutex
 	current               *GeoLocation
+	track                 *RingBuffer[GeoLocation] // History of valid fixes for GPX export
 	lastUpdate            time.Time
 	fixQuality            int    // 0 = no fix, 1 = GPS fix, 2 = DGPS fix, etc.
+	fixSource             string // NMEA sentence that set the current fix: fixSourceGGA, fixSourceGNS or fixSourceRMC
 	satellites            int    // Number of satellites in use
 	satellitesInView      int    // Number of satellites in view (total across all constellations)
 	status                string // "detecting", "failed", "no_fix", "fix"
@@ -183,40 +449,217 @@ type LocationState struct {
 	gpsReconnectDismissed bool   // Whether the GPS reconnection modal has been dismissed
 	gpsLastDisconnectTime time.Time
 	gpsReconnectAttempts  int
+	speedKPH              float64       // Ground speed from VTG
+	course                float64       // True course over ground in degrees from VTG
+	velocityUpdate        time.Time     // When speed/course were last reported
+	fixMaxAge             time.Duration // A fix older than this is stale and not handed out (0 = never stale)
+	maxHDOP               float64       // Positions with a higher HDOP are discarded (0 = no limit)
+	hdop                  float64       // Most recent HDOP reported by GGA/GNS
+	hdopRejected          bool          // Whether the most recent HDOP exceeded maxHDOP
+	fixType               string        // From GSA: "1" no fix, "2" 2D, "3" 3D; "" until a GSA arrives
+	pdop                  float64       // Position dilution of precision from GSA
+	vdop                  float64       // Vertical dilution of precision from GSA
+	peakInView            int           // Most satellites in view since the receiver connected
+
+	gpsPaused bool // Fixes are not attached to devices; GPS status and the track carry on
 }
 
+// NMEA sentences a fix can come from
+const (
+	fixSourceGGA = "GGA"
+	fixSourceGNS = "GNS"
+	fixSourceRMC = "RMC" // Position only; no elevation or HDOP
+)
+
+// How long a VTG speed/course reading is shown before it is considered stale
+const velocityMaxAge = 5 * time.Second
+
+// Default for how long a fix is used after the receiver last reported one
+const defaultFixMaxAge = 5 * time.Second
+
 // NewLocationState creates a new location state manager
 func NewLocationState() *LocationState {
 	return &LocationState{
-		status: "no_gps", // Default: no GPS device configured
+		status:    "no_gps", // Default: no GPS device configured
+		track:     NewRingBuffer[GeoLocation](gpsTrackCapacity),
+		fixMaxAge: defaultFixMaxAge,
 	}
 }
 
-// SetCurrent updates the current location
-func (ls *LocationState) SetCurrent(loc *GeoLocation, fixQuality int, satellites int, satellitesInView int) {
+// SetFixMaxAge sets how long a fix stays current without an update; 0 never expires it
+func (ls *LocationState) SetFixMaxAge(maxAge time.Duration) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.fixMaxAge = maxAge
+}
+
+// SetCurrent updates the current location, recording which sentence type it came from
+func (ls *LocationState) SetCurrent(loc *GeoLocation, source string, fixQuality int, satellites int, satellitesInView int) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
 
 	ls.current = loc
 	ls.lastUpdate = time.Now()
+	ls.fixSource = source
 	ls.fixQuality = fixQuality
 	ls.satellites = satellites
 	ls.satellitesInView = satellitesInView
 
 	if fixQuality > 0 {
 		ls.status = "fix"
+		if loc != nil {
+			ls.track.Push(*loc)
+		}
 	} else {
 		ls.status = "no_fix"
 	}
 }
 
-// GetCurrent returns the current location (or nil if none)
+// SetMaxHDOP sets the HDOP above which positions are discarded; 0 accepts any
+func (ls *LocationState) SetMaxHDOP(maxHDOP float64) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.maxHDOP = maxHDOP
+}
+
+// ToggleGPSPaused stops or resumes attaching fixes to devices and returns whether it is now paused
+// Used while moving between sites, so transit positions don't end up in device geo data
+func (ls *LocationState) ToggleGPSPaused() bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.gpsPaused = !ls.gpsPaused
+	return ls.gpsPaused
+}
+
+// IsGPSPaused returns whether fixes are currently kept off devices
+func (ls *LocationState) IsGPSPaused() bool {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.gpsPaused
+}
+
+// AcceptHDOP records the receiver's HDOP and reports whether positions that precise should be used
+// A rejected HDOP puts the status into "poor_fix"
+func (ls *LocationState) AcceptHDOP(hdop float64) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.hdop = hdop
+	ls.hdopRejected = ls.maxHDOP > 0 && hdop > ls.maxHDOP
+	if ls.hdopRejected {
+		ls.status = "poor_fix"
+	}
+	return !ls.hdopRejected
+}
+
+// SetSatellitesInView records the satellites in view reported by GSV, with or without a fix
+func (ls *LocationState) SetSatellitesInView(count int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.satellitesInView = count
+	if count > ls.peakInView {
+		ls.peakInView = count
+	}
+}
+
+// SetGSA records the fix type and dilution of precision values from a GSA sentence
+func (ls *LocationState) SetGSA(fixType string, pdop, vdop float64) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.fixType = fixType
+	ls.pdop = pdop
+	ls.vdop = vdop
+}
+
+// GetDOP returns the GSA fix type ("" if none seen) with its PDOP and VDOP
+func (ls *LocationState) GetDOP() (fixType string, pdop, vdop float64) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.fixType, ls.pdop, ls.vdop
+}
+
+// NoFixReason describes why there is no fix, so the user knows whether to wait or move
+// Satellites in view dropping to zero after some were seen usually means the antenna is lost or blocked
+func (ls *LocationState) NoFixReason() string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	switch {
+	case ls.satellitesInView > 0:
+		return "Acquiring"
+	case ls.peakInView > 0:
+		return "Antenna?"
+	default:
+		return "No Fix (0 SV)"
+	}
+}
+
+// GetHDOP returns the most recent HDOP, the configured limit, and whether positions are currently rejected
+func (ls *LocationState) GetHDOP() (hdop, maxHDOP float64, rejected bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.hdop, ls.maxHDOP, ls.hdopRejected
+}
+
+// GetCurrent returns the current location, or nil if there is none or it is stale
+// A stale fix is withheld so devices aren't tagged where the receiver last had signal
 func (ls *LocationState) GetCurrent() *GeoLocation {
 	ls.mu.RLock()
 	defer ls.mu.RUnlock()
+	if ls.isStaleLocked() {
+		return nil
+	}
 	return ls.current
 }
 
+// FixAge reports how long ago the last fix arrived and whether it is now stale
+func (ls *LocationState) FixAge() (age time.Duration, stale bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	if ls.current == nil {
+		return 0, false
+	}
+	return time.Since(ls.lastUpdate), ls.isStaleLocked()
+}
+
+// isStaleLocked reports whether the current fix is older than fixMaxAge
+// Caller must hold ls.mu
+func (ls *LocationState) isStaleLocked() bool {
+	return ls.current != nil && ls.fixMaxAge > 0 && time.Since(ls.lastUpdate) > ls.fixMaxAge
+}
+
+// SetVelocity records the current ground speed (km/h) and true course (degrees)
+func (ls *LocationState) SetVelocity(speedKPH, course float64) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.speedKPH = speedKPH
+	ls.course = course
+	ls.velocityUpdate = time.Now()
+}
+
+// GetVelocity returns the current ground speed (km/h) and true course (degrees)
+// Returns false if no recent reading is available
+func (ls *LocationState) GetVelocity() (speedKPH float64, course float64, ok bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	if ls.velocityUpdate.IsZero() || time.Since(ls.velocityUpdate) > velocityMaxAge {
+		return 0, 0, false
+	}
+	return ls.speedKPH, ls.course, true
+}
+
+// GetTrack returns all retained fixes (oldest to newest)
+func (ls *LocationState) GetTrack() []GeoLocation {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.track.GetAll()
+}
+
+// GetFixSource returns the NMEA sentence type that set the current fix, or "" before the first fix
+func (ls *LocationState) GetFixSource() string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.fixSource
+}
+
 // GetStatus returns the current GPS status and details
 func (ls *LocationState) GetStatus() (status string, fixQuality int, satellites int, satellitesInView int, lastUpdate time.Time) {
 	ls.mu.RLock()
@@ -260,9 +703,10 @@ func (ls *LocationState) SetGPSConnected(connected bool) {
 		ls.gpsReconnectAttempts = 0
 		ls.gpsReconnectDismissed = false
 	} else if connected && !wasConnected {
-		// Just reconnected
+		// Just reconnected; satellite history starts over with the new connection
 		ls.gpsReconnecting = false
 		ls.gpsReconnectAttempts = 0
+		ls.peakInView = 0
 	}
 }
 
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRSSILocationMapPush(t *testing.T) {
+	tests := []struct {
+		name      string
+		topN      int
+		capacity  int
+		pushes    []int         // RSSI of each push; the location's latitude is its index
+		rssis     []int         // RSSIs() afterwards, strongest first
+		locations map[int][]int // Latitudes kept per RSSI, oldest first; nil where none are
+	}{
+		{
+			name:      "stronger evicts weakest",
+			topN:      3,
+			capacity:  3,
+			pushes:    []int{-60, -70, -80, -50},
+			rssis:     []int{-50, -60, -70},
+			locations: map[int][]int{-50: {3}, -60: {0}, -70: {1}, -80: nil},
+		},
+		{
+			name:      "weaker dropped when full",
+			topN:      3,
+			capacity:  3,
+			pushes:    []int{-60, -70, -80, -90},
+			rssis:     []int{-60, -70, -80},
+			locations: map[int][]int{-80: {2}, -90: nil},
+		},
+		{
+			name:      "kept RSSI still accepts locations when full",
+			topN:      2,
+			capacity:  3,
+			pushes:    []int{-60, -70, -70, -90, -70},
+			rssis:     []int{-60, -70},
+			locations: map[int][]int{-60: {0}, -70: {1, 2, 4}, -90: nil},
+		},
+		{
+			name:      "topN 0 keeps everything",
+			topN:      0,
+			capacity:  3,
+			pushes:    []int{-90, -50, -70, -80, -60},
+			rssis:     []int{-50, -60, -70, -80, -90},
+			locations: map[int][]int{-90: {0}, -50: {1}},
+		},
+		{
+			name:      "capacity bound per RSSI",
+			topN:      3,
+			capacity:  3,
+			pushes:    []int{-60, -70, -60, -60, -70, -60, -60},
+			rssis:     []int{-60, -70},
+			locations: map[int][]int{-60: {3, 5, 6}, -70: {1, 4}},
+		},
+		{
+			name:      "capacity 1",
+			topN:      3,
+			capacity:  1,
+			pushes:    []int{-60, -60, -60},
+			rssis:     []int{-60},
+			locations: map[int][]int{-60: {2}},
+		},
+		{
+			name:      "defaults",
+			topN:      defaultGeoTopN,
+			capacity:  defaultGeoCapacity,
+			pushes:    []int{-60, -60, -60, -60, -70, -80, -50},
+			rssis:     []int{-50, -60, -70},
+			locations: map[int][]int{-60: {1, 2, 3}, -80: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rlm := NewRSSILocationMap(tt.topN, tt.capacity)
+			for i, rssi := range tt.pushes {
+				rlm.Push(rssi, GeoLocation{Latitude: float64(i)})
+			}
+
+			if got := rlm.RSSIs(); !slices.Equal(got, tt.rssis) {
+				t.Errorf("RSSIs() = %v, want %v", got, tt.rssis)
+			}
+			for rssi, want := range tt.locations {
+				var got []int
+				for _, loc := range rlm.LocationsAt(rssi) {
+					got = append(got, int(loc.Latitude))
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("LocationsAt(%d) = %v, want %v", rssi, got, want)
+				}
+			}
+			for _, rssi := range rlm.RSSIs() {
+				if n := len(rlm.LocationsAt(rssi)); n > rlm.capacity {
+					t.Errorf("RSSI %d keeps %d locations, over capacity %d", rssi, n, rlm.capacity)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"io"
+	"path/filepath"
 	"time"
 
 	"github.com/adrianmo/go-nmea"
@@ -12,6 +13,18 @@ import (
 // GPS baud rates to try, in order of likelihood
 var gpsBaudRates = []int{9600, 115200, 38400, 4800}
 
+// sameDevicePath reports whether two serial device paths refer to the same device
+// Symlinks (e.g. /dev/serial/by-id/...) are resolved before comparing
+func sameDevicePath(a, b string) bool {
+	resolve := func(path string) string {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return resolved
+		}
+		return filepath.Clean(path)
+	}
+	return resolve(a) == resolve(b)
+}
+
 // autoBaudDetect attempts to detect the correct baud rate for the GPS device
 // Returns the detected baud rate, or 0 if detection failed
 func autoBaudDetect(portPath string) int {
@@ -91,12 +104,14 @@ func readGPS(portPath string, locState *LocationState, done <-chan struct{}) {
 	baudRate := autoBaudDetect(portPath)
 	if baudRate == 0 {
 		// Detection failed
+		logger.Error("GPS baud rate detection failed", "port", portPath, "tried", gpsBaudRates)
 		locState.SetStatus("failed")
 		return
 	}
+	logger.Info("GPS baud rate detected", "port", portPath, "baud", baudRate)
 
 	// Reconnection logic with exponential backoff
-	reconnectDelay := 1 * time.Second
+	reconnectDelay := initialReconnectDelay
 	maxReconnectDelay := 5 * time.Second
 
 	for {
@@ -113,6 +128,7 @@ func readGPS(portPath string, locState *LocationState, done <-chan struct{}) {
 		port, err = openGPSPort(portPath, baudRate)
 		if err != nil {
 			// Failed to open, increment reconnect attempt
+			logger.Warn("GPS port open failed", "port", portPath, "error", err)
 			locState.SetGPSReconnectAttempt()
 			locState.SetGPSConnected(false)
 			locState.SetStatus("no_fix")
@@ -121,22 +137,19 @@ func readGPS(portPath string, locState *LocationState, done <-chan struct{}) {
 			case <-done:
 				return
 			case <-time.After(reconnectDelay):
-				// Linear backoff
-				reconnectDelay += 1 * time.Second
-				if reconnectDelay > maxReconnectDelay {
-					reconnectDelay = maxReconnectDelay
-				}
+				reconnectDelay = nextReconnectDelay(reconnectDelay, maxReconnectDelay)
 			}
 			continue
 		}
 
 		// Successfully opened
+		logger.Info("GPS port connected", "port", portPath, "baud", baudRate)
 		locState.SetGPSConnected(true)
 		locState.SetStatus("no_fix")
-		reconnectDelay = 1 * time.Second // Reset backoff
+		reconnectDelay = initialReconnectDelay // Reset backoff
 
 		// Read from the port until error or done
-		err = readGPSLoop(port, locState, done)
+		err = readGPSLoop(port, locState, done, nil)
 
 		// Close the port
 		port.Close()
@@ -149,6 +162,7 @@ func readGPS(portPath string, locState *LocationState, done <-chan struct{}) {
 		}
 
 		// Connection lost, mark as disconnected
+		logger.Warn("GPS connection lost", "port", portPath, "error", err)
 		locState.SetGPSConnected(false)
 		locState.SetStatus("no_fix")
 
@@ -162,13 +176,13 @@ func readGPS(portPath string, locState *LocationState, done <-chan struct{}) {
 }
 
 // readGPSLoop performs the actual GPS reading and processing
-func readGPSLoop(port io.Reader, locState *LocationState, done <-chan struct{}) error {
+// pacer is nil for live receivers and set when playing back a recording
+func readGPSLoop(port io.Reader, locState *LocationState, done <-chan struct{}, pacer *nmeaPacer) error {
 	scanner := bufio.NewScanner(port)
 	scanner.Buffer(make([]byte, 4096), 16384)
 
-	// Track satellites in view from GSV messages
-	// We need to accumulate across multiple GSV sentences
-	gsvSatellitesInView := 0
+	// Per-connection parser state (satellites in view, which position sentences are present)
+	var nmeaState nmeaReaderState
 
 	for {
 		select {
@@ -177,7 +191,10 @@ func readGPSLoop(port io.Reader, locState *LocationState, done <-chan struct{})
 		default:
 			if scanner.Scan() {
 				line := scanner.Text()
-				parseNMEASentence(line, locState, &gsvSatellitesInView)
+				if pacer != nil && !pacer.wait(line, done) {
+					return nil
+				}
+				parseNMEASentence(line, locState, &nmeaState)
 			} else {
 				// Error or EOF
 				if err := scanner.Err(); err != nil {
@@ -191,8 +208,28 @@ func readGPSLoop(port io.Reader, locState *LocationState, done <-chan struct{})
 	}
 }
 
+// GNS positions are ignored while GGA has been seen within this window,
+// and RMC positions while GGA or GNS has produced a fix within it
+const ggaPreferenceWindow = 5 * time.Second
+
+// nmeaReaderState carries state across the sentences of one GPS connection
+type nmeaReaderState struct {
+	satellitesInView map[string]int // From GSV, per talker ID (GP, GL, GA, GB, ...)
+	lastGGA          time.Time      // When a GGA sentence was last handled
+	lastFullFix      time.Time      // When GGA or GNS last set the current fix (with elevation and HDOP)
+}
+
+// totalSatellitesInView sums satellites in view across all constellations
+func (st *nmeaReaderState) totalSatellitesInView() int {
+	total := 0
+	for _, count := range st.satellitesInView {
+		total += count
+	}
+	return total
+}
+
 // parseNMEASentence parses an NMEA sentence and updates location state
-func parseNMEASentence(line string, locState *LocationState, gsvSatellitesInView *int) {
+func parseNMEASentence(line string, locState *LocationState, state *nmeaReaderState) {
 	s, err := nmea.Parse(line)
 	if err != nil {
 		// Ignore malformed sentences
@@ -204,29 +241,62 @@ func parseNMEASentence(line string, locState *LocationState, gsvSatellitesInView
 	case nmea.GGA:
 		// GGA: Global Positioning System Fix Data
 		// Preferred for elevation data
-		handleGGA(m, locState, *gsvSatellitesInView)
+		state.lastGGA = time.Now()
+		if handleGGA(m, locState, state.totalSatellitesInView()) {
+			state.lastFullFix = state.lastGGA
+		}
+
+	case nmea.GNS:
+		// GNS: multi-constellation fix data
+		// Some receivers emit this instead of GGA; only use it when GGA is absent
+		if time.Since(state.lastGGA) > ggaPreferenceWindow {
+			if handleGNS(m, locState, state.totalSatellitesInView()) {
+				state.lastFullFix = time.Now()
+			}
+		}
 
 	case nmea.RMC:
 		// RMC: Recommended Minimum Navigation Information
-		// Use as fallback if GGA not available
-		handleRMC(m, locState, *gsvSatellitesInView)
+		// Receivers send it alongside GGA each second; it has no elevation or HDOP, so it only
+		// fills in when GGA/GNS hasn't produced a fix lately rather than zeroing a fresh one
+		if time.Since(state.lastFullFix) > ggaPreferenceWindow {
+			handleRMC(m, locState, state.totalSatellitesInView())
+		}
+
+	case nmea.VTG:
+		// VTG: Course and speed over ground
+		handleVTG(m, locState)
 
 	case nmea.GSV:
 		// GSV: Satellites in View
 		// Track total satellites in view across all constellations
-		handleGSV(m, gsvSatellitesInView)
+		if state.satellitesInView == nil {
+			state.satellitesInView = make(map[string]int)
+		}
+		handleGSV(m, state.satellitesInView)
+		locState.SetSatellitesInView(state.totalSatellitesInView())
+
+	case nmea.GSA:
+		// GSA: fix type and dilution of precision
+		handleGSA(m, locState)
 	}
 }
 
 // handleGGA processes a GGA sentence (position, elevation, fix quality)
-func handleGGA(gga nmea.GGA, locState *LocationState, satellitesInView int) {
+// Returns whether it set the current fix
+func handleGGA(gga nmea.GGA, locState *LocationState, satellitesInView int) bool {
 	// Parse fix quality
 	fixQuality := parseFixQuality(gga.FixQuality)
 
 	if fixQuality == 0 {
 		// No fix
 		locState.SetStatus("no_fix")
-		return
+		return false
+	}
+
+	// Discard imprecise positions rather than tag devices with them
+	if !locState.AcceptHDOP(gga.HDOP) {
+		return false
 	}
 
 	// Valid fix - create GeoLocation
@@ -238,7 +308,49 @@ func handleGGA(gga nmea.GGA, locState *LocationState, satellitesInView int) {
 		Timestamp: time.Now().UTC(),
 	}
 
-	locState.SetCurrent(loc, fixQuality, int(gga.NumSatellites), satellitesInView)
+	locState.SetCurrent(loc, fixSourceGGA, fixQuality, int(gga.NumSatellites), satellitesInView)
+	return true
+}
+
+// handleGNS processes a GNS sentence (combined-constellation position, elevation, satellites)
+// Returns whether it set the current fix
+func handleGNS(gns nmea.GNS, locState *LocationState, satellitesInView int) bool {
+	fixQuality := gnsFixQuality(gns.Mode)
+
+	if fixQuality == 0 {
+		// No fix on any constellation
+		locState.SetStatus("no_fix")
+		return false
+	}
+
+	if !locState.AcceptHDOP(gns.HDOP) {
+		return false
+	}
+
+	loc := &GeoLocation{
+		Latitude:  gns.Latitude,
+		Longitude: gns.Longitude,
+		Elevation: gns.Altitude,
+		Accuracy:  gns.HDOP,
+		Timestamp: time.Now().UTC(),
+	}
+
+	locState.SetCurrent(loc, fixSourceGNS, fixQuality, int(gns.SVs), satellitesInView)
+	return true
+}
+
+// handleVTG processes a VTG sentence (course and ground speed)
+func handleVTG(vtg nmea.VTG, locState *LocationState) {
+	// Mode "N" means the data is not valid
+	if vtg.FFAMode == "N" {
+		return
+	}
+
+	speedKPH := vtg.GroundSpeedKPH
+	if speedKPH == 0 && vtg.GroundSpeedKnots != 0 {
+		speedKPH = vtg.GroundSpeedKnots * 1.852
+	}
+	locState.SetVelocity(speedKPH, vtg.TrueTrack)
 }
 
 // handleRMC processes an RMC sentence (position, speed, date)
@@ -249,6 +361,11 @@ func handleRMC(rmc nmea.RMC, locState *LocationState, satellitesInView int) {
 		return
 	}
 
+	// RMC carries no HDOP; don't let it bypass a rejection from GGA/GNS
+	if _, _, rejected := locState.GetHDOP(); rejected {
+		return
+	}
+
 	// RMC doesn't have elevation or satellites, so use 0/unknown
 	loc := &GeoLocation{
 		Latitude:  rmc.Latitude,
@@ -259,22 +376,58 @@ func handleRMC(rmc nmea.RMC, locState *LocationState, satellitesInView int) {
 	}
 
 	// Set with minimal fix quality (1 = GPS fix) and unknown satellite counts
-	locState.SetCurrent(loc, 1, 0, satellitesInView)
+	locState.SetCurrent(loc, fixSourceRMC, 1, 0, satellitesInView)
+}
+
+// handleGSA processes a GSA sentence (fix type, PDOP, VDOP)
+// Multi-constellation receivers send one per system; the best fix type in a burst is not tracked,
+// so the value shown is from the most recent sentence
+func handleGSA(gsa nmea.GSA, locState *LocationState) {
+	locState.SetGSA(gsa.FixType, gsa.PDOP, gsa.VDOP)
 }
 
 // handleGSV processes a GSV sentence (satellites in view)
-func handleGSV(gsv nmea.GSV, gsvSatellitesInView *int) {
+// Each constellation (talker ID) sends its own GSV sequence; counts are kept per talker
+func handleGSV(gsv nmea.GSV, satellitesInView map[string]int) {
 	// GSV sentences come in multiple messages
 	// TotalMessages tells us how many total messages
 	// MessageNumber tells us which message this is
 	// NumberSVsInView is only present in the first message
 
-	// If this is the first message of a new sequence, reset the counter
+	// If this is the first message of a new sequence, reset this constellation's count
 	if gsv.MessageNumber == 1 {
-		*gsvSatellitesInView = int(gsv.NumberSVsInView)
+		satellitesInView[gsv.Talker] = int(gsv.NumberSVsInView)
 	}
 	// Note: We don't need to accumulate across messages because
-	// NumberSVsInView in the first message already gives us the total
+	// NumberSVsInView in the first message already gives us the constellation's total
+}
+
+// gnsFixQuality converts GNS per-constellation mode indicators to a GGA-style fix quality
+// The best mode reported by any constellation wins
+func gnsFixQuality(modes []string) int {
+	best := 0
+	for _, mode := range modes {
+		quality := 0
+		switch mode {
+		case nmea.AutonomousGNS:
+			quality = 1 // GPS fix
+		case nmea.DifferentialGNS:
+			quality = 2 // DGPS fix
+		case nmea.PreciseGNS:
+			quality = 3 // PPS fix
+		case nmea.RealTimeKinematicGNS:
+			quality = 4 // RTK fix
+		case nmea.FloatRTKGNS:
+			quality = 5 // RTK float
+		case nmea.EstimatedGNS:
+			quality = 6 // Estimated
+		}
+		// Any real fix beats estimated
+		if quality != 0 && (best == 0 || best == 6 || (quality != 6 && quality > best)) {
+			best = quality
+		}
+	}
+	return best
 }
 
 // parseFixQuality converts NMEA fix quality string to integer
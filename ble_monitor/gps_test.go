@@ -0,0 +1,249 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Sentences recorded from receivers, with valid checksums
+const (
+	nmeaGGAFix   = "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"
+	nmeaGGANoFix = "$GPGGA,123520,,,,,0,00,,,M,,M,,*61"
+	nmeaGNSFix   = "$GNGNS,014035.00,4332.69262,S,17235.48549,E,AA,13,0.9,25.63,11.24,,*70"
+	nmeaGNSNoFix = "$GNGNS,014036.00,,,,,NN,00,,,,,*7D"
+	nmeaRMCFix   = "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A"
+	nmeaRMCVoid  = "$GPRMC,123521,V,,,,,,,230394,,*38"
+	nmeaVTG      = "$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K,A*25"
+	nmeaVTGVoid  = "$GPVTG,,T,,M,,N,,K,N*2C"
+	nmeaGSA      = "$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39"
+	nmeaGPGSV1   = "$GPGSV,2,1,08,01,40,083,46,02,17,308,41,12,07,344,39,14,22,228,45*75"
+	nmeaGPGSV2   = "$GPGSV,2,2,08,15,40,083,46,16,17,308,41,17,07,344,39,18,22,228,45*7F"
+	nmeaGLGSV    = "$GLGSV,1,1,03,65,40,083,46,66,17,308,41,67,07,344,39*5F"
+
+	// nmeaGGAFix with the last checksum digit changed
+	nmeaGGABadChecksum = "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48"
+)
+
+// feedNMEA parses sentences in order into a fresh LocationState, as one GPS connection would
+func feedNMEA(sentences ...string) *LocationState {
+	locState := NewLocationState()
+	state := &nmeaReaderState{}
+	for _, line := range sentences {
+		parseNMEASentence(line, locState, state)
+	}
+	return locState
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestParseNMEASentenceFix(t *testing.T) {
+	tests := []struct {
+		name       string
+		sentences  []string
+		fixQuality int
+		source     string
+		satellites int
+		lat, lon   float64
+		elevation  float64
+		hdop       float64
+	}{
+		{
+			name:       "GGA",
+			sentences:  []string{nmeaGGAFix},
+			fixQuality: 1,
+			source:     fixSourceGGA,
+			satellites: 8,
+			lat:        48.1173,
+			lon:        11.516666666666667,
+			elevation:  545.4,
+			hdop:       0.9,
+		},
+		{
+			name:       "GNS",
+			sentences:  []string{nmeaGNSFix},
+			fixQuality: 1,
+			source:     fixSourceGNS,
+			satellites: 13,
+			lat:        -43.54487700000001,
+			lon:        172.5914248333333,
+			elevation:  25.63,
+			hdop:       0.9,
+		},
+		{
+			name:       "RMC",
+			sentences:  []string{nmeaRMCFix},
+			fixQuality: 1,
+			source:     fixSourceRMC,
+			lat:        48.1173,
+			lon:        11.516666666666667,
+		},
+		{
+			// RMC is sent alongside GGA and must not replace its elevation and HDOP
+			name:       "RMC after GGA",
+			sentences:  []string{nmeaGGAFix, nmeaRMCFix},
+			fixQuality: 1,
+			source:     fixSourceGGA,
+			satellites: 8,
+			lat:        48.1173,
+			lon:        11.516666666666667,
+			elevation:  545.4,
+			hdop:       0.9,
+		},
+		{
+			// GNS is ignored while GGA is being received
+			name:       "GNS after GGA",
+			sentences:  []string{nmeaGGAFix, nmeaGNSFix},
+			fixQuality: 1,
+			source:     fixSourceGGA,
+			satellites: 8,
+			lat:        48.1173,
+			lon:        11.516666666666667,
+			elevation:  545.4,
+			hdop:       0.9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locState := feedNMEA(tt.sentences...)
+
+			status, fixQuality, satellites, _, _ := locState.GetStatus()
+			if status != "fix" {
+				t.Fatalf("status = %q, want fix", status)
+			}
+			if fixQuality != tt.fixQuality {
+				t.Errorf("fix quality = %d, want %d", fixQuality, tt.fixQuality)
+			}
+			if source := locState.GetFixSource(); source != tt.source {
+				t.Errorf("fix source = %q, want %q", source, tt.source)
+			}
+			if satellites != tt.satellites {
+				t.Errorf("satellites = %d, want %d", satellites, tt.satellites)
+			}
+
+			loc := locState.GetCurrent()
+			if loc == nil {
+				t.Fatal("no current location")
+			}
+			if !approxEqual(loc.Latitude, tt.lat) || !approxEqual(loc.Longitude, tt.lon) {
+				t.Errorf("location = %f, %f, want %f, %f", loc.Latitude, loc.Longitude, tt.lat, tt.lon)
+			}
+			if !approxEqual(loc.Elevation, tt.elevation) {
+				t.Errorf("elevation = %f, want %f", loc.Elevation, tt.elevation)
+			}
+			if !approxEqual(loc.Accuracy, tt.hdop) {
+				t.Errorf("accuracy = %f, want %f", loc.Accuracy, tt.hdop)
+			}
+			if hdop, _, _ := locState.GetHDOP(); !approxEqual(hdop, tt.hdop) {
+				t.Errorf("HDOP = %f, want %f", hdop, tt.hdop)
+			}
+		})
+	}
+}
+
+func TestParseNMEASentenceNoFix(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentences []string
+		status    string
+	}{
+		{name: "GGA quality 0", sentences: []string{nmeaGGANoFix}, status: "no_fix"},
+		{name: "GNS mode N", sentences: []string{nmeaGNSNoFix}, status: "no_fix"},
+		{name: "RMC void", sentences: []string{nmeaRMCVoid}, status: "no_fix"},
+		{name: "GGA bad checksum", sentences: []string{nmeaGGABadChecksum}, status: "no_gps"},
+		{name: "truncated", sentences: []string{nmeaGGAFix[:30]}, status: "no_gps"},
+		{name: "not NMEA", sentences: []string{"hello"}, status: "no_gps"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locState := feedNMEA(tt.sentences...)
+
+			status, fixQuality, _, _, _ := locState.GetStatus()
+			if status != tt.status {
+				t.Errorf("status = %q, want %q", status, tt.status)
+			}
+			if fixQuality != 0 {
+				t.Errorf("fix quality = %d, want 0", fixQuality)
+			}
+			if loc := locState.GetCurrent(); loc != nil {
+				t.Errorf("current location = %+v, want nil", *loc)
+			}
+			if source := locState.GetFixSource(); source != "" {
+				t.Errorf("fix source = %q, want none", source)
+			}
+		})
+	}
+}
+
+func TestParseNMEASentenceLosesFix(t *testing.T) {
+	locState := feedNMEA(nmeaGGAFix, nmeaGGANoFix)
+	if status, _, _, _, _ := locState.GetStatus(); status != "no_fix" {
+		t.Errorf("status = %q, want no_fix", status)
+	}
+}
+
+func TestParseNMEASentenceSatellitesInView(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentences []string
+		inView    int
+	}{
+		{name: "one constellation", sentences: []string{nmeaGPGSV1, nmeaGPGSV2}, inView: 8},
+		{name: "two constellations", sentences: []string{nmeaGPGSV1, nmeaGPGSV2, nmeaGLGSV}, inView: 11},
+		{name: "repeated sequence", sentences: []string{nmeaGPGSV1, nmeaGPGSV2, nmeaGPGSV1, nmeaGPGSV2}, inView: 8},
+		{name: "carried into fix", sentences: []string{nmeaGPGSV1, nmeaGLGSV, nmeaGGAFix}, inView: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locState := feedNMEA(tt.sentences...)
+			if _, _, _, inView, _ := locState.GetStatus(); inView != tt.inView {
+				t.Errorf("satellites in view = %d, want %d", inView, tt.inView)
+			}
+		})
+	}
+}
+
+func TestParseNMEASentenceGSA(t *testing.T) {
+	locState := feedNMEA(nmeaGSA)
+	fixType, pdop, vdop := locState.GetDOP()
+	if fixType != "3" {
+		t.Errorf("fix type = %q, want 3", fixType)
+	}
+	if !approxEqual(pdop, 2.5) || !approxEqual(vdop, 2.1) {
+		t.Errorf("PDOP/VDOP = %f/%f, want 2.5/2.1", pdop, vdop)
+	}
+}
+
+func TestParseNMEASentenceVTG(t *testing.T) {
+	locState := feedNMEA(nmeaVTG)
+	speedKPH, course, ok := locState.GetVelocity()
+	if !ok {
+		t.Fatal("no velocity")
+	}
+	if !approxEqual(speedKPH, 10.2) || !approxEqual(course, 54.7) {
+		t.Errorf("speed/course = %f/%f, want 10.2/54.7", speedKPH, course)
+	}
+
+	if _, _, ok := feedNMEA(nmeaVTGVoid).GetVelocity(); ok {
+		t.Error("velocity set from a VTG with mode N")
+	}
+}
+
+func TestParseNMEASentenceMaxHDOP(t *testing.T) {
+	locState := NewLocationState()
+	locState.SetMaxHDOP(0.5)
+	state := &nmeaReaderState{}
+	parseNMEASentence(nmeaGGAFix, locState, state)
+	parseNMEASentence(nmeaRMCFix, locState, state)
+
+	if status, _, _, _, _ := locState.GetStatus(); status != "poor_fix" {
+		t.Errorf("status = %q, want poor_fix", status)
+	}
+	if loc := locState.GetCurrent(); loc != nil {
+		t.Errorf("current location = %+v, want nil", *loc)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/adrianmo/go-nmea"
+)
+
+// nmeaPacer delays recorded NMEA so fixes are applied at their original pace
+// Sentences are scheduled by RMC timestamps; everything between two RMCs is applied when the first is due
+type nmeaPacer struct {
+	speed     float64   // Playback multiplier; 0 applies everything at once
+	origin    time.Time // Recording time that plays at wallStart; the first RMC's time when zero
+	wallStart time.Time
+}
+
+// newNMEAPacer starts the playback clock now
+// origin aligns playback with another recording, e.g. the first record of a -replay capture
+func newNMEAPacer(speed float64, origin time.Time) *nmeaPacer {
+	return &nmeaPacer{speed: speed, origin: origin, wallStart: time.Now()}
+}
+
+// wait blocks until line is due, returning false if done was closed first
+// Only RMC sentences with a valid date and time are ever delayed
+func (p *nmeaPacer) wait(line string, done <-chan struct{}) bool {
+	if p.speed <= 0 {
+		return true
+	}
+	s, err := nmea.Parse(line)
+	if err != nil {
+		return true
+	}
+	rmc, ok := s.(nmea.RMC)
+	if !ok {
+		return true
+	}
+	recorded := nmea.DateTime(0, rmc.Date, rmc.Time)
+	if recorded.IsZero() {
+		return true
+	}
+	if p.origin.IsZero() {
+		p.origin = recorded
+	}
+
+	// Sentences recorded before the origin are applied immediately, so the fix is current when playback lines up
+	due := p.wallStart.Add(time.Duration(float64(recorded.Sub(p.origin)) / p.speed))
+	delay := time.Until(due)
+	if delay <= 0 {
+		return true
+	}
+	select {
+	case <-done:
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// readGPSFile plays recorded NMEA into the location state, paced by pacer
+// The last fix is kept once the recording ends
+func readGPSFile(file io.ReadCloser, pacer *nmeaPacer, locState *LocationState, done <-chan struct{}) {
+	defer file.Close()
+
+	locState.SetGPSConnected(true)
+	locState.SetStatus("no_fix")
+	readGPSLoop(file, locState, done, pacer)
+}
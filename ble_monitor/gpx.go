@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+)
+
+// writeGPX writes the recorded GPS track to a GPX 1.1 file
+// Each fix becomes a <trkpt> with elevation and UTC timestamp
+func writeGPX(filename string, track []GeoLocation) error {
+	if len(track) == 0 {
+		return fmt.Errorf("no GPS fixes recorded")
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	// Write GPX header
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	w.WriteString("\n")
+	w.WriteString(`<gpx version="1.1" creator="ble_monitor" xmlns="http://www.topografix.com/GPX/1/1">`)
+	w.WriteString("\n  <trk>\n")
+	fmt.Fprintf(w, "    <name>GPS Track - %s</name>\n", formatDisplayTime(time.Now(), exportTimeLayout))
+	w.WriteString("    <trkseg>\n")
+
+	// Write track points
+	for _, loc := range track {
+		fmt.Fprintf(w, "      <trkpt lat=\"%.7f\" lon=\"%.7f\">\n", loc.Latitude, loc.Longitude)
+		fmt.Fprintf(w, "        <ele>%.1f</ele>\n", loc.Elevation)
+		fmt.Fprintf(w, "        <time>%s</time>\n", loc.Timestamp.UTC().Format(time.RFC3339))
+		w.WriteString("      </trkpt>\n")
+	}
+
+	// Write GPX footer
+	w.WriteString("    </trkseg>\n")
+	w.WriteString("  </trk>\n")
+	w.WriteString("</gpx>\n")
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write GPX: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// How often -headless prints a status line
+const headlessStatusInterval = 10 * time.Second
+
+// runHeadless collects without a screen until a signal arrives (-headless), for unattended sensors
+// Ingestion, autosave, streaming and the HTTP API run as usual; a terse status line goes to out
+func runHeadless(out io.Writer, app *App, sigChan <-chan os.Signal) {
+	ticker := time.NewTicker(headlessStatusInterval)
+	defer ticker.Stop()
+
+	fmt.Fprintln(out, headlessStatusLine(app, time.Now()))
+	for {
+		select {
+		case now := <-ticker.C:
+			fmt.Fprintln(out, headlessStatusLine(app, now))
+		case <-sigChan:
+			return
+		}
+	}
+}
+
+// headlessStatusLine summarizes devices, inputs, GPS and autosave on one line
+func headlessStatusLine(app *App, now time.Time) string {
+	deviceCount, advPerSec := app.agg.Stats()
+	parts := []string{
+		formatDisplayTime(now, displayTimeLayout),
+		fmt.Sprintf("%d devices, %d adv/s, peak %d", deviceCount, advPerSec, app.agg.Peak()),
+	}
+
+	// Inputs
+	if app.connState.Len() > 1 {
+		parts = append(parts, app.connState.Summary())
+	} else if connected, _, attempts := app.connState.GetStatus(); connected {
+		parts = append(parts, "connected")
+	} else if attempts > 0 {
+		parts = append(parts, fmt.Sprintf("DISCONNECTED (attempt %d)", attempts))
+	} else {
+		parts = append(parts, "connecting")
+	}
+
+	// GPS, when a receiver is configured
+	switch status, _, satellites, satellitesInView, _ := app.locState.GetStatus(); status {
+	case "no_gps":
+	case "fix":
+		if loc := app.locState.GetCurrent(); loc != nil {
+			parts = append(parts, fmt.Sprintf("GPS %.5f, %.5f (%d / %d)", loc.Latitude, loc.Longitude, satellitesInView, satellites))
+		} else {
+			parts = append(parts, "GPS stale")
+		}
+	default:
+		parts = append(parts, "GPS "+strings.ReplaceAll(status, "_", " "))
+	}
+
+	if app.locState.IsGPSPaused() {
+		parts = append(parts, "GPS tagging paused")
+	}
+
+	// Autosave
+	if app.autosaver != nil {
+		if lastSave, err := app.autosaver.Status(); err != nil {
+			parts = append(parts, "autosave FAILED")
+		} else if !lastSave.IsZero() {
+			parts = append(parts, "saved "+formatDisplayTime(lastSave, "15:04:05"))
+		}
+	}
+
+	if app.stream != nil {
+		if dropped := app.stream.Dropped(); dropped > 0 {
+			parts = append(parts, fmt.Sprintf("JSONL dropped %d", dropped))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
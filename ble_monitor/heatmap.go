@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-kml/v3"
+)
+
+// Heatmap rendering limits
+const (
+	defaultHeatmapCellMeters = 10.0
+	heatmapMaxCells          = 2048 // Per side; beyond this the cell size is too small for the area
+	heatmapTargetPixels      = 1024 // Cells are scaled up so the image is roughly this wide
+	heatmapWeakRSSI          = -100 // Drawn blue
+	heatmapStrongRSSI        = -40  // Drawn red
+	heatmapAlpha             = 180
+)
+
+// heatmapGrid holds the strongest RSSI observed in each cell of a lat/lon grid
+// Row 0 is the southernmost row
+type heatmapGrid struct {
+	south, west float64 // Corner of cell (0, 0)
+	dLat, dLon  float64 // Cell size in degrees
+	rows, cols  int
+	maxRSSI     []int  // rows*cols, valid only where seen is true
+	seen        []bool // Whether any observation fell in the cell
+}
+
+// buildHeatmapGrid bins every device's geo observations into cells of roughly cellMeters on a side
+// Returns nil if there are no observations
+func buildHeatmapGrid(devices []*BLEDevice, cellMeters float64) (*heatmapGrid, error) {
+	type observation struct {
+		loc  GeoLocation
+		rssi int
+	}
+	var observations []observation
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for _, dev := range devices {
+		if dev.GeoData == nil {
+			continue
+		}
+		for _, rssi := range dev.GeoData.RSSIs() {
+			for _, loc := range dev.GeoData.LocationsAt(rssi) {
+				observations = append(observations, observation{loc: loc, rssi: rssi})
+				minLat, maxLat = math.Min(minLat, loc.Latitude), math.Max(maxLat, loc.Latitude)
+				minLon, maxLon = math.Min(minLon, loc.Longitude), math.Max(maxLon, loc.Longitude)
+			}
+		}
+	}
+	if len(observations) == 0 {
+		return nil, nil
+	}
+
+	// Convert the cell size to degrees at the area's mid latitude
+	metersPerDegree := earthRadiusMeters * math.Pi / 180
+	dLat := cellMeters / metersPerDegree
+	dLon := dLat / math.Max(math.Cos((minLat+maxLat)/2*math.Pi/180), 1e-6)
+
+	rows := int((maxLat-minLat)/dLat) + 1
+	cols := int((maxLon-minLon)/dLon) + 1
+	if rows > heatmapMaxCells || cols > heatmapMaxCells {
+		return nil, fmt.Errorf("heatmap would be %dx%d cells; use a larger cell size", cols, rows)
+	}
+
+	grid := &heatmapGrid{
+		south:   minLat,
+		west:    minLon,
+		dLat:    dLat,
+		dLon:    dLon,
+		rows:    rows,
+		cols:    cols,
+		maxRSSI: make([]int, rows*cols),
+		seen:    make([]bool, rows*cols),
+	}
+	for _, obs := range observations {
+		row := min(int((obs.loc.Latitude-minLat)/dLat), rows-1)
+		col := min(int((obs.loc.Longitude-minLon)/dLon), cols-1)
+		i := row*cols + col
+		if !grid.seen[i] || obs.rssi > grid.maxRSSI[i] {
+			grid.maxRSSI[i] = obs.rssi
+			grid.seen[i] = true
+		}
+	}
+	return grid, nil
+}
+
+// heatColor maps an RSSI onto a blue → cyan → green → yellow → red ramp (weak to strong)
+func heatColor(rssi int) color.NRGBA {
+	f := clampFloat(float64(rssi-heatmapWeakRSSI)/float64(heatmapStrongRSSI-heatmapWeakRSSI), 0, 1)
+	var r, g, b float64
+	switch {
+	case f < 0.25:
+		g, b = f/0.25, 1
+	case f < 0.5:
+		g, b = 1, 1-(f-0.25)/0.25
+	case f < 0.75:
+		r, g = (f-0.5)/0.25, 1
+	default:
+		r, g = 1, 1-(f-0.75)/0.25
+	}
+	return color.NRGBA{R: uint8(r * 255), G: uint8(g * 255), B: uint8(b * 255), A: heatmapAlpha}
+}
+
+// render draws the grid as an image, north up, with empty cells transparent
+func (g *heatmapGrid) render() *image.NRGBA {
+	scale := max(1, heatmapTargetPixels/max(g.rows, g.cols))
+	img := image.NewNRGBA(image.Rect(0, 0, g.cols*scale, g.rows*scale))
+	for row := 0; row < g.rows; row++ {
+		y0 := (g.rows - 1 - row) * scale // Image row 0 is north
+		for col := 0; col < g.cols; col++ {
+			i := row*g.cols + col
+			if !g.seen[i] {
+				continue
+			}
+			c := heatColor(g.maxRSSI[i])
+			for y := y0; y < y0+scale; y++ {
+				for x := col * scale; x < (col+1)*scale; x++ {
+					img.SetNRGBA(x, y, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// overlay returns a GroundOverlay placing the rendered grid image, found at imageHref, over its area
+func (g *heatmapGrid) overlay(cellMeters float64, imageHref string) kml.Element {
+	description := fmt.Sprintf("Strongest RSSI per %.0f m cell. Blue ≤ %d dBm, red ≥ %d dBm.", cellMeters, heatmapWeakRSSI, heatmapStrongRSSI)
+	return kml.GroundOverlay(
+		kml.Name("RSSI Heatmap"),
+		kml.Description(description),
+		kml.Icon(kml.Href(imageHref)),
+		kml.LatLonBox(
+			kml.North(g.south+float64(g.rows)*g.dLat),
+			kml.South(g.south),
+			kml.East(g.west+float64(g.cols)*g.dLon),
+			kml.West(g.west),
+		),
+	)
+}
+
+// exportHeatmapKML writes a KML GroundOverlay of the strongest RSSI per grid cell across the given devices
+// The overlay image is written next to the KML as a PNG with the same base name
+func exportHeatmapKML(filename string, devices []*BLEDevice, cellMeters float64) error {
+	grid, err := buildHeatmapGrid(devices, cellMeters)
+	if err != nil {
+		return err
+	}
+	if grid == nil {
+		return fmt.Errorf("no geolocated observations to map")
+	}
+
+	// Write the overlay image
+	pngFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".png"
+	pngFile, err := os.Create(pngFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := png.Encode(pngFile, grid.render()); err != nil {
+		pngFile.Close()
+		return fmt.Errorf("failed to write PNG: %w", err)
+	}
+	if err := pngFile.Close(); err != nil {
+		return fmt.Errorf("failed to write PNG: %w", err)
+	}
+
+	doc := kml.KML(
+		kml.Document(
+			kml.Name(fmt.Sprintf("BLE RSSI Heatmap - %s", formatDisplayTime(time.Now(), exportTimeLayout))),
+			grid.overlay(cellMeters, filepath.Base(pngFilename)),
+		),
+	)
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := doc.WriteIndent(file, "", "  "); err != nil {
+		return fmt.Errorf("failed to write KML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// keyBinding is one line of the ? help overlay
+type keyBinding struct {
+	keys   string
+	action string
+}
+
+// keyHelpSection groups related key bindings under a heading
+type keyHelpSection struct {
+	title    string
+	bindings []keyBinding
+}
+
+// keyHelp lists every table view key binding for the ? overlay; new keys belong here, not in the status line
+var keyHelp = []keyHelpSection{
+	{title: "Navigation", bindings: []keyBinding{
+		{"↑↓ / jk", "Move the cursor"},
+		{"PgUp PgDn Home End", "Move by page, to the top or bottom"},
+		{"> / <", "Jump to the strongest / weakest signal"},
+		{"←→ / hl", "Scroll columns"},
+		{"Tab", "Switch tables"},
+		{"1", "Show only the focused table"},
+		{"Enter", "Device details"},
+	}},
+	{title: "View", bindings: []keyBinding{
+		{"s / S", "Cycle sort key / reverse sort"},
+		{"a", "Timestamps or ages in Last Seen"},
+		{"x", "Short or full service UUIDs"},
+		{"b / B", "Class colors / class legend"},
+		{"p", "Pause: freeze the display while recording continues"},
+		{"P", "Snapshot: inspect and export a captured copy, P again for live"},
+		{"d", "Device count graph"},
+		{"f", "Find the selected device by signal"},
+		{"i", "Firmware notifications"},
+		{"?", "This help"},
+	}},
+	{title: "Filters", bindings: []keyBinding{
+		{"m", "Manufacturer code"},
+		{"n", "Named devices only"},
+		{"o", "Connectable devices only"},
+		{"t", "Cycle device class"},
+		{"r", "Cycle protocol"},
+		{"v", "Minimum observation count"},
+	}},
+	{title: "Data", bindings: []keyBinding{
+		{"e / E", "Export menu / quick JSON export"},
+		{"g", "Export GPX track"},
+		{"z", "Pause or resume GPS tagging of devices"},
+		{"w", "Edit watchlist"},
+		{"y / Y", "Copy MAC / device JSON"},
+		{"c / u", "Clear devices / undo clear"},
+		{"q", "Quit"},
+	}},
+}
+
+// HelpModalState tracks the ? key help overlay
+type HelpModalState struct {
+	showing      bool
+	scrollOffset int
+}
+
+// Show displays the help overlay from the top
+func (h *HelpModalState) Show() {
+	h.showing = true
+	h.scrollOffset = 0
+}
+
+// Hide hides the help overlay
+func (h *HelpModalState) Hide() {
+	h.showing = false
+}
+
+// IsShowing returns whether the overlay is currently visible
+func (h *HelpModalState) IsShowing() bool {
+	return h != nil && h.showing
+}
+
+// ScrollUp scrolls the overlay up by one line
+func (h *HelpModalState) ScrollUp() {
+	if h.scrollOffset > 0 {
+		h.scrollOffset--
+	}
+}
+
+// ScrollDown scrolls the overlay down by one line (clamped when drawn)
+func (h *HelpModalState) ScrollDown() {
+	h.scrollOffset++
+}
+
+// keyHelpLines formats keyHelp as overlay lines, keys aligned in a column
+func keyHelpLines() []string {
+	keysWidth := 0
+	for _, section := range keyHelp {
+		for _, binding := range section.bindings {
+			keysWidth = max(keysWidth, len([]rune(binding.keys)))
+		}
+	}
+
+	var lines []string
+	for i, section := range keyHelp {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, section.title)
+		for _, binding := range section.bindings {
+			padding := keysWidth - len([]rune(binding.keys))
+			lines = append(lines, fmt.Sprintf("  %s%*s  %s", binding.keys, padding, "", binding.action))
+		}
+	}
+	return lines
+}
+
+// drawHelpModal draws the key help overlay, scrolling when it doesn't fit
+func drawHelpModal(s tcell.Screen, helpModal *HelpModalState) {
+	width, height := s.Size()
+	lines := keyHelpLines()
+
+	// Modal dimensions (tall enough for every line when it fits, up to 76 columns)
+	modalWidth := min(76, width-4)
+	modalHeight := min(len(lines)+5, height-2)
+	if modalWidth < 20 || modalHeight < 6 {
+		return
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	borderStyle := theme.Detail.Border
+	bgStyle := theme.Detail.Body
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, " KEYS ")
+
+	contentX := modalX + 2
+	contentWidth := modalWidth - 4
+	contentY := modalY + 3
+	contentHeight := modalHeight - 5
+
+	// Clamp scroll to content
+	maxScroll := max(0, len(lines)-contentHeight)
+	if helpModal.scrollOffset > maxScroll {
+		helpModal.scrollOffset = maxScroll
+	}
+
+	for i := 0; i < contentHeight && helpModal.scrollOffset+i < len(lines); i++ {
+		drawText(s, contentX, contentY+i, contentWidth, bgStyle, lines[helpModal.scrollOffset+i])
+	}
+
+	hint := "ESC: Close"
+	if maxScroll > 0 {
+		hint = "↑↓/jk: Scroll | ESC: Close"
+	}
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// APIServer serves a read-only JSON view of the live device and GPS state
+type APIServer struct {
+	agg       *Aggregator
+	connState *ConnectionSet
+	locState  *LocationState
+	hub       *WSHub
+	server    *http.Server
+	listener  net.Listener
+}
+
+// RSSILocations is the geo history stored for one RSSI value
+type RSSILocations struct {
+	RSSI      int           `json:"rssi"`
+	Locations []GeoLocation `json:"locations"`
+}
+
+// deviceResponse is a single device with its per-RSSI geo history
+type deviceResponse struct {
+	*BLEDevice
+	GeoHistory []RSSILocations `json:"geo_history"`
+}
+
+// gpsResponse is the current GPS fix and receiver status
+type gpsResponse struct {
+	Status           string       `json:"status"`
+	FixQuality       int          `json:"fix_quality"`
+	FixSource        string       `json:"fix_source,omitempty"` // NMEA sentence behind the fix: GGA, GNS or RMC
+	Satellites       int          `json:"satellites"`
+	SatellitesInView int          `json:"satellites_in_view"`
+	HDOP             float64      `json:"hdop,omitempty"`
+	FixType          string       `json:"fix_type,omitempty"` // GSA fix type: 1 none, 2 2D, 3 3D
+	PDOP             float64      `json:"pdop,omitempty"`
+	VDOP             float64      `json:"vdop,omitempty"`
+	LastUpdate       time.Time    `json:"last_update"`
+	Location         *GeoLocation `json:"location,omitempty"`
+	SpeedKPH         *float64     `json:"speed_kph,omitempty"`
+	Course           *float64     `json:"course,omitempty"`
+}
+
+// healthResponse summarizes input and GPS health for monitoring
+type healthResponse struct {
+	Connected        bool   `json:"connected"`
+	ReconnectTries   int    `json:"reconnect_attempts"`
+	GPSStatus        string `json:"gps_status"`
+	Devices          int    `json:"devices"`
+	AdvertsPerSecond int    `json:"adv_per_sec"`
+	WSClients        int    `json:"ws_clients"`
+	WSDropped        uint64 `json:"ws_dropped"`
+}
+
+// newAPIServer binds addr immediately so a bad -http value fails before the TUI starts
+func newAPIServer(addr string, agg *Aggregator, connState *ConnectionSet, locState *LocationState, hub *WSHub) (*APIServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start HTTP API: %w", err)
+	}
+
+	api := &APIServer{
+		agg:       agg,
+		connState: connState,
+		locState:  locState,
+		hub:       hub,
+		listener:  listener,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", api.handleDevices)
+	mux.HandleFunc("GET /devices/{mac}", api.handleDevice)
+	mux.HandleFunc("GET /gps", api.handleGPS)
+	mux.HandleFunc("GET /healthz", api.handleHealth)
+	mux.Handle("GET /ws", hub)
+
+	api.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return api, nil
+}
+
+// Run serves requests until Close is called
+func (api *APIServer) Run() {
+	api.server.Serve(api.listener)
+}
+
+// Close stops the server and drops open connections, including WebSocket clients
+func (api *APIServer) Close() error {
+	api.hub.Close()
+	return api.server.Close()
+}
+
+// handleDevices returns all devices as a JSON array, recent first, then stale
+func (api *APIServer) handleDevices(w http.ResponseWriter, r *http.Request) {
+	sorted := api.agg.GetSnapshotBy(defaultRecentSort, defaultStaleSort)
+	devices := make([]*BLEDevice, 0, len(sorted.Recent)+len(sorted.Stale))
+	devices = append(devices, sorted.Recent...)
+	devices = append(devices, sorted.Stale...)
+	writeJSON(w, http.StatusOK, devices)
+}
+
+// handleDevice returns one device including its geo history, or 404 if unknown
+func (api *APIServer) handleDevice(w http.ResponseWriter, r *http.Request) {
+	dev := api.agg.GetSnapshot(normalizeMAC(r.PathValue("mac")))
+	if dev == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "device not found"})
+		return
+	}
+
+	resp := deviceResponse{BLEDevice: dev, GeoHistory: []RSSILocations{}}
+	if dev.GeoData != nil {
+		for _, rssi := range dev.GeoData.RSSIs() {
+			resp.GeoHistory = append(resp.GeoHistory, RSSILocations{
+				RSSI:      rssi,
+				Locations: dev.GeoData.LocationsAt(rssi),
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGPS returns the current fix and receiver status
+func (api *APIServer) handleGPS(w http.ResponseWriter, r *http.Request) {
+	status, fixQuality, satellites, satellitesInView, lastUpdate := api.locState.GetStatus()
+	resp := gpsResponse{
+		Status:           status,
+		FixQuality:       fixQuality,
+		FixSource:        api.locState.GetFixSource(),
+		Satellites:       satellites,
+		SatellitesInView: satellitesInView,
+		LastUpdate:       lastUpdate,
+		Location:         api.locState.GetCurrent(),
+	}
+	resp.HDOP, _, _ = api.locState.GetHDOP()
+	resp.FixType, resp.PDOP, resp.VDOP = api.locState.GetDOP()
+	if speed, course, ok := api.locState.GetVelocity(); ok {
+		resp.SpeedKPH = &speed
+		resp.Course = &course
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleHealth reports connection and GPS status; 503 while the BLE input is disconnected
+func (api *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	connected, _, attempts := api.connState.GetStatus()
+	gpsStatus, _, _, _, _ := api.locState.GetStatus()
+	devices, advPerSec := api.agg.Stats()
+
+	code := http.StatusOK
+	if !connected {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, healthResponse{
+		Connected:        connected,
+		ReconnectTries:   attempts,
+		GPSStatus:        gpsStatus,
+		Devices:          devices,
+		AdvertsPerSecond: advPerSec,
+		WSClients:        api.hub.ClientCount(),
+		WSDropped:        api.hub.Dropped(),
+	})
+}
+
+// writeJSON encodes v as the response body with the given status code
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
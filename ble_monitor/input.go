@@ -1,75 +1,233 @@
 package main
 
 import (
-	"fmt"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	json "github.com/goccy/go-json"
 )
 
 // handleKeyboardEvent processes keyboard input
-func handleKeyboardEvent(ev *tcell.EventKey, agg *Aggregator, paused *bool, pauseMu *sync.RWMutex, tableState *TableState, connState *ConnectionState, locState *LocationState, exportModal *ExportModalState, s tcell.Screen) bool {
+func handleKeyboardEvent(ev *tcell.EventKey, app *App) bool {
+	agg := app.agg
+	tableState := app.tableState
+	locState := app.locState
+	exportModal := app.exportModal
+	detailModal := app.detailModal
+
 	// Export modal has highest priority (if showing)
 	if exportModal.IsShowing() {
 		switch ev.Key() {
 		case tcell.KeyEsc:
 			// ESC closes modal
 			exportModal.Hide()
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			app.redraw()
 			return false
 		case tcell.KeyUp:
 			// Up arrow - previous option
 			exportModal.SelectPrev()
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			app.redraw()
 			return false
 		case tcell.KeyDown, tcell.KeyTab:
 			// Down arrow or Tab - next option
 			exportModal.SelectNext()
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			app.redraw()
 			return false
 		case tcell.KeyEnter:
 			// Enter - execute selected option
 			selected := exportModal.GetSelected()
 			exportModal.Hide()
-			if selected == 0 {
-				handleExport(agg)
-			} else {
-				handleExportKML(agg)
+			switch selected {
+			case 0:
+				handleExport(app, exportModal.filtered)
+			case 1:
+				handleExportKML(app, exportModal.filtered)
+			case 2:
+				handleExportHeatmap(app, exportModal.filtered)
+			case 3:
+				handleExportKMZ(app, exportModal.filtered)
 			}
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			app.redraw()
 			return false
 		case tcell.KeyRune:
 			switch ev.Rune() {
 			case 'j', 'J':
 				// J key - export JSON directly
 				exportModal.Hide()
-				handleExport(agg)
-				drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+				handleExport(app, exportModal.filtered)
+				app.redraw()
 				return false
 			case 'k', 'K':
 				// K key - export KML directly
 				exportModal.Hide()
-				handleExportKML(agg)
-				drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+				handleExportKML(app, exportModal.filtered)
+				app.redraw()
+				return false
+			case 'h', 'H':
+				// H key - export heatmap directly
+				exportModal.Hide()
+				handleExportHeatmap(app, exportModal.filtered)
+				app.redraw()
+				return false
+			case 'z', 'Z':
+				// Z key - export KMZ directly
+				exportModal.Hide()
+				handleExportKMZ(app, exportModal.filtered)
+				app.redraw()
 				return false
+			case 'a', 'A':
+				// A key - switch between all and filtered devices
+				if app.filter.IsActive() {
+					exportModal.ToggleScope()
+					app.redraw()
+				}
+				return false
+			}
+		}
+		// Consume any other keys when modal is showing
+		return false
+	}
+
+	// Clear confirmation: only 'y' confirms, anything else cancels
+	if app.clearModal.IsShowing() {
+		app.clearModal.Hide()
+		if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+			handleClear(app)
+		}
+		app.redraw()
+		return false
+	}
+
+	// Device detail modal is next (if showing)
+	if detailModal.IsShowing() {
+		switch ev.Key() {
+		case tcell.KeyEsc, tcell.KeyEnter:
+			// ESC (or Enter again) closes modal
+			detailModal.Hide()
+		case tcell.KeyUp:
+			detailModal.ScrollUp()
+		case tcell.KeyDown:
+			detailModal.ScrollDown()
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'k', 'K':
+				detailModal.ScrollUp()
+			case 'j', 'J':
+				detailModal.ScrollDown()
 			}
 		}
 		// Consume any other keys when modal is showing
+		app.redraw()
+		return false
+	}
+
+	// Notifications panel scrolls like the detail modal
+	if app.noticesModal.IsShowing() {
+		switch ev.Key() {
+		case tcell.KeyEsc, tcell.KeyEnter:
+			app.noticesModal.Hide()
+		case tcell.KeyUp:
+			app.noticesModal.ScrollUp()
+		case tcell.KeyDown:
+			app.noticesModal.ScrollDown()
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'k', 'K':
+				app.noticesModal.ScrollUp()
+			case 'j', 'J':
+				app.noticesModal.ScrollDown()
+			case 'i', 'I':
+				app.noticesModal.Hide()
+			}
+		}
+		app.redraw()
+		return false
+	}
+
+	// Key help scrolls like the notifications panel
+	if app.helpModal.IsShowing() {
+		switch ev.Key() {
+		case tcell.KeyEsc, tcell.KeyEnter:
+			app.helpModal.Hide()
+		case tcell.KeyUp:
+			app.helpModal.ScrollUp()
+		case tcell.KeyDown:
+			app.helpModal.ScrollDown()
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'k', 'K':
+				app.helpModal.ScrollUp()
+			case 'j', 'J':
+				app.helpModal.ScrollDown()
+			case '?':
+				app.helpModal.Hide()
+			}
+		}
+		app.redraw()
+		return false
+	}
+
+	// Watchlist editor captures all keys while open
+	if app.watchModal.IsShowing() {
+		handleWatchModalKey(ev, app)
+		app.redraw()
+		return false
+	}
+
+	// Manufacturer filter picker captures all keys while open
+	if app.mfrModal.IsShowing() {
+		handleMfrFilterModalKey(ev, app)
+		app.redraw()
+		return false
+	}
+
+	// Proximity view: ESC returns to the table, other keys are ignored
+	if app.proximity.IsShowing() {
+		switch ev.Key() {
+		case tcell.KeyEsc:
+			app.proximity.Hide()
+		case tcell.KeyCtrlC:
+			return true
+		}
+		app.redraw()
+		return false
+	}
+
+	// Device count graph: ESC or d returns to the table, other keys are ignored
+	if app.density != nil && app.density.IsShowing() {
+		switch ev.Key() {
+		case tcell.KeyEsc:
+			app.density.Hide()
+		case tcell.KeyCtrlC:
+			return true
+		case tcell.KeyRune:
+			if r := ev.Rune(); r == 'd' || r == 'D' {
+				app.density.Hide()
+			}
+		}
+		app.redraw()
 		return false
 	}
 
 	// If GPS failure modal is showing, any key dismisses it
 	if locState.ShouldShowGPSFailureModal() {
 		locState.DismissGPSFailure()
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
 		return false
 	}
 
 	// If GPS reconnection modal is showing, any key dismisses it
 	if locState.ShouldShowGPSReconnectModal() {
 		locState.DismissGPSReconnect()
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
+		return false
+	}
+
+	// ESC dismisses the disconnection modal; the data underneath stays browsable while reconnecting
+	if ev.Key() == tcell.KeyEsc && app.connState.IsModalShown() {
+		app.connState.DismissModal(time.Now())
+		app.redraw()
 		return false
 	}
 
@@ -78,143 +236,557 @@ func handleKeyboardEvent(ev *tcell.EventKey, agg *Aggregator, paused *bool, paus
 		switch ev.Rune() {
 		case 'q', 'Q':
 			return true // Signal quit
-		case 'e', 'E':
+		case 'e':
 			// Show export modal instead of exporting directly
-			exportModal.Show()
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			exportModal.Show(app.filter.IsActive())
+			app.redraw()
+		case 'E':
+			// Shift+E - export JSON immediately, skipping the modal; honors the active filter
+			handleExport(app, app.filter.IsActive())
+			app.redraw()
+		case 'g', 'G':
+			handleExportGPX(app)
+			app.redraw()
 		case 'c', 'C':
-			handleClear(agg, tableState, paused, s, connState, locState, exportModal)
-		case 'p', 'P':
-			handlePause(paused, pauseMu)
-		case 'j', 'J': // Scroll down (vim-style)
+			deviceCount, _ := agg.Stats()
+			app.clearModal.Show(deviceCount)
+			app.redraw()
+		case 'u', 'U':
+			handleUndoClear(app)
+		case 'a', 'A':
+			// Switch Last Seen between timestamps and relative ages
+			tableState.relativeAge = !tableState.relativeAge
+			app.redraw()
+		case 'b':
+			// Tint rows by device class group
+			tableState.classColors = !tableState.classColors
+			app.redraw()
+		case 'B':
+			tableState.classLegend = !tableState.classLegend
+			app.redraw()
+		case '1':
+			// Show only the focused table at full height; Tab switches which one
+			tableState.singleTable = !tableState.singleTable
+			app.redraw()
+		case 'x', 'X':
+			// Switch Service UUIDs between short 16-bit and full 128-bit forms
+			tableState.fullUUIDs = !tableState.fullUUIDs
+			app.redraw()
+		case 's':
+			handleSortCycle(tableState)
+			app.redraw()
+		case 'S':
+			handleSortReverse(tableState)
+			app.redraw()
+		case 'p':
+			handlePause(app)
+			app.redraw()
+		case 'P':
+			handleSnapshot(app)
+			app.redraw()
+		case 'z', 'Z':
+			handleGPSPause(app)
+			app.redraw()
+		case 'w', 'W':
+			handleShowWatchlist(app)
+			app.redraw()
+		case 'f', 'F':
+			handleShowProximity(app)
+			app.redraw()
+		case 'm', 'M':
+			app.mfrModal.Show()
+			app.redraw()
+		case 'o', 'O':
+			app.filter.ToggleConnectableOnly()
+			resetTablePositions(tableState)
+			app.redraw()
+		case 'n', 'N':
+			app.filter.ToggleNamedOnly()
+			resetTablePositions(tableState)
+			app.redraw()
+		case 't', 'T':
+			// Cycle through the device classes currently present
+			app.filter.CycleClass(deviceClasses(app.unfilteredView()))
+			resetTablePositions(tableState)
+			app.redraw()
+		case 'r', 'R':
+			// Cycle through the protocols the firmware has reported
+			app.filter.CycleProtocol(deviceProtocols(app.unfilteredView()))
+			resetTablePositions(tableState)
+			app.redraw()
+		case 'v', 'V':
+			// Hide devices seen fewer than the -min-count threshold
+			app.filter.ToggleMinCount()
+			resetTablePositions(tableState)
+			app.redraw()
+		case 'i', 'I':
+			if app.noticesModal != nil {
+				app.noticesModal.Show()
+			}
+			app.redraw()
+		case '?':
+			if app.helpModal != nil {
+				app.helpModal.Show()
+			}
+			app.redraw()
+		case 'd', 'D':
+			if app.density != nil {
+				app.density.Toggle()
+			}
+			app.redraw()
+		case 'y':
+			handleYank(app, false)
+			app.redraw()
+		case 'Y':
+			handleYank(app, true)
+			app.redraw()
+		case '>':
+			handleJumpToSignal(app, true)
+			app.redraw()
+		case '<':
+			handleJumpToSignal(app, false)
+			app.redraw()
+		case 'j', 'J': // Move cursor down (vim-style)
 			handleScrollDown(tableState)
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
-		case 'k', 'K': // Scroll up (vim-style)
+			app.redraw()
+		case 'k', 'K': // Move cursor up (vim-style)
 			handleScrollUp(tableState)
-			drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+			app.redraw()
+		case 'h', 'H': // Scroll columns left (vim-style)
+			handleScrollColumns(tableState, -1)
+			app.redraw()
+		case 'l', 'L': // Scroll columns right (vim-style)
+			handleScrollColumns(tableState, 1)
+			app.redraw()
 		}
 	case tcell.KeyUp:
 		handleScrollUp(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
 	case tcell.KeyDown:
 		handleScrollDown(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
+	case tcell.KeyLeft:
+		handleScrollColumns(tableState, -1)
+		app.redraw()
+	case tcell.KeyRight:
+		handleScrollColumns(tableState, 1)
+		app.redraw()
 	case tcell.KeyPgUp:
 		handlePageUp(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
 	case tcell.KeyPgDn:
 		handlePageDown(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
 	case tcell.KeyHome:
 		handleHome(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
 	case tcell.KeyEnd:
-		handleEnd(tableState, agg)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		handleEnd(app)
+		app.redraw()
 	case tcell.KeyTab:
 		handleTabSwitch(tableState)
-		drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+		app.redraw()
+	case tcell.KeyEnter:
+		handleShowDetail(app)
+		app.redraw()
 	case tcell.KeyCtrlC:
 		return true // Signal quit
 	}
 	return false
 }
 
+// handleScrollColumns shifts the first visible column by delta; drawTable clamps it to the column count
+func handleScrollColumns(tableState *TableState, delta int) {
+	tableState.colOffset = max(0, tableState.colOffset+delta)
+}
+
 // handleExport exports devices to timestamped JSON file
-func handleExport(agg *Aggregator) {
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("ble_devices_%s.json", timestamp)
-	agg.ExportJSON(filename)
-	// Could show error in status line, but for now ignore
+// Only the filtered devices are written when filtered is set
+func handleExport(app *App, filtered bool) {
+	filename := exportFilename(app, "", ".json")
+	devices := app.exportDevices(filtered)
+	runExport(app, filename, func() error { return exportDevicesJSON(filename, devices) })
 }
 
 // handleExportKML exports devices to timestamped KML file
-func handleExportKML(agg *Aggregator) {
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("ble_devices_%s.kml", timestamp)
-	agg.ExportKML(filename)
-	// Could show error in status line, but for now ignore
+func handleExportKML(app *App, filtered bool) {
+	filename := exportFilename(app, "", ".kml")
+	devices := app.exportDevices(filtered)
+	runExport(app, filename, func() error { return exportDevicesKML(filename, devices) })
+}
+
+// handleExportHeatmap exports an RSSI heatmap to a timestamped KML file with a PNG overlay
+func handleExportHeatmap(app *App, filtered bool) {
+	filename := exportFilename(app, "ble_heatmap", ".kml")
+	devices, cellMeters := app.exportDevices(filtered), app.heatmapCellMeters
+	runExport(app, filename, func() error { return exportHeatmapKML(filename, devices, cellMeters) })
+}
+
+// handleExportKMZ exports devices and the RSSI heatmap overlay to a timestamped KMZ file
+func handleExportKMZ(app *App, filtered bool) {
+	filename := exportFilename(app, "", ".kmz")
+	devices, cellMeters := app.exportDevices(filtered), app.heatmapCellMeters
+	runExport(app, filename, func() error { return exportDevicesKMZ(filename, devices, cellMeters) })
+}
+
+// handleExportGPX exports the recorded GPS track to timestamped GPX file
+func handleExportGPX(app *App) {
+	filename := app.exports.timestamped("gps_track", ".gpx")
+	track := app.locState.GetTrack()
+	runExport(app, filename, func() error { return writeGPX(filename, track) })
+}
+
+// exportFilename returns a new path for an export named name, or the device prefix when name is ""
+// Exports of a snapshot are named after the time it was taken rather than now
+func exportFilename(app *App, name, ext string) string {
+	if name == "" {
+		name = app.exports.devicePrefix()
+	}
+	if app.snapshot != nil {
+		return app.exports.snapshot(name, app.snapshot.Now, ext)
+	}
+	return app.exports.timestamped(name, ext)
+}
+
+// runExport hands an export to the background worker, or writes it inline when there is none
+// The data to write must already be captured, since write runs on another goroutine
+func runExport(app *App, filename string, write func() error) {
+	if app.exporter == nil {
+		reportExport(app, filename, write())
+		return
+	}
+	if !app.exporter.Submit(filename, write) {
+		app.setStatusMessage("✗ Too many exports queued, try again shortly")
+	}
+}
+
+// reportExport shows the outcome of an export in the status line
+func reportExport(app *App, filename string, err error) {
+	if err != nil {
+		logger.Error("export failed", "file", filename, "error", err)
+		app.setStatusMessage("✗ Export failed: " + err.Error())
+		return
+	}
+	logger.Info("exported", "file", filename)
+	app.setStatusMessage("✓ Exported " + filename)
 }
 
 // handleClear clears the aggregator and resets scroll positions
-func handleClear(agg *Aggregator, tableState *TableState, paused *bool, s tcell.Screen, connState *ConnectionState, locState *LocationState, exportModal *ExportModalState) {
-	agg.Clear()
+func handleClear(app *App) {
+	app.agg.Clear()
+	if app.session != nil {
+		app.session.Reset(time.Now())
+	}
+	// A snapshot keeps the devices it captured
+	if app.IsPaused() {
+		app.frozen = app.agg.GetSnapshotBy(app.tableState.nearSort, app.tableState.farSort)
+	}
+	resetTablePositions(app.tableState)
+	app.redraw()
+}
+
+// resetTablePositions scrolls both tables to the top, used when the list they show is replaced
+func resetTablePositions(tableState *TableState) {
 	tableState.nearScrollOffset = 0
 	tableState.farScrollOffset = 0
-	drawTable(s, agg.GetSorted(), *paused, tableState, connState, locState, exportModal)
+	tableState.nearSelected = 0
+	tableState.farSelected = 0
+}
+
+// handleUndoClear restores the devices removed by the last clear
+func handleUndoClear(app *App) {
+	if !app.agg.UndoClear() {
+		return
+	}
+	if app.session != nil {
+		app.session.Restore()
+	}
+	if app.IsPaused() {
+		app.frozen = app.agg.GetSnapshotBy(app.tableState.nearSort, app.tableState.farSort)
+	}
+	app.redraw()
 }
 
-// handlePause toggles pause state
-func handlePause(paused *bool, pauseMu *sync.RWMutex) {
-	pauseMu.Lock()
-	*paused = !*paused
-	pauseMu.Unlock()
+// handlePause toggles pause state, or leaves snapshot mode back to live
+// Pausing freezes the whole display; ingestion keeps running and the display catches up on resume
+func handlePause(app *App) {
+	if app.InSnapshot() {
+		app.snapshot = nil
+		return
+	}
+	setPaused(app, !app.IsPaused())
 }
 
-// handleScrollDown scrolls the focused table down by one row
+// setPaused pauses or resumes the display
+func setPaused(app *App, paused bool) {
+	app.pauseMu.Lock()
+	app.paused = paused
+	app.pauseMu.Unlock()
+
+	if paused {
+		app.frozen = app.agg.GetSnapshotBy(app.tableState.nearSort, app.tableState.farSort)
+	} else {
+		app.frozen = nil
+	}
+}
+
+// handleSnapshot captures the devices into snapshot mode, or returns to live when already in it
+// Unlike pause the rest of the screen stays live, and exports write the snapshot
+func handleSnapshot(app *App) {
+	if app.InSnapshot() {
+		app.snapshot = nil
+		return
+	}
+	setPaused(app, false)
+	app.snapshot = app.agg.GetSnapshotBy(app.tableState.nearSort, app.tableState.farSort)
+}
+
+// handleGPSPause stops or resumes tagging devices with the GPS fix; BLE ingestion carries on either way
+func handleGPSPause(app *App) {
+	if app.locState.ToggleGPSPaused() {
+		app.setStatusMessage("GPS tagging paused: devices won't record locations (z to resume)")
+	} else {
+		app.setStatusMessage("GPS tagging resumed")
+	}
+}
+
+// handleScrollDown moves the focused table's cursor down by one row
 func handleScrollDown(tableState *TableState) {
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset++
+		tableState.nearSelected++
 	} else {
-		tableState.farScrollOffset++
+		tableState.farSelected++
 	}
 }
 
-// handleScrollUp scrolls the focused table up by one row
+// handleScrollUp moves the focused table's cursor up by one row
 func handleScrollUp(tableState *TableState) {
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset--
-		if tableState.nearScrollOffset < 0 {
-			tableState.nearScrollOffset = 0
+		tableState.nearSelected--
+		if tableState.nearSelected < 0 {
+			tableState.nearSelected = 0
 		}
 	} else {
-		tableState.farScrollOffset--
-		if tableState.farScrollOffset < 0 {
-			tableState.farScrollOffset = 0
+		tableState.farSelected--
+		if tableState.farSelected < 0 {
+			tableState.farSelected = 0
 		}
 	}
 }
 
-// handlePageUp scrolls the focused table up by 10 rows
+// handlePageUp moves the focused table's cursor up by 10 rows
 func handlePageUp(tableState *TableState) {
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset -= 10
-		if tableState.nearScrollOffset < 0 {
-			tableState.nearScrollOffset = 0
+		tableState.nearSelected -= 10
+		if tableState.nearSelected < 0 {
+			tableState.nearSelected = 0
 		}
 	} else {
-		tableState.farScrollOffset -= 10
-		if tableState.farScrollOffset < 0 {
-			tableState.farScrollOffset = 0
+		tableState.farSelected -= 10
+		if tableState.farSelected < 0 {
+			tableState.farSelected = 0
 		}
 	}
 }
 
-// handlePageDown scrolls the focused table down by 10 rows
+// handlePageDown moves the focused table's cursor down by 10 rows
 func handlePageDown(tableState *TableState) {
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset += 10
+		tableState.nearSelected += 10
 	} else {
-		tableState.farScrollOffset += 10
+		tableState.farSelected += 10
 	}
 }
 
-// handleHome scrolls the focused table to the top
+// handleHome moves the focused table's cursor to the top
 func handleHome(tableState *TableState) {
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset = 0
+		tableState.nearSelected = 0
 	} else {
-		tableState.farScrollOffset = 0
+		tableState.farSelected = 0
 	}
 }
 
-// handleEnd scrolls the focused table to the bottom
-func handleEnd(tableState *TableState, agg *Aggregator) {
-	sorted := agg.GetSorted()
+// handleEnd moves the focused table's cursor to the bottom
+func handleEnd(app *App) {
+	tableState := app.tableState
+	sorted := app.view()
 	if tableState.focusedTable == "near" {
-		tableState.nearScrollOffset = len(sorted.Recent)
+		tableState.nearSelected = len(sorted.Recent) - 1
 	} else {
-		tableState.farScrollOffset = len(sorted.Stale)
+		tableState.farSelected = len(sorted.Stale) - 1
+	}
+}
+
+// handleShowDetail opens the detail modal for the device under the cursor
+func handleShowDetail(app *App) {
+	if dev := app.selectedDevice(); dev != nil {
+		app.detailModal.Show(dev.MacAddress)
+	}
+}
+
+// handleYank copies the MAC of the device under the cursor, or with asJSON its full detail, to the clipboard
+func handleYank(app *App, asJSON bool) {
+	dev := app.selectedDevice()
+	if dev == nil {
+		return
+	}
+	if !asJSON {
+		yank(app, dev.MacAddress, dev.MacAddress)
+		return
+	}
+	snapshot := app.deviceSnapshot(dev.MacAddress)
+	if snapshot == nil {
+		snapshot = dev
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		app.setStatusMessage("✗ Copy failed: " + err.Error())
+		return
+	}
+	yank(app, dev.MacAddress+" details", string(data))
+}
+
+// handleShowProximity enters the full-screen proximity view for the device under the cursor
+func handleShowProximity(app *App) {
+	if dev := app.selectedDevice(); dev != nil {
+		app.proximity.Show(dev.MacAddress, app.agg)
+	}
+}
+
+// handleShowWatchlist opens the watchlist editor, pre-filled with the MAC under the cursor
+func handleShowWatchlist(app *App) {
+	mac := ""
+	if dev := app.selectedDevice(); dev != nil {
+		mac = dev.MacAddress
+	}
+	app.watchModal.Show(mac)
+}
+
+// handleWatchModalKey processes a key press while the watchlist editor is open
+func handleWatchModalKey(ev *tcell.EventKey, app *App) {
+	watchModal := app.watchModal
+	switch ev.Key() {
+	case tcell.KeyEsc:
+		watchModal.Hide()
+	case tcell.KeyEnter:
+		// Add the typed MAC and clear the input for the next one; a malformed MAC stays for editing
+		if mac := normalizeMAC(watchModal.input); mac != "" {
+			app.watchlist.Add(mac)
+			watchModal.input = ""
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(watchModal.input) > 0 {
+			watchModal.input = watchModal.input[:len(watchModal.input)-1]
+		}
+	case tcell.KeyUp:
+		if watchModal.selected > 0 {
+			watchModal.selected--
+		}
+	case tcell.KeyDown:
+		watchModal.selected++ // Clamped when drawn
+	case tcell.KeyDelete:
+		macs := app.watchlist.List()
+		if watchModal.selected >= 0 && watchModal.selected < len(macs) {
+			app.watchlist.Remove(macs[watchModal.selected])
+		}
+	case tcell.KeyRune:
+		// Accept only characters that can appear in a MAC address
+		ch := ev.Rune()
+		if (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F') || ch == ':' || ch == '-' {
+			if len(watchModal.input) < 17 {
+				watchModal.input += string(ch)
+			}
+		}
+	}
+}
+
+// handleMfrFilterModalKey processes a key press while the manufacturer filter picker is open
+// Enter applies the typed code if there is one, otherwise the highlighted list entry
+func handleMfrFilterModalKey(ev *tcell.EventKey, app *App) {
+	mfrModal := app.mfrModal
+	switch ev.Key() {
+	case tcell.KeyEsc:
+		mfrModal.Hide()
+	case tcell.KeyEnter:
+		if mfrModal.input != "" {
+			code, err := strconv.ParseInt(mfrModal.input, 0, 32)
+			if err != nil {
+				return // Leave the modal open so the code can be corrected
+			}
+			app.filter.SetMfrCode(int(code))
+		} else if mfrModal.selected == 0 {
+			app.filter.ClearMfrCode()
+		} else {
+			codes := countMfrCodes(app.unfilteredView())
+			if mfrModal.selected-1 < len(codes) {
+				app.filter.SetMfrCode(codes[mfrModal.selected-1].Code)
+			}
+		}
+		mfrModal.Hide()
+		resetTablePositions(app.tableState)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(mfrModal.input) > 0 {
+			mfrModal.input = mfrModal.input[:len(mfrModal.input)-1]
+		}
+	case tcell.KeyUp:
+		if mfrModal.selected > 0 {
+			mfrModal.selected--
+		}
+	case tcell.KeyDown:
+		mfrModal.selected++ // Clamped when drawn
+	case tcell.KeyRune:
+		// Accept decimal or 0x-prefixed hex codes
+		ch := ev.Rune()
+		if (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F') || ch == 'x' || ch == 'X' {
+			if len(mfrModal.input) < 6 {
+				mfrModal.input += string(ch)
+			}
+		}
+	}
+}
+
+// handleSortCycle advances the focused table to the next sort key
+func handleSortCycle(tableState *TableState) {
+	if tableState.focusedTable == "near" {
+		tableState.nearSort = tableState.nearSort.Next()
+	} else {
+		tableState.farSort = tableState.farSort.Next()
+	}
+}
+
+// handleSortReverse flips the sort direction of the focused table
+func handleSortReverse(tableState *TableState) {
+	if tableState.focusedTable == "near" {
+		tableState.nearSort = tableState.nearSort.Reversed()
+	} else {
+		tableState.farSort = tableState.farSort.Reversed()
+	}
+}
+
+// handleJumpToSignal focuses and selects the device with the strongest (or weakest) RSSI among those shown
+// drawDeviceTable scrolls the selection into view
+func handleJumpToSignal(app *App, strongest bool) {
+	sorted := app.view()
+	table, index, best := "", -1, 0
+	consider := func(name string, devices []*BLEDevice) {
+		for i, dev := range devices {
+			if index < 0 || (strongest && dev.RSSI > best) || (!strongest && dev.RSSI < best) {
+				table, index, best = name, i, dev.RSSI
+			}
+		}
+	}
+	consider("near", sorted.Recent)
+	consider("far", sorted.Stale)
+	if index < 0 {
+		return
+	}
+
+	app.tableState.focusedTable = table
+	if table == "near" {
+		app.tableState.nearSelected = index
+	} else {
+		app.tableState.farSelected = index
 	}
 }
 
@@ -228,44 +800,59 @@ func handleTabSwitch(tableState *TableState) {
 }
 
 // handleMouseEvent processes mouse input
-func handleMouseEvent(ev *tcell.EventMouse, tableState *TableState, agg *Aggregator, paused bool, s tcell.Screen, connState *ConnectionState, locState *LocationState, exportModal *ExportModalState) {
+func handleMouseEvent(ev *tcell.EventMouse, app *App) {
+	tableState := app.tableState
 	_, y := ev.Position()
 	buttons := ev.Buttons()
 
-	// Determine which table the mouse is over
-	_, height := s.Size()
-	midPoint := (height - 1) / 2
+	// The tables aren't interactive under a modal or a full-screen view
+	if app.exportModal.IsShowing() || app.clearModal.IsShowing() || app.detailModal.IsShowing() ||
+		app.watchModal.IsShowing() || app.mfrModal.IsShowing() || app.noticesModal.IsShowing() || app.helpModal.IsShowing() || app.proximity.IsShowing() ||
+		(app.density != nil && app.density.IsShowing()) {
+		return
+	}
 
-	if buttons&tcell.WheelUp != 0 {
-		// Scroll up
-		if y < midPoint && tableState.focusedTable == "near" {
-			tableState.nearScrollOffset--
-			if tableState.nearScrollOffset < 0 {
-				tableState.nearScrollOffset = 0
-			}
-		} else if y >= midPoint && tableState.focusedTable == "far" {
-			tableState.farScrollOffset--
-			if tableState.farScrollOffset < 0 {
-				tableState.farScrollOffset = 0
+	// Determine which table the mouse is over from where each was last drawn
+	over := ""
+	layout := &tableState.nearLayout
+	if tableState.nearLayout.contains(y) {
+		over = "near"
+	} else if tableState.farLayout.contains(y) {
+		over, layout = "far", &tableState.farLayout
+	}
+
+	// Wheel only moves the cursor of the focused table when hovering over it
+	overFocused := over != "" && over == tableState.focusedTable
+
+	if buttons&tcell.Button1 != 0 {
+		// Click focuses the table under the pointer and moves the cursor to the clicked device
+		if over == "" {
+			return
+		}
+		tableState.focusedTable = over
+		if index := layout.deviceAt(y); index >= 0 {
+			if over == "near" {
+				tableState.nearSelected = index
+			} else {
+				tableState.farSelected = index
 			}
 		}
-		drawTable(s, agg.GetSorted(), paused, tableState, connState, locState, exportModal)
+		app.redraw()
+	} else if buttons&tcell.WheelUp != 0 {
+		if overFocused {
+			handleScrollUp(tableState)
+		}
+		app.redraw()
 	} else if buttons&tcell.WheelDown != 0 {
-		// Scroll down
-		if y < midPoint && tableState.focusedTable == "near" {
-			tableState.nearScrollOffset++
-		} else if y >= midPoint && tableState.focusedTable == "far" {
-			tableState.farScrollOffset++
+		if overFocused {
+			handleScrollDown(tableState)
 		}
-		drawTable(s, agg.GetSorted(), paused, tableState, connState, locState, exportModal)
+		app.redraw()
 	}
 }
 
 // handleResizeEvent processes terminal resize events
-func handleResizeEvent(s tcell.Screen, agg *Aggregator, paused *bool, pauseMu *sync.RWMutex, tableState *TableState, connState *ConnectionState, locState *LocationState, exportModal *ExportModalState) {
-	s.Sync()
-	pauseMu.RLock()
-	isPaused := *paused
-	pauseMu.RUnlock()
-	drawTable(s, agg.GetSorted(), isPaused, tableState, connState, locState, exportModal)
+func handleResizeEvent(app *App) {
+	app.screen.Sync()
+	app.redraw()
 }
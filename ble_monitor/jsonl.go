@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// Records buffered between ingestion and the writer goroutine before new ones are dropped
+const jsonlBufferSize = 1024
+
+// StreamRecord is one line of the -jsonl-out stream: a single observation enriched with lookups and the GPS fix
+type StreamRecord struct {
+	Timestamp    time.Time         `json:"timestamp"`
+	MacAddress   string            `json:"mac_address"`
+	RSSI         int               `json:"rssi"`
+	DeviceName   string            `json:"device_name,omitempty"`
+	Vendor       string            `json:"vendor,omitempty"`
+	MfrCode      int               `json:"mfr_code"`
+	Company      string            `json:"company,omitempty"`
+	MfrData      string            `json:"mfr_data,omitempty"`
+	ServiceUUIDs []string          `json:"service_uuids,omitempty"`
+	ServiceData  map[string]string `json:"service_data,omitempty"`
+	Connectable  *bool             `json:"connectable,omitempty"`
+	Protocol     string            `json:"protocol,omitempty"`
+	Source       string            `json:"source,omitempty"`
+	Count        int               `json:"count"`
+	Location     *GeoLocation      `json:"location,omitempty"`
+}
+
+// JSONLStream writes StreamRecords to a sink without ever blocking ingestion
+// Records are queued on a buffered channel; when it is full the record is dropped and counted
+type JSONLStream struct {
+	records chan StreamRecord
+	out     io.WriteCloser
+	dropped atomic.Uint64
+	quit    chan struct{} // Closed by Close to stop the writer
+	done    chan struct{} // Closed once the writer goroutine has flushed and exited
+}
+
+// nopCloser keeps Close from closing stdout
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// openJSONLStream opens the sink ("-" for stdout, otherwise a file to append to) and starts the writer
+func openJSONLStream(path string) (*JSONLStream, error) {
+	var out io.WriteCloser = nopCloser{os.Stdout}
+	if path != "-" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open JSON Lines output: %w", err)
+		}
+		out = file
+	}
+
+	stream := &JSONLStream{
+		records: make(chan StreamRecord, jsonlBufferSize),
+		out:     out,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go stream.run()
+	return stream, nil
+}
+
+// Send queues a record for writing, dropping it if the writer has fallen behind
+func (js *JSONLStream) Send(rec StreamRecord) {
+	select {
+	case js.records <- rec:
+	default:
+		js.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many records were discarded because the buffer was full
+func (js *JSONLStream) Dropped() uint64 {
+	return js.dropped.Load()
+}
+
+// run encodes queued records, flushing whenever the queue drains so a live consumer sees them promptly
+func (js *JSONLStream) run() {
+	defer close(js.done)
+	w := bufio.NewWriter(js.out)
+	encoder := json.NewEncoder(w)
+	defer w.Flush()
+	for {
+		select {
+		case rec := <-js.records:
+			encoder.Encode(rec)
+			if len(js.records) == 0 {
+				w.Flush()
+			}
+		case <-js.quit:
+			// Write out whatever is still queued
+			for {
+				select {
+				case rec := <-js.records:
+					encoder.Encode(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close writes out everything queued and closes the sink
+// Records sent after Close are never written
+func (js *JSONLStream) Close() error {
+	close(js.quit)
+	<-js.done
+	return js.out.Close()
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"encoding/xml"
 	"fmt"
+	"image/color"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,7 +22,7 @@ func buildDeviceDescription(dev *BLEDevice) string {
 
 	// Last Seen
 	html.WriteString("<li><strong>Last Seen:</strong> ")
-	html.WriteString(dev.LastSeen.Format("2006-01-02 15:04:05"))
+	html.WriteString(formatDisplayTime(dev.LastSeen, exportTimeLayout))
 	html.WriteString("</li>")
 
 	// Count
@@ -50,6 +54,18 @@ func buildDeviceDescription(dev *BLEDevice) string {
 		}
 	}
 
+	// Path Length and Spread (chronological track over all collected locations)
+	if dev.GeoData != nil {
+		if locations := dev.GeoData.GetAllLocations(); len(locations) >= 2 {
+			html.WriteString("<li><strong>Path Length:</strong> ")
+			html.WriteString(formatDistance(pathLengthMeters(locations)))
+			html.WriteString("</li>")
+			html.WriteString("<li><strong>Spread:</strong> ")
+			html.WriteString(formatDistance(spreadMeters(locations)))
+			html.WriteString("</li>")
+		}
+	}
+
 	// Device Name
 	html.WriteString("<li><strong>Device Name:</strong> ")
 	if dev.DeviceName != "" {
@@ -59,10 +75,30 @@ func buildDeviceDescription(dev *BLEDevice) string {
 	}
 	html.WriteString("</li>")
 
+	// Vendor (from MAC OUI prefix)
+	html.WriteString("<li><strong>Vendor:</strong> ")
+	if vendor := lookupVendor(dev.MacAddress); vendor != "" {
+		html.WriteString(vendor)
+	} else {
+		html.WriteString("(unknown)")
+	}
+	html.WriteString("</li>")
+
+	// Protocol (only when the firmware reports it)
+	if dev.Protocol != "" {
+		html.WriteString("<li><strong>Protocol:</strong> ")
+		html.WriteString(dev.Protocol)
+		html.WriteString("</li>")
+	}
+
 	// Service UUIDs
 	html.WriteString("<li><strong>Service UUIDs:</strong> ")
 	if len(dev.ServiceUUIDs) > 0 {
-		html.WriteString(strings.Join(dev.ServiceUUIDs, ", "))
+		names := make([]string, len(dev.ServiceUUIDs))
+		for i, uuid := range dev.ServiceUUIDs {
+			names[i] = formatServiceUUID(uuid)
+		}
+		html.WriteString(strings.Join(names, ", "))
 	} else {
 		html.WriteString("(none)")
 	}
@@ -71,7 +107,7 @@ func buildDeviceDescription(dev *BLEDevice) string {
 	// Manufacturer Code
 	html.WriteString("<li><strong>Mfr ID:</strong> ")
 	if dev.MfrCode != 0 {
-		html.WriteString(fmt.Sprintf("%d", dev.MfrCode))
+		html.WriteString(formatMfrCode(dev.MfrCode))
 	} else {
 		html.WriteString("(none)")
 	}
@@ -94,36 +130,49 @@ func buildDeviceDescription(dev *BLEDevice) string {
 // getMaxRSSI returns the maximum RSSI from a list of locations with their RSSIs
 func getMaxRSSI(locations []GeoLocation, dev *BLEDevice) int {
 	// Get max RSSI from the device's GeoData
-	dev.GeoData.mu.RLock()
-	defer dev.GeoData.mu.RUnlock()
-
-	if len(dev.GeoData.allRSSIs) == 0 {
+	rssis := dev.GeoData.RSSIs()
+	if len(rssis) == 0 {
 		return dev.RSSI // Fallback to current RSSI
 	}
 
-	return dev.GeoData.allRSSIs[0] // First element is highest (sorted descending)
+	return rssis[0] // First element is highest (sorted descending)
+}
+
+// Style id of the session boundary polygon
+const sessionBoundaryStyleID = "session-boundary"
+
+// Appended to an RSSI band's style id for its range ring style
+const rangeRingStyleSuffix = "-ring"
+
+// createSharedStyles creates the shared Style elements device exports refer to:
+// one per RSSI band, an unfilled one per band for range rings, then the translucent session boundary
+// Rings get styles of their own rather than an inline PolyStyle, since viewers differ on whether
+// an inline sub-style is merged with the shared one or replaces it
+func createSharedStyles() []kml.Element {
+	styles := make([]kml.Element, 0, 2*len(rssiBands)+1)
+	for _, band := range rssiBands {
+		styles = append(styles, sharedLineStyle(band.styleID, band.kmlColor, 3))
+	}
+	for _, band := range rssiBands {
+		styles = append(styles, kml.SharedStyle(band.styleID+rangeRingStyleSuffix,
+			kml.LineStyle(kml.Color(band.kmlColor), kml.Width(3)),
+			kml.PolyStyle(kml.Fill(false)),
+		))
+	}
+	return append(styles, sharedLineStyle(sessionBoundaryStyleID, color.RGBA{B: 0xff, A: 0x80}, 4))
 }
 
-// createRSSIStyles creates shared Style elements for RSSI-based coloring
-// Styles are generated as raw XML via generateStylesXML() for simplicity
-func createRSSIStyles() []kml.Element {
-	// Return empty - styles are added via generateStylesXML() as raw XML
-	return []kml.Element{}
+// sharedLineStyle creates a shared Style drawing lines and polygon fills in c
+func sharedLineStyle(id string, c color.RGBA, width float64) kml.Element {
+	return kml.SharedStyle(id,
+		kml.LineStyle(kml.Color(c), kml.Width(width)),
+		kml.PolyStyle(kml.Color(c)),
+	)
 }
 
 // getStyleURLForRSSI returns the style URL reference for a given RSSI
 func getStyleURLForRSSI(rssi int) string {
-	if rssi > -50 {
-		return "#rssi-blue"
-	} else if rssi > -60 {
-		return "#rssi-green"
-	} else if rssi > -70 {
-		return "#rssi-yellow"
-	} else if rssi > -80 {
-		return "#rssi-orange"
-	} else {
-		return "#rssi-red"
-	}
+	return "#" + bandForRSSI(rssi).styleID
 }
 
 // computeConvexHull computes the convex hull of a set of points using Graham scan
@@ -200,13 +249,14 @@ func isCounterClockwise(p1, p2, p3 GeoLocation) bool {
 }
 
 // sortByPolarAngle sorts points by polar angle relative to pivot (in place)
+// Points at the same angle are ordered nearest first, so the scan keeps only the farthest
 func sortByPolarAngle(points []GeoLocation, pivot GeoLocation) {
 	// Simple insertion sort by angle (good enough for small N)
 	for i := 1; i < len(points); i++ {
 		key := points[i]
 		j := i - 1
 
-		for j >= 0 && polarAngle(pivot, points[j]) > polarAngle(pivot, key) {
+		for j >= 0 && polarLess(pivot, key, points[j]) {
 			points[j+1] = points[j]
 			j--
 		}
@@ -214,22 +264,55 @@ func sortByPolarAngle(points []GeoLocation, pivot GeoLocation) {
 	}
 }
 
-// polarAngle computes the polar angle from pivot to point
+// polarLess reports whether a sorts before b around pivot: smaller polar angle, then nearer
+func polarLess(pivot, a, b GeoLocation) bool {
+	angleA, angleB := polarAngle(pivot, a), polarAngle(pivot, b)
+	if angleA != angleB {
+		return angleA < angleB
+	}
+	return pivotDistanceSquared(pivot, a) < pivotDistanceSquared(pivot, b)
+}
+
+// polarAngle computes the polar angle from pivot to point, in radians
+// The pivot is the lowest point, so every angle falls in [0, π]
 func polarAngle(pivot, point GeoLocation) float64 {
 	dy := point.Latitude - pivot.Latitude
 	dx := point.Longitude - pivot.Longitude
+	return math.Atan2(dy, dx)
+}
+
+// pivotDistanceSquared returns the squared planar distance in degrees from pivot to point
+func pivotDistanceSquared(pivot, point GeoLocation) float64 {
+	dy := point.Latitude - pivot.Latitude
+	dx := point.Longitude - pivot.Longitude
+	return dx*dx + dy*dy
+}
+
+// Default minimum spacing between path points (-kml-path-spacing), about the jitter of a stationary GPS fix
+const defaultKMLPathSpacing = 2.0
 
-	// Handle special cases to avoid division by zero
-	if dx == 0 && dy == 0 {
-		return 0 // Same point
+// kmlPathSpacing is the minimum distance in meters between consecutive path points; 0 keeps every point
+var kmlPathSpacing = defaultKMLPathSpacing
+
+// dedupeByDistance drops points closer than meters to the last point kept, collapsing GPS jitter
+// while stationary into a single point. The first point is always kept
+func dedupeByDistance(points []GeoLocation, meters float64) []GeoLocation {
+	if len(points) <= 1 || meters <= 0 {
+		return points
 	}
-	if dx == 0 {
-		if dy > 0 {
-			return 1e9 // Vertical up (very large angle)
+
+	deduped := []GeoLocation{points[0]}
+	for _, point := range points[1:] {
+		if haversineMeters(deduped[len(deduped)-1], point) >= meters {
+			deduped = append(deduped, point)
 		}
-		return -1e9 // Vertical down
 	}
-	return dy / dx // Simplified comparison for sorting purposes
+	return deduped
+}
+
+// pathCoordinate converts a location to a KML coordinate
+func pathCoordinate(loc GeoLocation) kml.Coordinate {
+	return kml.Coordinate{Lon: loc.Longitude, Lat: loc.Latitude, Alt: loc.Elevation}
 }
 
 // smoothPath applies Ramer-Douglas-Peucker algorithm to simplify/smooth a path
@@ -241,8 +324,8 @@ func smoothPath(points []GeoLocation) []GeoLocation {
 
 	// Epsilon controls how much simplification occurs
 	// Larger epsilon = more simplification
-	// This is in degrees; ~0.0001 degrees ≈ 11 meters at equator
-	const epsilon = 0.0001
+	// This is in meters, so it means the same thing at every latitude
+	const epsilon = 11.0
 
 	return douglasPeucker(points, epsilon)
 }
@@ -283,58 +366,177 @@ func douglasPeucker(points []GeoLocation, epsilon float64) []GeoLocation {
 	return []GeoLocation{points[0], points[end]}
 }
 
-// perpendicularDistance calculates the perpendicular distance from point to line segment
+// perpendicularDistance calculates the perpendicular distance in meters from point to the line through lineStart and lineEnd
+// Coordinates are projected onto a local plane around lineStart (longitude scaled by cos(latitude)),
+// which matches haversineMeters closely over the short spans a path covers
 func perpendicularDistance(point, lineStart, lineEnd GeoLocation) float64 {
-	// Using simplified 2D distance for lat/lon (good enough for small distances)
-	x := point.Longitude
-	y := point.Latitude
-	x1 := lineStart.Longitude
-	y1 := lineStart.Latitude
-	x2 := lineEnd.Longitude
-	y2 := lineEnd.Latitude
-
-	dx := x2 - x1
-	dy := y2 - y1
-
 	// Handle degenerate case where line segment is a point
-	if dx == 0 && dy == 0 {
-		// Distance to point
-		return ((x-x1)*(x-x1) + (y-y1)*(y-y1))
+	if lineStart.Latitude == lineEnd.Latitude && lineStart.Longitude == lineEnd.Longitude {
+		return haversineMeters(point, lineStart)
 	}
 
+	// Project to meters relative to lineStart
+	metersPerDegree := earthRadiusMeters * math.Pi / 180
+	lonScale := math.Cos(lineStart.Latitude * math.Pi / 180)
+	x := (point.Longitude - lineStart.Longitude) * lonScale * metersPerDegree
+	y := (point.Latitude - lineStart.Latitude) * metersPerDegree
+	x2 := (lineEnd.Longitude - lineStart.Longitude) * lonScale * metersPerDegree
+	y2 := (lineEnd.Latitude - lineStart.Latitude) * metersPerDegree
+
 	// Calculate perpendicular distance using cross product
-	numerator := ((y2-y1)*x - (x2-x1)*y + x2*y1 - y2*x1)
-	if numerator < 0 {
-		numerator = -numerator
-	}
-	denominator := (dx*dx + dy*dy)
+	return math.Abs(y2*x-x2*y) / math.Hypot(x2, y2)
+}
+
+// Imperial unit conversions for formatDistanceUnits
+const (
+	metersPerFoot = 0.3048
+	feetPerMile   = 5280
+)
 
-	if denominator == 0 {
-		return 0
+// formatDistance formats a distance in meters, switching to kilometers above 1 km
+func formatDistance(meters float64) string {
+	if meters >= 1000 {
+		return fmt.Sprintf("%.2f km", meters/1000)
 	}
+	return fmt.Sprintf("%.0f m", meters)
+}
 
-	// Return normalized distance
-	return (numerator * numerator) / denominator
+// formatDistanceUnits formats a distance in meters, or in feet and miles when imperial is set
+func formatDistanceUnits(meters float64, imperial bool) string {
+	if !imperial {
+		return formatDistance(meters)
+	}
+	feet := meters / metersPerFoot
+	if feet >= feetPerMile/10 {
+		return fmt.Sprintf("%.2f mi", feet/feetPerMile)
+	}
+	return fmt.Sprintf("%.0f ft", feet)
 }
 
 // createPlacemarksForDevice creates KML placemarks for a device
 // Returns up to 3 placemarks: point, path, polygon
 
+// KML folder layouts for device exports (-kml-layout)
+const (
+	kmlLayoutGeometry = "geometry" // Points, Paths and Polygons folders holding every device
+	kmlLayoutDevice   = "device"   // One folder per device holding its point, path and polygon
+)
+
+// kmlLayout is the folder layout used by device KML exports
+var kmlLayout = kmlLayoutGeometry
+
+// setKMLLayout selects the folder layout for device KML exports
+func setKMLLayout(name string) error {
+	switch name {
+	case kmlLayoutGeometry, kmlLayoutDevice:
+		kmlLayout = name
+		return nil
+	}
+	return fmt.Errorf("unknown layout %q (want geometry or device)", name)
+}
+
+// kmlDeviceFolderName names a device's folder by MAC, with the vendor when the OUI is known
+func kmlDeviceFolderName(dev *BLEDevice) string {
+	if vendor := lookupVendor(dev.MacAddress); vendor != "" {
+		return fmt.Sprintf("%s (%s)", dev.MacAddress, vendor)
+	}
+	return dev.MacAddress
+}
+
+// Vertices in each range ring polygon
+const rangeRingVertices = 36
+
+// Range rings around each device's estimated position (-kml-range-rings), sized from its strongest RSSI
+var (
+	kmlRangeRings   bool
+	kmlRangeTxPower int = pathLossRefRSSI // RSSI expected at 1 m (-kml-tx-power)
+)
+
+// circlePolygon returns a closed ring of vertices points radiusMeters around center
+// It works on a local flat approximation, which is plenty for the tens of meters BLE reaches
+func circlePolygon(center GeoLocation, radiusMeters float64, vertices int) []GeoLocation {
+	metersPerDegree := earthRadiusMeters * math.Pi / 180
+	dLat := radiusMeters / metersPerDegree
+	dLon := dLat / math.Max(math.Cos(center.Latitude*math.Pi/180), 1e-6)
+
+	ring := make([]GeoLocation, vertices+1)
+	for i := 0; i < vertices; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(vertices)
+		ring[i] = GeoLocation{
+			Latitude:  center.Latitude + dLat*math.Sin(angle),
+			Longitude: center.Longitude + dLon*math.Cos(angle),
+			Elevation: center.Elevation,
+		}
+	}
+	ring[vertices] = ring[0] // Close the ring
+	return ring
+}
+
+// rangeRingPlacemark draws an unfilled circle around center whose radius is the distance the
+// path-loss model gives for rssi, as a visual uncertainty radius for the point estimate
+func rangeRingPlacemark(dev *BLEDevice, center GeoLocation, rssi int) kml.Element {
+	radius := rssiToMeters(rssi, kmlRangeTxPower)
+	ring := circlePolygon(center, radius, rangeRingVertices)
+	coords := make([]kml.Coordinate, len(ring))
+	for i, loc := range ring {
+		coords[i] = pathCoordinate(loc)
+	}
+
+	return kml.Placemark(
+		kml.Name(dev.MacAddress),
+		kml.Description(fmt.Sprintf("<ul><li><strong>Range:</strong> ~%s at %d dBm (path-loss estimate, %d dBm at 1 m)</li></ul>",
+			formatDistance(radius), rssi, kmlRangeTxPower)),
+		// Outline only, in the RSSI color, so the ring doesn't hide what it surrounds
+		kml.StyleURL(getStyleURLForRSSI(rssi)+rangeRingStyleSuffix),
+		kml.Polygon(
+			kml.OuterBoundaryIs(
+				kml.LinearRing(
+					kml.Coordinates(coords...),
+				),
+			),
+		),
+	)
+}
+
 // ExportKML exports all devices with geolocation data to a KML file
-// Organized into layers: Points, Paths, Polygons, and Session Boundary
+// Organized into layers (Points, Paths, Polygons) or per-device folders, plus a Session Boundary
 func (a *Aggregator) ExportKML(filename string) error {
-	sorted := a.GetSorted()
+	// Work from a snapshot so every device's geo data is internally consistent while ingestion continues
+	return exportDevicesKML(filename, a.GetSnapshotBy(defaultRecentSort, defaultStaleSort).All())
+}
+
+// exportDevicesKML writes the given devices (typically a snapshot) to a KML file
+func exportDevicesKML(filename string, allDevices []*BLEDevice) error {
+	// Create KML document
+	doc := kml.KML(
+		kml.Document(devicesKMLElements(allDevices)...),
+	)
 
-	// Combine all devices (recent first, then stale)
-	allDevices := make([]*BLEDevice, 0, len(sorted.Recent)+len(sorted.Stale))
-	allDevices = append(allDevices, sorted.Recent...)
-	allDevices = append(allDevices, sorted.Stale...)
+	// Create file
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
 
+	// Write KML
+	if err := doc.WriteIndent(file, "", "  "); err != nil {
+		return fmt.Errorf("failed to write KML: %w", err)
+	}
+
+	return nil
+}
+
+// devicesKMLElements builds the contents of a device KML Document: its name, the RSSI styles,
+// the device layers (or per-device folders) and the session boundary
+func devicesKMLElements(allDevices []*BLEDevice) []kml.Element {
 	// Separate placemarks by type (layer)
 	var pointPlacemarks []kml.Element
 	var pathPlacemarks []kml.Element
 	var polygonPlacemarks []kml.Element
-	var allPoints []GeoLocation // Collect all points for session boundary
+	var ringPlacemarks []kml.Element // Range rings, a layer of their own in either layout
+	var deviceFolders []kml.Element  // One folder per device with -kml-layout device
+	var allPoints []GeoLocation      // Collect all points for session boundary
 
 	for _, dev := range allDevices {
 		if dev.GeoData == nil {
@@ -342,36 +544,17 @@ func (a *Aggregator) ExportKML(filename string) error {
 		}
 
 		// Get location data from all RSSIs
-		dev.GeoData.mu.RLock()
-
-		if len(dev.GeoData.allRSSIs) == 0 {
-			dev.GeoData.mu.RUnlock()
+		allRSSIValues := dev.GeoData.RSSIs()
+		if len(allRSSIValues) == 0 {
 			continue
 		}
 
-		// For points: use only the highest RSSI
-		highestRSSI := dev.GeoData.allRSSIs[0]
-		highestBuffer := dev.GeoData.data[highestRSSI]
-
-		var highestLocations []GeoLocation
-		if highestBuffer != nil && highestBuffer.Size() > 0 {
-			highestLocations = highestBuffer.GetAll()
-		}
-
-		// For paths and polygons: collect ALL locations from ALL RSSIs
-		var allDeviceLocations []GeoLocation
-		for _, rssi := range dev.GeoData.allRSSIs {
-			buffer := dev.GeoData.data[rssi]
-			if buffer != nil && buffer.Size() > 0 {
-				locations := buffer.GetAll()
-				allDeviceLocations = append(allDeviceLocations, locations...)
-			}
-		}
-
-		dev.GeoData.mu.RUnlock()
+		// For paths and polygons: ALL locations from ALL RSSIs, oldest first so a path
+		// follows the order the device was heard in rather than jumping between RSSIs
+		allDeviceLocations := dev.GeoData.GetAllLocations()
 
 		// Skip if we have no data at all
-		if len(highestLocations) == 0 && len(allDeviceLocations) == 0 {
+		if len(allDeviceLocations) == 0 {
 			continue
 		}
 
@@ -379,93 +562,68 @@ func (a *Aggregator) ExportKML(filename string) error {
 		allPoints = append(allPoints, allDeviceLocations...)
 
 		description := buildDeviceDescription(dev)
+		var devicePoints, devicePaths, devicePolygons []kml.Element
 
-		// Calculate average location from highest RSSI only
-		var avgLoc *GeoLocation
-		if len(highestLocations) > 0 {
-			var sumLat, sumLon, sumEl float64
-			for _, loc := range highestLocations {
-				sumLat += loc.Latitude
-				sumLon += loc.Longitude
-				sumEl += loc.Elevation
-			}
-			count := float64(len(highestLocations))
-			avgLoc = &GeoLocation{
-				Latitude:  sumLat / count,
-				Longitude: sumLon / count,
-				Elevation: sumEl / count,
+		// 1. Point: signal-weighted position estimate across all RSSIs
+		if estimate := dev.GeoData.EstimatePosition(); estimate != nil {
+			// With a range ring sized from the strongest RSSI (allRSSIValues is strongest first)
+			if kmlRangeRings {
+				ringPlacemarks = append(ringPlacemarks, rangeRingPlacemark(dev, *estimate, allRSSIValues[0]))
 			}
-		}
-
-		// 1. Point (if at least 1 location in highest RSSI)
-		if avgLoc != nil {
-			pointPlacemarks = append(pointPlacemarks, kml.Placemark(
+			devicePoints = append(devicePoints, kml.Placemark(
 				kml.Name(dev.MacAddress),
 				kml.Description(description),
 				kml.Point(
 					kml.Coordinates(kml.Coordinate{
-						Lon: avgLoc.Longitude,
-						Lat: avgLoc.Latitude,
-						Alt: avgLoc.Elevation,
+						Lon: estimate.Longitude,
+						Lat: estimate.Latitude,
+						Alt: estimate.Elevation,
 					}),
 				),
 			))
 		}
 
-		// 2. Path (if at least 2 locations across ALL RSSIs)
+		// 2. Path (if at least 2 locations across ALL RSSIs, once GPS jitter is collapsed)
 		// Create multi-segment paths, each segment colored by its RSSI
-		if len(allDeviceLocations) >= 2 {
-			smoothedPath := smoothPath(allDeviceLocations)
-
-			// We need to create multi-segment paths
-			// Since we don't have RSSI per point, we'll sample from the device's RSSIs
-			// and create segments based on signal strength zones
-
-			// Get all RSSIs for this device to determine segment colors
-			dev.GeoData.mu.RLock()
-			allRSSIValues := make([]int, len(dev.GeoData.allRSSIs))
-			copy(allRSSIValues, dev.GeoData.allRSSIs)
-			dev.GeoData.mu.RUnlock()
-
-			// Create segments (approximate gradient by breaking path into colored pieces)
-			// We'll divide the path into segments and assign RSSI based on position
-			segmentCount := min(len(smoothedPath)-1, len(allRSSIValues))
-			if segmentCount < 1 {
-				segmentCount = len(smoothedPath) - 1
-			}
-
-			// Create one placemark per segment
-			for i := 0; i < len(smoothedPath)-1; i++ {
-				// Determine RSSI for this segment (interpolate through available RSSIs)
-				rssiIdx := (i * len(allRSSIValues)) / max(len(smoothedPath)-1, 1)
-				if rssiIdx >= len(allRSSIValues) {
-					rssiIdx = len(allRSSIValues) - 1
+		if pathLocations := dedupeByDistance(allDeviceLocations, kmlPathSpacing); len(pathLocations) >= 2 {
+			smoothedPath := smoothPath(pathLocations)
+
+			// Each segment is colored by the RSSI heard at its later point; smoothing only drops
+			// points, so every one left is a stored location. Consecutive segments in the same
+			// RSSI band are joined into one LineString
+			rssiAt := dev.GeoData.RSSIByLocation()
+			var runCoords []kml.Coordinate
+			runStyle := ""
+			flushRun := func() {
+				if len(runCoords) < 2 {
+					return
 				}
-				segmentRSSI := allRSSIValues[rssiIdx]
-
-				// Create a two-point line segment
-				segmentCoords := []kml.Coordinate{
-					{
-						Lon: smoothedPath[i].Longitude,
-						Lat: smoothedPath[i].Latitude,
-						Alt: smoothedPath[i].Elevation,
-					},
-					{
-						Lon: smoothedPath[i+1].Longitude,
-						Lat: smoothedPath[i+1].Latitude,
-						Alt: smoothedPath[i+1].Elevation,
-					},
-				}
-
-				pathPlacemarks = append(pathPlacemarks, kml.Placemark(
-					kml.Name(fmt.Sprintf("%s-seg%d", dev.MacAddress, i)),
+				devicePaths = append(devicePaths, kml.Placemark(
+					kml.Name(fmt.Sprintf("%s-seg%d", dev.MacAddress, len(devicePaths))),
 					kml.Description(description),
-					kml.StyleURL(getStyleURLForRSSI(segmentRSSI)),
+					kml.StyleURL(runStyle),
 					kml.LineString(
-						kml.Coordinates(segmentCoords...),
+						kml.Coordinates(runCoords...),
 					),
 				))
 			}
+
+			for i := 0; i < len(smoothedPath)-1; i++ {
+				rssi, ok := rssiAt[smoothedPath[i+1]]
+				if !ok {
+					rssi = dev.RSSI
+				}
+				segmentStyle := getStyleURLForRSSI(rssi)
+
+				// Start a new placemark only where the band changes, sharing the boundary point
+				if segmentStyle != runStyle {
+					flushRun()
+					runStyle = segmentStyle
+					runCoords = []kml.Coordinate{pathCoordinate(smoothedPath[i])}
+				}
+				runCoords = append(runCoords, pathCoordinate(smoothedPath[i+1]))
+			}
+			flushRun()
 		}
 
 		// 3. Polygon (if at least 3 locations across ALL RSSIs)
@@ -489,7 +647,7 @@ func (a *Aggregator) ExportKML(filename string) error {
 			// Close the polygon by repeating the first point
 			coords[len(hull)] = coords[0]
 
-			polygonPlacemarks = append(polygonPlacemarks, kml.Placemark(
+			devicePolygons = append(devicePolygons, kml.Placemark(
 				kml.Name(dev.MacAddress),
 				kml.Description(description),
 				kml.StyleURL(getStyleURLForRSSI(maxRSSI)),
@@ -502,15 +660,34 @@ func (a *Aggregator) ExportKML(filename string) error {
 				),
 			))
 		}
+
+		if kmlLayout == kmlLayoutDevice {
+			folderElements := []kml.Element{kml.Name(kmlDeviceFolderName(dev))}
+			folderElements = append(folderElements, devicePoints...)
+			folderElements = append(folderElements, devicePaths...)
+			folderElements = append(folderElements, devicePolygons...)
+			deviceFolders = append(deviceFolders, kml.Folder(folderElements...))
+		} else {
+			pointPlacemarks = append(pointPlacemarks, devicePoints...)
+			pathPlacemarks = append(pathPlacemarks, devicePaths...)
+			polygonPlacemarks = append(polygonPlacemarks, devicePolygons...)
+		}
 	}
 
 	// Build document elements
 	docElements := []kml.Element{
-		kml.Name(fmt.Sprintf("BLE Devices - %s", time.Now().Format("2006-01-02 15:04:05"))),
+		kml.Name(fmt.Sprintf("BLE Devices - %s", formatDisplayTime(time.Now(), exportTimeLayout))),
 	}
 
 	// Add shared styles for RSSI-based coloring
-	docElements = append(docElements, createRSSIStyles()...)
+	docElements = append(docElements, createSharedStyles()...)
+
+	// Add Devices folder (per-device layout)
+	if len(deviceFolders) > 0 {
+		devicesFolderElements := []kml.Element{kml.Name("Devices")}
+		devicesFolderElements = append(devicesFolderElements, deviceFolders...)
+		docElements = append(docElements, kml.Folder(devicesFolderElements...))
+	}
 
 	// Add Points folder
 	if len(pointPlacemarks) > 0 {
@@ -533,6 +710,13 @@ func (a *Aggregator) ExportKML(filename string) error {
 		docElements = append(docElements, kml.Folder(polygonsFolderElements...))
 	}
 
+	// Add Range Rings folder
+	if len(ringPlacemarks) > 0 {
+		ringsFolderElements := []kml.Element{kml.Name("Range Rings")}
+		ringsFolderElements = append(ringsFolderElements, ringPlacemarks...)
+		docElements = append(docElements, kml.Folder(ringsFolderElements...))
+	}
+
 	// Add Session Boundary folder (if we have any points)
 	if len(allPoints) > 0 {
 		sessionBoundary := createSessionBoundary(allPoints)
@@ -545,24 +729,214 @@ func (a *Aggregator) ExportKML(filename string) error {
 		}
 	}
 
-	// Create KML document
-	doc := kml.KML(
-		kml.Document(docElements...),
-	)
+	return docElements
+}
 
-	// Create file
-	file, err := os.Create(filename)
+// KML structures used when reading existing files for merge/update
+// Only the parts we need are modeled; geometry and any other Placemark children are kept verbatim
+
+// kmlRoot is the top-level <kml> element
+// Exported files wrap everything in a Document, but hand-edited files may use a Folder instead
+type kmlRoot struct {
+	XMLName  xml.Name     `xml:"kml"`
+	Xmlns    string       `xml:"xmlns,attr,omitempty"`
+	Document *kmlDocument `xml:"Document"`
+	Folder   *kmlFolder   `xml:"Folder"`
+}
+
+// kmlDocument is a <Document> container
+type kmlDocument struct {
+	Name       string         `xml:"name,omitempty"`
+	StylesXML  string         `xml:",innerxml"` // Written verbatim; ignored when reading
+	Folders    []kmlFolder    `xml:"Folder"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+// kmlFolder is a (possibly nested) <Folder> container
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Folders    []kmlFolder    `xml:"Folder"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+// kmlPlacemark is a <Placemark> with its geometry preserved as raw XML
+type kmlPlacemark struct {
+	ID          string       `xml:"id,attr,omitempty"`
+	Name        string       `xml:"name,omitempty"`
+	Description string       `xml:"description,omitempty"`
+	StyleURL    string       `xml:"styleUrl,omitempty"`
+	Children    []kmlRawNode `xml:",any"`
+}
+
+// kmlRawNode is any element we don't model, kept as its raw inner XML
+type kmlRawNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+// kmlLayers holds placemarks grouped by the folders we export
+type kmlLayers struct {
+	Points   []kmlPlacemark
+	Paths    []kmlPlacemark
+	Polygons []kmlPlacemark
+	Rings    []kmlPlacemark // Range rings (derived from RSSI, kept as they are)
+	Sessions []kmlPlacemark // Session boundaries (recomputed on write)
+}
+
+// parseKMLFile reads a KML file and groups its placemarks into layers
+func parseKMLFile(filePath string) (*kmlLayers, error) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	defer file.Close()
 
-	// Write KML
-	if err := doc.WriteIndent(file, "", "  "); err != nil {
-		return fmt.Errorf("failed to write KML: %w", err)
+	var root kmlRoot
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse KML: %w", err)
 	}
 
-	return nil
+	layers := &kmlLayers{}
+	if root.Document != nil {
+		layers.add("", root.Document.Placemarks)
+		for _, folder := range root.Document.Folders {
+			layers.addFolder("", folder)
+		}
+	}
+	if root.Folder != nil {
+		layers.addFolder("", *root.Folder)
+	}
+	return layers, nil
+}
+
+// addFolder adds all placemarks in a folder tree
+// The first folder named like one of our layers decides the layer for everything below it
+func (l *kmlLayers) addFolder(layer string, folder kmlFolder) {
+	if layer == "" {
+		switch strings.TrimSpace(folder.Name) {
+		case "Points", "Paths", "Polygons", "Range Rings", "Session Boundary":
+			layer = strings.TrimSpace(folder.Name)
+		}
+	}
+	l.add(layer, folder.Placemarks)
+	for _, sub := range folder.Folders {
+		l.addFolder(layer, sub)
+	}
+}
+
+// add appends placemarks to the given layer, or infers the layer from geometry if unknown
+func (l *kmlLayers) add(layer string, placemarks []kmlPlacemark) {
+	for _, pm := range placemarks {
+		pm.stripNamespaces()
+		target := layer
+		if target == "" {
+			target = pm.inferLayer()
+		}
+		switch target {
+		case "Points":
+			l.Points = append(l.Points, pm)
+		case "Paths":
+			l.Paths = append(l.Paths, pm)
+		case "Polygons":
+			l.Polygons = append(l.Polygons, pm)
+		case "Range Rings":
+			l.Rings = append(l.Rings, pm)
+		case "Session Boundary":
+			l.Sessions = append(l.Sessions, pm)
+		}
+	}
+}
+
+// stripNamespaces clears the KML namespace from preserved children so they re-serialize without xmlns attributes
+func (pm *kmlPlacemark) stripNamespaces() {
+	for i := range pm.Children {
+		pm.Children[i].XMLName.Space = ""
+		attrs := pm.Children[i].Attrs[:0]
+		for _, attr := range pm.Children[i].Attrs {
+			if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+				attrs = append(attrs, attr)
+			}
+		}
+		pm.Children[i].Attrs = attrs
+	}
+}
+
+// inferLayer picks a layer from the placemark's geometry element
+func (pm *kmlPlacemark) inferLayer() string {
+	for _, child := range pm.Children {
+		switch child.XMLName.Local {
+		case "Point":
+			return "Points"
+		case "LineString":
+			return "Paths"
+		case "Polygon":
+			return "Polygons"
+		}
+	}
+	return ""
+}
+
+// coordinates returns every coordinate tuple in the placemark's geometry
+func (pm *kmlPlacemark) coordinates() []GeoLocation {
+	var locations []GeoLocation
+	for _, child := range pm.Children {
+		decoder := xml.NewDecoder(strings.NewReader(child.InnerXML))
+		inCoords := false
+		for {
+			tok, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				inCoords = t.Name.Local == "coordinates"
+			case xml.EndElement:
+				inCoords = false
+			case xml.CharData:
+				if inCoords {
+					locations = append(locations, parseKMLCoordinates(string(t))...)
+				}
+			}
+		}
+	}
+	return locations
+}
+
+// parseKMLCoordinates parses a whitespace-separated list of "lon,lat[,alt]" tuples
+func parseKMLCoordinates(text string) []GeoLocation {
+	var locations []GeoLocation
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		lon, errLon := strconv.ParseFloat(parts[0], 64)
+		lat, errLat := strconv.ParseFloat(parts[1], 64)
+		if errLon != nil || errLat != nil {
+			continue
+		}
+		var alt float64
+		if len(parts) >= 3 {
+			alt, _ = strconv.ParseFloat(parts[2], 64)
+		}
+		locations = append(locations, GeoLocation{
+			Latitude:  lat,
+			Longitude: lon,
+			Elevation: alt,
+		})
+	}
+	return locations
+}
+
+// allCoordinates returns every coordinate in the device layers (range rings and session boundaries are derived, so skipped)
+func (l *kmlLayers) allCoordinates() []GeoLocation {
+	var locations []GeoLocation
+	for _, group := range [][]kmlPlacemark{l.Points, l.Paths, l.Polygons} {
+		for i := range group {
+			locations = append(locations, group[i].coordinates()...)
+		}
+	}
+	return locations
 }
 
 // updateKMLAndExit updates an existing KML file with new features (styling, etc.)
@@ -575,46 +949,33 @@ func updateKMLAndExit(filePath string) error {
 		return fmt.Errorf("file does not exist: %s", filePath)
 	}
 
-	// Read and parse the existing KML
+	// Read the existing KML (kept for the backup)
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	kmlText := string(content)
-
 	// Extract placemarks from each folder
 	fmt.Println("Extracting placemarks...")
-	pointPlacemarks := extractPlacemarksFromFolder(kmlText, "Points")
-	pathPlacemarks := extractPlacemarksFromFolder(kmlText, "Paths")
-	polygonPlacemarks := extractPlacemarksFromFolder(kmlText, "Polygons")
-	sessionBoundaryPlacemarks := extractPlacemarksFromFolder(kmlText, "Session Boundary")
+	layers, err := parseKMLFile(filePath)
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("  Found %d points, %d paths, %d polygons, %d session boundaries\n",
-		len(pointPlacemarks), len(pathPlacemarks), len(polygonPlacemarks), len(sessionBoundaryPlacemarks))
+		len(layers.Points), len(layers.Paths), len(layers.Polygons), len(layers.Sessions))
 
 	// Update placemarks with styling
 	fmt.Println("Adding RSSI-based styling...")
 
-	// Update paths: extract RSSI and add styleUrl
-	styledPaths := make([]string, 0, len(pathPlacemarks))
-	for _, placemark := range pathPlacemarks {
-		rssi := extractRSSIFromPlacemark(placemark)
-		styled := addStyleURLToPlacemark(placemark, getStyleURLForRSSI(rssi))
-		styledPaths = append(styledPaths, styled)
+	// Update paths and polygons: extract RSSI and set styleUrl
+	for i := range layers.Paths {
+		layers.Paths[i].StyleURL = getStyleURLForRSSI(extractRSSIFromDescription(layers.Paths[i].Description))
 	}
-
-	// Update polygons: extract RSSI and add styleUrl
-	styledPolygons := make([]string, 0, len(polygonPlacemarks))
-	for _, placemark := range polygonPlacemarks {
-		rssi := extractRSSIFromPlacemark(placemark)
-		styled := addStyleURLToPlacemark(placemark, getStyleURLForRSSI(rssi))
-		styledPolygons = append(styledPolygons, styled)
+	for i := range layers.Polygons {
+		layers.Polygons[i].StyleURL = getStyleURLForRSSI(extractRSSIFromDescription(layers.Polygons[i].Description))
 	}
 
-	// Extract all coordinates for potential session boundary recomputation
-	allCoords := extractAllCoordinates(kmlText)
-
 	fmt.Println("Writing updated KML...")
 
 	// Create backup
@@ -626,7 +987,7 @@ func updateKMLAndExit(filePath string) error {
 	}
 
 	// Write updated KML back to original file
-	if err := writeMergedKML(filePath, pointPlacemarks, styledPaths, styledPolygons, allCoords); err != nil {
+	if err := writeMergedKML(filePath, layers, layers.allCoordinates()); err != nil {
 		return fmt.Errorf("failed to write updated KML: %w", err)
 	}
 
@@ -637,78 +998,25 @@ func updateKMLAndExit(filePath string) error {
 	return nil
 }
 
-// extractRSSIFromPlacemark extracts RSSI value from placemark CDATA description
-func extractRSSIFromPlacemark(placemark string) int {
+// extractRSSIFromDescription extracts the RSSI value from a placemark's HTML description
+func extractRSSIFromDescription(description string) int {
 	// Look for <strong>RSSI:</strong> {value}
-	rssiTag := "<strong>RSSI:</strong>"
-	startIdx := strings.Index(placemark, rssiTag)
-	if startIdx == -1 {
+	_, after, found := strings.Cut(description, "<strong>RSSI:</strong>")
+	if !found {
 		return -100 // Default to very weak if not found
 	}
 
-	startIdx += len(rssiTag)
-
-	// Find the next </li> tag
-	endIdx := strings.Index(placemark[startIdx:], "</li>")
-	if endIdx == -1 {
-		return -100
-	}
-
-	rssiStr := strings.TrimSpace(placemark[startIdx : startIdx+endIdx])
-
 	var rssi int
-	if _, err := fmt.Sscanf(rssiStr, "%d", &rssi); err != nil {
+	if _, err := fmt.Sscanf(strings.TrimSpace(after), "%d", &rssi); err != nil {
 		return -100
 	}
 
 	return rssi
 }
 
-// addStyleURLToPlacemark adds or updates the styleUrl element in a placemark
-func addStyleURLToPlacemark(placemark, styleURL string) string {
-	// Check if styleUrl already exists
-	if strings.Contains(placemark, "<styleUrl>") {
-		// Replace existing styleUrl
-		startTag := "<styleUrl>"
-		endTag := "</styleUrl>"
-
-		startIdx := strings.Index(placemark, startTag)
-		if startIdx == -1 {
-			return placemark
-		}
-
-		endIdx := strings.Index(placemark[startIdx:], endTag)
-		if endIdx == -1 {
-			return placemark
-		}
-		endIdx += startIdx + len(endTag)
-
-		// Replace the styleUrl
-		before := placemark[:startIdx]
-		after := placemark[endIdx:]
-		return before + fmt.Sprintf("<styleUrl>%s</styleUrl>", styleURL) + after
-	}
-
-	// Add new styleUrl after <name> tag
-	nameEndTag := "</name>"
-	nameEndIdx := strings.Index(placemark, nameEndTag)
-	if nameEndIdx == -1 {
-		// No name tag, add after <Placemark>
-		placemarkStartIdx := strings.Index(placemark, "<Placemark>")
-		if placemarkStartIdx == -1 {
-			return placemark
-		}
-		insertIdx := placemarkStartIdx + len("<Placemark>")
-		return placemark[:insertIdx] + "\n      <styleUrl>" + styleURL + "</styleUrl>" + placemark[insertIdx:]
-	}
-
-	insertIdx := nameEndIdx + len(nameEndTag)
-	return placemark[:insertIdx] + "\n      <styleUrl>" + styleURL + "</styleUrl>" + placemark[insertIdx:]
-}
-
 // mergeKMLAndExit merges multiple KML files and writes the result
 // Called from main when -merge-kml flag is used
-func mergeKMLAndExit(filePaths []string) error {
+func mergeKMLAndExit(filePaths []string, exports ExportPaths) error {
 	if len(filePaths) == 0 {
 		return fmt.Errorf("no files specified")
 	}
@@ -716,9 +1024,7 @@ func mergeKMLAndExit(filePaths []string) error {
 	fmt.Printf("Merging %d KML files...\n", len(filePaths))
 
 	// Collect all placemarks by folder type
-	var allPoints []string
-	var allPaths []string
-	var allPolygons []string
+	merged := &kmlLayers{}
 	var allSessionPoints []GeoLocation
 
 	successCount := 0
@@ -727,19 +1033,20 @@ func mergeKMLAndExit(filePaths []string) error {
 	for _, filePath := range filePaths {
 		fmt.Printf("Reading: %s\n", filePath)
 
-		points, paths, polygons, sessionPoints, err := extractPlacemarksFromKML(filePath)
+		layers, err := parseKMLFile(filePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "WARNING: Failed to parse %s: %v (skipping)\n", filePath, err)
 			continue
 		}
 
-		allPoints = append(allPoints, points...)
-		allPaths = append(allPaths, paths...)
-		allPolygons = append(allPolygons, polygons...)
-		allSessionPoints = append(allSessionPoints, sessionPoints...)
+		merged.Points = append(merged.Points, layers.Points...)
+		merged.Paths = append(merged.Paths, layers.Paths...)
+		merged.Polygons = append(merged.Polygons, layers.Polygons...)
+		merged.Rings = append(merged.Rings, layers.Rings...)
+		allSessionPoints = append(allSessionPoints, layers.allCoordinates()...)
 
 		successCount++
-		fmt.Printf("  ✓ Loaded %d points, %d paths, %d polygons\n", len(points), len(paths), len(polygons))
+		fmt.Printf("  ✓ Loaded %d points, %d paths, %d polygons\n", len(layers.Points), len(layers.Paths), len(layers.Polygons))
 	}
 
 	if successCount == 0 {
@@ -748,14 +1055,14 @@ func mergeKMLAndExit(filePaths []string) error {
 
 	fmt.Printf("\nSuccessfully merged %d/%d files\n", successCount, len(filePaths))
 	fmt.Printf("Total: %d points, %d paths, %d polygons, %d location data points\n",
-		len(allPoints), len(allPaths), len(allPolygons), len(allSessionPoints))
+		len(merged.Points), len(merged.Paths), len(merged.Polygons), len(allSessionPoints))
 
 	// Find non-colliding filename
-	outputPath := findNonCollidingFilename("ble_devices-MERGE", ".kml")
+	outputPath := exports.merged()
 	fmt.Printf("\nWriting merged KML to: %s\n", outputPath)
 
 	// Write merged KML
-	if err := writeMergedKML(outputPath, allPoints, allPaths, allPolygons, allSessionPoints); err != nil {
+	if err := writeMergedKML(outputPath, merged, allSessionPoints); err != nil {
 		return fmt.Errorf("failed to write merged KML: %w", err)
 	}
 
@@ -763,183 +1070,29 @@ func mergeKMLAndExit(filePaths []string) error {
 	return nil
 }
 
-// extractPlacemarksFromKML parses a KML file and extracts Placemark XML by folder
-func extractPlacemarksFromKML(filePath string) ([]string, []string, []string, []GeoLocation, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to read file: %w", err)
-	}
-
-	text := string(content)
-
-	// Extract placemarks from each folder by name
-	points := extractPlacemarksFromFolder(text, "Points")
-	paths := extractPlacemarksFromFolder(text, "Paths")
-	polygons := extractPlacemarksFromFolder(text, "Polygons")
-
-	// Extract all coordinates for session boundary
-	sessionPoints := extractAllCoordinates(text)
-
-	return points, paths, polygons, sessionPoints, nil
-}
-
-// extractPlacemarksFromFolder extracts all Placemark elements from a named folder
-func extractPlacemarksFromFolder(kmlText, folderName string) []string {
-	var placemarks []string
-
-	// Find the folder by name
-	folderNameTag := fmt.Sprintf("<name>%s</name>", folderName)
-	folderIdx := strings.Index(kmlText, folderNameTag)
-	if folderIdx == -1 {
-		return placemarks // Folder not found
-	}
-
-	// Find the <Folder> tag before the name
-	folderStart := strings.LastIndex(kmlText[:folderIdx], "<Folder>")
-	if folderStart == -1 {
-		return placemarks
-	}
-
-	// Find the closing </Folder> tag
-	folderEnd := strings.Index(kmlText[folderStart:], "</Folder>")
-	if folderEnd == -1 {
-		return placemarks
-	}
-	folderEnd += folderStart
-
-	folderContent := kmlText[folderStart:folderEnd]
-
-	// Extract all <Placemark>...</Placemark> within this folder
-	searchStart := 0
-	for {
-		placemarkStart := strings.Index(folderContent[searchStart:], "<Placemark>")
-		if placemarkStart == -1 {
-			break
-		}
-		placemarkStart += searchStart
-
-		placemarkEnd := strings.Index(folderContent[placemarkStart:], "</Placemark>")
-		if placemarkEnd == -1 {
-			break
-		}
-		placemarkEnd += placemarkStart + len("</Placemark>")
-
-		placemark := folderContent[placemarkStart:placemarkEnd]
-		placemarks = append(placemarks, placemark)
-
-		searchStart = placemarkEnd
-	}
-
-	return placemarks
-}
-
-// extractAllCoordinates extracts all coordinate data from KML text
-func extractAllCoordinates(kmlText string) []GeoLocation {
-	var locations []GeoLocation
-
-	coordsStart := "<coordinates>"
-	coordsEnd := "</coordinates>"
-
-	searchStart := 0
-	for {
-		start := strings.Index(kmlText[searchStart:], coordsStart)
-		if start == -1 {
-			break
-		}
-		start += searchStart + len(coordsStart)
-
-		end := strings.Index(kmlText[start:], coordsEnd)
-		if end == -1 {
-			break
-		}
-		end += start
-
-		coordsText := strings.TrimSpace(kmlText[start:end])
-
-		// Parse coordinate tuples (space-separated)
-		tuples := strings.Fields(coordsText)
-		for _, tuple := range tuples {
-			parts := strings.Split(tuple, ",")
-			if len(parts) >= 2 {
-				var lon, lat, alt float64
-				fmt.Sscanf(parts[0], "%f", &lon)
-				fmt.Sscanf(parts[1], "%f", &lat)
-				if len(parts) >= 3 {
-					fmt.Sscanf(parts[2], "%f", &alt)
-				}
-
-				locations = append(locations, GeoLocation{
-					Latitude:  lat,
-					Longitude: lon,
-					Elevation: alt,
-				})
-			}
-		}
-
-		searchStart = end + len(coordsEnd)
-	}
-
-	return locations
-}
-
 // writeMergedKML writes merged placemarks to a new KML file
-func writeMergedKML(outputPath string, points, paths, polygons []string, sessionPoints []GeoLocation) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Write KML header
-	file.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	file.WriteString("\n")
-	file.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2">`)
-	file.WriteString("\n  <Document>\n")
-	file.WriteString(fmt.Sprintf("    <name>BLE Devices - MERGED - %s</name>\n", time.Now().Format("2006-01-02 15:04:05")))
-
-	// Write shared styles
-	file.WriteString(generateStylesXML())
-
-	// Write Points folder
-	if len(points) > 0 {
-		file.WriteString("    <Folder>\n")
-		file.WriteString("      <name>Points</name>\n")
-		for _, placemark := range points {
-			// Indent the placemark
-			indented := strings.ReplaceAll(placemark, "\n", "\n      ")
-			file.WriteString("      " + indented + "\n")
-		}
-		file.WriteString("    </Folder>\n")
+// The session boundary is recomputed from sessionPoints rather than copied from the inputs
+func writeMergedKML(outputPath string, layers *kmlLayers, sessionPoints []GeoLocation) error {
+	doc := &kmlDocument{
+		Name:      fmt.Sprintf("BLE Devices - MERGED - %s", formatDisplayTime(time.Now(), exportTimeLayout)),
+		StylesXML: generateStylesXML(),
 	}
 
-	// Write Paths folder
-	if len(paths) > 0 {
-		file.WriteString("    <Folder>\n")
-		file.WriteString("      <name>Paths</name>\n")
-		for _, placemark := range paths {
-			indented := strings.ReplaceAll(placemark, "\n", "\n      ")
-			file.WriteString("      " + indented + "\n")
+	// Add non-empty layer folders in export order
+	for _, folder := range []kmlFolder{
+		{Name: "Points", Placemarks: layers.Points},
+		{Name: "Paths", Placemarks: layers.Paths},
+		{Name: "Polygons", Placemarks: layers.Polygons},
+		{Name: "Range Rings", Placemarks: layers.Rings},
+	} {
+		if len(folder.Placemarks) > 0 {
+			doc.Folders = append(doc.Folders, folder)
 		}
-		file.WriteString("    </Folder>\n")
 	}
 
-	// Write Polygons folder
-	if len(polygons) > 0 {
-		file.WriteString("    <Folder>\n")
-		file.WriteString("      <name>Polygons</name>\n")
-		for _, placemark := range polygons {
-			indented := strings.ReplaceAll(placemark, "\n", "\n      ")
-			file.WriteString("      " + indented + "\n")
-		}
-		file.WriteString("    </Folder>\n")
-	}
-
-	// Write Session Boundary folder (recompute from all coordinates)
+	// Add Session Boundary folder (recompute from all coordinates)
 	if len(sessionPoints) > 0 {
-		file.WriteString("    <Folder>\n")
-		file.WriteString("      <name>Session Boundary</name>\n")
-
-		// Create session boundary placemark
+		session := kmlFolder{Name: "Session Boundary"}
 		hull := computeConvexHull(sessionPoints)
 		if len(hull) >= 3 {
 			coords := make([]string, len(hull)+1)
@@ -949,33 +1102,42 @@ func writeMergedKML(outputPath string, points, paths, polygons []string, session
 			coords[len(hull)] = coords[0] // Close polygon
 
 			description := fmt.Sprintf(
-				"&lt;ul&gt;&lt;li&gt;&lt;strong&gt;Total Points:&lt;/strong&gt; %d&lt;/li&gt;&lt;li&gt;&lt;strong&gt;Boundary Points:&lt;/strong&gt; %d&lt;/li&gt;&lt;li&gt;&lt;strong&gt;Merge Time:&lt;/strong&gt; %s&lt;/li&gt;&lt;/ul&gt;",
+				"<ul><li><strong>Total Points:</strong> %d</li><li><strong>Boundary Points:</strong> %d</li><li><strong>Merge Time:</strong> %s</li></ul>",
 				len(sessionPoints),
 				len(hull),
-				time.Now().Format("2006-01-02 15:04:05"),
+				formatDisplayTime(time.Now(), exportTimeLayout),
 			)
 
-			file.WriteString("      <Placemark>\n")
-			file.WriteString("        <name>Session Area</name>\n")
-			file.WriteString(fmt.Sprintf("        <description>%s</description>\n", description))
-			file.WriteString("        <Polygon>\n")
-			file.WriteString("          <outerBoundaryIs>\n")
-			file.WriteString("            <LinearRing>\n")
-			file.WriteString(fmt.Sprintf("              <coordinates>%s</coordinates>\n", strings.Join(coords, " ")))
-			file.WriteString("            </LinearRing>\n")
-			file.WriteString("          </outerBoundaryIs>\n")
-			file.WriteString("        </Polygon>\n")
-			file.WriteString("      </Placemark>\n")
+			session.Placemarks = append(session.Placemarks, kmlPlacemark{
+				Name:        "Session Area",
+				Description: description,
+				StyleURL:    "#" + sessionBoundaryStyleID,
+				Children: []kmlRawNode{{
+					XMLName:  xml.Name{Local: "Polygon"},
+					InnerXML: "<outerBoundaryIs><LinearRing><coordinates>" + strings.Join(coords, " ") + "</coordinates></LinearRing></outerBoundaryIs>",
+				}},
+			})
 		}
-
-		file.WriteString("    </Folder>\n")
+		doc.Folders = append(doc.Folders, session)
 	}
 
-	// Write KML footer
-	file.WriteString("  </Document>\n")
-	file.WriteString("</kml>\n")
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-	return nil
+	// Write KML
+	if _, err := file.WriteString(xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(&kmlRoot{Xmlns: "http://www.opengis.net/kml/2.2", Document: doc}); err != nil {
+		return fmt.Errorf("failed to write KML: %w", err)
+	}
+	_, err = file.WriteString("\n")
+	return err
 }
 
 // findNonCollidingFilename finds a filename that doesn't exist
@@ -999,33 +1161,16 @@ func findNonCollidingFilename(prefix, ext string) string {
 	return fmt.Sprintf("%s-%d%s", prefix, time.Now().Unix(), ext)
 }
 
-// generateStylesXML generates the XML for shared KML styles
+// generateStylesXML renders createSharedStyles as raw XML for writeMergedKML's Document
 func generateStylesXML() string {
-	return `    <Style id="rssi-blue">
-      <LineStyle><color>ff0000ff</color><width>3</width></LineStyle>
-      <PolyStyle><color>ff0000ff</color></PolyStyle>
-    </Style>
-    <Style id="rssi-green">
-      <LineStyle><color>ff00ff00</color><width>3</width></LineStyle>
-      <PolyStyle><color>ff00ff00</color></PolyStyle>
-    </Style>
-    <Style id="rssi-yellow">
-      <LineStyle><color>ff00ffff</color><width>3</width></LineStyle>
-      <PolyStyle><color>ff00ffff</color></PolyStyle>
-    </Style>
-    <Style id="rssi-orange">
-      <LineStyle><color>ff0080ff</color><width>3</width></LineStyle>
-      <PolyStyle><color>ff0080ff</color></PolyStyle>
-    </Style>
-    <Style id="rssi-red">
-      <LineStyle><color>ffff0000</color><width>3</width></LineStyle>
-      <PolyStyle><color>ffff0000</color></PolyStyle>
-    </Style>
-    <Style id="session-boundary">
-      <LineStyle><color>80ff0000</color><width>4</width></LineStyle>
-      <PolyStyle><color>80ff0000</color></PolyStyle>
-    </Style>
-`
+	var buf strings.Builder
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("    ", "  ")
+	for _, style := range createSharedStyles() {
+		// Errors are impossible here: the styles are fixed and strings.Builder never fails
+		_ = encoder.Encode(style)
+	}
+	return "\n" + buf.String()
 }
 
 // createSessionBoundary creates a polygon representing the total session area
@@ -1060,13 +1205,13 @@ func createSessionBoundary(allPoints []GeoLocation) kml.Element {
 		"<ul><li><strong>Total Points:</strong> %d</li><li><strong>Boundary Points:</strong> %d</li><li><strong>Session Time:</strong> %s</li></ul>",
 		len(allPoints),
 		len(hull),
-		time.Now().Format("2006-01-02 15:04:05"),
+		formatDisplayTime(time.Now(), exportTimeLayout),
 	)
 
 	return kml.Placemark(
 		kml.Name("Session Area"),
 		kml.Description(description),
-		kml.StyleURL("#session-boundary"),
+		kml.StyleURL("#"+sessionBoundaryStyleID),
 		kml.Polygon(
 			kml.OuterBoundaryIs(
 				kml.LinearRing(
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twpayne/go-kml/v3"
+)
+
+var (
+	kmlStyleIDPattern  = regexp.MustCompile(`<Style id="([^"]+)">`)
+	kmlStyleURLPattern = regexp.MustCompile(`<styleUrl>#([^<]+)</styleUrl>`)
+)
+
+// testGeoCapacity keeps every fix the test devices below are heard at
+const testGeoCapacity = 16
+
+// testGeoDevice returns a device heard at each RSSI, walking north-east from a fixed origin
+func testGeoDevice(mac string, rssis ...int) *BLEDevice {
+	dev := &BLEDevice{MacAddress: mac, RSSI: rssis[0], GeoData: NewRSSILocationMap(0, testGeoCapacity)}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, rssi := range rssis {
+		dev.GeoData.Push(rssi, GeoLocation{
+			Latitude:  51.5 + float64(i)*0.0005,
+			Longitude: -0.1 + float64(i)*0.0005,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return dev
+}
+
+// renderDeviceKML renders the device KML Document as an export would write it
+func renderDeviceKML(t *testing.T, devices ...*BLEDevice) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := kml.KML(kml.Document(devicesKMLElements(devices)...)).WriteIndent(&buf, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// assertStylesDefined fails unless every styleUrl in doc names a Style defined in it
+func assertStylesDefined(t *testing.T, doc string) {
+	t.Helper()
+	defined := make(map[string]bool)
+	for _, match := range kmlStyleIDPattern.FindAllStringSubmatch(doc, -1) {
+		defined[match[1]] = true
+	}
+	references := kmlStyleURLPattern.FindAllStringSubmatch(doc, -1)
+	if len(references) == 0 {
+		t.Fatal("no styleUrl references")
+	}
+	for _, match := range references {
+		if !defined[match[1]] {
+			t.Errorf("styleUrl #%s has no matching Style", match[1])
+		}
+	}
+}
+
+func TestDeviceKMLStylesDefined(t *testing.T) {
+	doc := renderDeviceKML(t,
+		testGeoDevice("AA:BB:CC:00:00:01", -45, -55, -65, -75, -85),
+		testGeoDevice("AA:BB:CC:00:00:02", -90, -88, -86),
+	)
+	assertStylesDefined(t, doc)
+
+	for _, band := range rssiBands {
+		if !strings.Contains(doc, `<Style id="`+band.styleID+`">`) {
+			t.Errorf("no Style for band %s", band.styleID)
+		}
+	}
+	if !strings.Contains(doc, `<styleUrl>#`+sessionBoundaryStyleID+`</styleUrl>`) {
+		t.Error("session boundary not drawn")
+	}
+}
+
+func TestSharedStylesMatchMergedKML(t *testing.T) {
+	var buf strings.Builder
+	if err := kml.KML(kml.Document(createSharedStyles()...)).WriteIndent(&buf, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	want := kmlStyleIDPattern.FindAllString(buf.String(), -1)
+	got := kmlStyleIDPattern.FindAllString(generateStylesXML(), -1)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("merged KML styles = %v, want %v", got, want)
+	}
+	if len(want) != 2*len(rssiBands)+1 {
+		t.Errorf("%d shared styles, want two per RSSI band plus the session boundary", len(want))
+	}
+}
+
+func TestRSSIBandColorsAreKMLOrder(t *testing.T) {
+	// KML colors are aabbggrr, so blue is ffff0000 and red ff0000ff
+	doc := generateStylesXML()
+	for id, color := range map[string]string{"rssi-blue": "ffff0000", "rssi-red": "ff0000ff"} {
+		start := strings.Index(doc, `<Style id="`+id+`">`)
+		if start < 0 {
+			t.Fatalf("no Style %s", id)
+		}
+		if style := doc[start:]; !strings.Contains(style[:strings.Index(style, "</Style>")], "<color>"+color+"</color>") {
+			t.Errorf("Style %s is not colored %s", id, color)
+		}
+	}
+}
+
+func TestRangeRingStyles(t *testing.T) {
+	defer func(enabled bool) { kmlRangeRings = enabled }(kmlRangeRings)
+	kmlRangeRings = true
+
+	doc := renderDeviceKML(t, testGeoDevice("AA:BB:CC:00:00:01", -45, -75))
+	assertStylesDefined(t, doc)
+
+	ringStyleID := bandForRSSI(-45).styleID + rangeRingStyleSuffix
+	if !strings.Contains(doc, "<styleUrl>#"+ringStyleID+"</styleUrl>") {
+		t.Fatalf("no ring drawn with #%s", ringStyleID)
+	}
+
+	// The ring style outlines in the band color without filling
+	start := strings.Index(doc, `<Style id="`+ringStyleID+`">`)
+	style := doc[start : start+strings.Index(doc[start:], "</Style>")]
+	for _, want := range []string{"<LineStyle>", "<fill>0</fill>"} {
+		if !strings.Contains(style, want) {
+			t.Errorf("Style %s lacks %s:\n%s", ringStyleID, want, style)
+		}
+	}
+
+	// Rings carry no inline Style that could replace the shared one
+	folder := doc[strings.Index(doc, "<name>Range Rings</name>"):]
+	if strings.Contains(folder[:strings.Index(folder, "</Folder>")], "<Style>") {
+		t.Error("range ring has an inline Style")
+	}
+}
+
+// lonLat builds a GeoLocation from planar test coordinates
+func lonLat(lon, lat float64) GeoLocation {
+	return GeoLocation{Longitude: lon, Latitude: lat}
+}
+
+func TestComputeConvexHull(t *testing.T) {
+	square := []GeoLocation{lonLat(0, 0), lonLat(2, 0), lonLat(2, 2), lonLat(0, 2)}
+	tests := []struct {
+		name   string
+		points []GeoLocation
+		want   []GeoLocation // Counter-clockwise from the lowest point
+	}{
+		{
+			name:   "square with interior points",
+			points: []GeoLocation{lonLat(1, 1), lonLat(2, 2), lonLat(0.5, 1.5), lonLat(0, 0), lonLat(0, 2), lonLat(2, 0)},
+			want:   square,
+		},
+		{
+			// Slopes alone sort points left of the pivot before those to its right
+			name:   "diamond with points left of the pivot",
+			points: []GeoLocation{lonLat(-1, 0), lonLat(0, 1), lonLat(-0.5, 0.2), lonLat(1, 0), lonLat(0, -1), lonLat(0.3, -0.1)},
+			want:   []GeoLocation{lonLat(0, -1), lonLat(1, 0), lonLat(0, 1), lonLat(-1, 0)},
+		},
+		{
+			name: "collinear points on every edge",
+			points: []GeoLocation{
+				lonLat(0, 2), lonLat(1, 0), lonLat(2, 1), lonLat(1, 2), lonLat(0, 1),
+				lonLat(2, 2), lonLat(0, 0), lonLat(2, 0), lonLat(0.5, 0), lonLat(0, 1.5),
+			},
+			want: square,
+		},
+		{
+			name: "duplicates",
+			points: []GeoLocation{
+				lonLat(2, 2), lonLat(0, 0), lonLat(2, 0), lonLat(0, 0), lonLat(0, 2),
+				lonLat(2, 2), lonLat(1, 1), lonLat(1, 1), lonLat(2, 0), lonLat(0, 2),
+			},
+			want: square,
+		},
+		{
+			// The pivot is the lowest latitude, leftmost on a tie
+			name:   "triangle with ties on the lowest latitude",
+			points: []GeoLocation{lonLat(4, 0), lonLat(2, 3), lonLat(1, 0), lonLat(2, 1), lonLat(3, 0)},
+			want:   []GeoLocation{lonLat(1, 0), lonLat(4, 0), lonLat(2, 3)},
+		},
+		{
+			name:   "all collinear",
+			points: []GeoLocation{lonLat(1, 1), lonLat(3, 3), lonLat(0, 0), lonLat(2, 2), lonLat(1, 1)},
+			want:   []GeoLocation{lonLat(0, 0), lonLat(3, 3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]GeoLocation(nil), tt.points...)
+			got := computeConvexHull(tt.points)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("hull = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.points, input) {
+				t.Error("input points were reordered")
+			}
+		})
+	}
+}
+
+var kmlCoordinatesPattern = regexp.MustCompile(`<coordinates>([^<]*)</coordinates>`)
+
+// pathLatitudes returns the latitudes along the Paths folder's LineStrings in document order,
+// dropping the point each segment shares with the one before it
+func pathLatitudes(t *testing.T, doc string) []float64 {
+	t.Helper()
+	start := strings.Index(doc, "<name>Paths</name>")
+	if start < 0 {
+		t.Fatal("no Paths folder")
+	}
+	folder := doc[start:]
+	folder = folder[:strings.Index(folder, "</Folder>")]
+
+	var latitudes []float64
+	for _, match := range kmlCoordinatesPattern.FindAllStringSubmatch(folder, -1) {
+		for i, coordinate := range strings.Fields(match[1]) {
+			if i == 0 && len(latitudes) > 0 {
+				continue
+			}
+			// lon,lat with an optional altitude
+			var lon, lat float64
+			if _, err := fmt.Sscanf(coordinate, "%g,%g", &lon, &lat); err != nil {
+				t.Fatalf("bad coordinate %q: %v", coordinate, err)
+			}
+			latitudes = append(latitudes, lat)
+		}
+	}
+	return latitudes
+}
+
+// testWalkDevice returns a device heard while walking north in a zigzag, one fix per second,
+// at RSSIs alternating between strong and weak
+func testWalkDevice(steps int) *BLEDevice {
+	dev := &BLEDevice{MacAddress: "AA:BB:CC:00:00:01", RSSI: -50, GeoData: NewRSSILocationMap(0, testGeoCapacity)}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := range steps {
+		rssi := -50
+		if i%2 == 1 {
+			rssi = -85
+		}
+		dev.GeoData.Push(rssi, GeoLocation{
+			Latitude:  51.5 + float64(i)*0.001,
+			Longitude: -0.1 + float64(i%2)*0.001,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return dev
+}
+
+func TestDevicePathIsChronological(t *testing.T) {
+	const steps = 8
+	latitudes := pathLatitudes(t, renderDeviceKML(t, testWalkDevice(steps)))
+	if len(latitudes) != steps {
+		t.Fatalf("path has %d points, want %d", len(latitudes), steps)
+	}
+	for i := 1; i < len(latitudes); i++ {
+		if latitudes[i] <= latitudes[i-1] {
+			t.Fatalf("path goes back from %.4f to %.4f at point %d: %v", latitudes[i-1], latitudes[i], i, latitudes)
+		}
+	}
+}
+
+// pathStyleURLs returns the styleUrl of each placemark in the Paths folder, in document order
+func pathStyleURLs(doc string) []string {
+	folder := doc[strings.Index(doc, "<name>Paths</name>"):]
+	folder = folder[:strings.Index(folder, "</Folder>")]
+	var styles []string
+	for _, match := range kmlStyleURLPattern.FindAllStringSubmatch(folder, -1) {
+		styles = append(styles, match[1])
+	}
+	return styles
+}
+
+func TestDevicePathRunsFollowRSSI(t *testing.T) {
+	// Heard strong for the first half of the walk and weak for the second
+	dev := &BLEDevice{MacAddress: "AA:BB:CC:00:00:01", RSSI: -85, GeoData: NewRSSILocationMap(0, testGeoCapacity)}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := range 8 {
+		rssi := []int{-45, -48, -47, -46, -85, -85, -85, -85}[i]
+		dev.GeoData.Push(rssi, GeoLocation{
+			Latitude:  51.5 + float64(i)*0.001,
+			Longitude: -0.1 + float64(i%2)*0.001,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	doc := renderDeviceKML(t, dev)
+	strong, weak := bandForRSSI(-45).styleID, bandForRSSI(-85).styleID
+	if got := pathStyleURLs(doc); !slices.Equal(got, []string{strong, weak}) {
+		t.Errorf("path runs = %v, want [%s %s]", got, strong, weak)
+	}
+
+	// The runs join end to end in time order
+	latitudes := pathLatitudes(t, doc)
+	if len(latitudes) != 8 {
+		t.Fatalf("path has %d points, want 8", len(latitudes))
+	}
+	for i := 1; i < len(latitudes); i++ {
+		if latitudes[i] <= latitudes[i-1] {
+			t.Fatalf("path goes back at point %d: %v", i, latitudes)
+		}
+	}
+}
+
+func TestDevicePathSegmentsAlternate(t *testing.T) {
+	// testWalkDevice alternates strong and weak, so no two consecutive segments share a band
+	doc := renderDeviceKML(t, testWalkDevice(6))
+	strong, weak := bandForRSSI(-50).styleID, bandForRSSI(-85).styleID
+	want := []string{weak, strong, weak, strong, weak}
+	if got := pathStyleURLs(doc); !slices.Equal(got, want) {
+		t.Errorf("path runs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+
+	"github.com/twpayne/go-kml/v3"
+)
+
+// Paths inside a KMZ archive; Google Earth opens the first .kml entry, conventionally doc.kml
+const (
+	kmzDocPath     = "doc.kml"
+	kmzHeatmapPath = "files/heatmap.png"
+)
+
+// exportDevicesKMZ writes the device layers and an RSSI heatmap overlay as a single KMZ file:
+// doc.kml and the overlay image zipped together, so the export carries everything it references
+// The heatmap is left out when there are no geolocated observations or the area is too large to grid
+func exportDevicesKMZ(filename string, devices []*BLEDevice, cellMeters float64) error {
+	docElements := devicesKMLElements(devices)
+
+	var overlayPNG []byte
+	grid, err := buildHeatmapGrid(devices, cellMeters)
+	if err != nil {
+		logger.Warn("KMZ export without heatmap overlay", "file", filename, "error", err)
+	} else if grid != nil {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, grid.render()); err != nil {
+			return fmt.Errorf("failed to write PNG: %w", err)
+		}
+		overlayPNG = buf.Bytes()
+		docElements = append(docElements, kml.Folder(kml.Name("Heatmap"), grid.overlay(cellMeters, kmzHeatmapPath)))
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	docWriter, err := archive.Create(kmzDocPath)
+	if err != nil {
+		return fmt.Errorf("failed to write KMZ: %w", err)
+	}
+	if err := kml.KML(kml.Document(docElements...)).WriteIndent(docWriter, "", "  "); err != nil {
+		return fmt.Errorf("failed to write KML: %w", err)
+	}
+
+	// PNG is already compressed, so it is stored as is
+	if overlayPNG != nil {
+		imageWriter, err := archive.CreateHeader(&zip.FileHeader{Name: kmzHeatmapPath, Method: zip.Store})
+		if err != nil {
+			return fmt.Errorf("failed to write KMZ: %w", err)
+		}
+		if _, err := imageWriter.Write(overlayPNG); err != nil {
+			return fmt.Errorf("failed to write KMZ: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write KMZ: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Longest input line written to the log; malformed firmware output can be arbitrarily long
+const maxLoggedLineBytes = 200
+
+// logger records diagnostics to the -log file
+// The TUI owns stdout and stderr, so nothing is logged until openLog points this at a file
+var logger = slog.New(slog.DiscardHandler)
+
+// openLog appends log records at or above level ("debug", "info", "warn" or "error") to path
+// The returned file must be closed on exit
+func openLog(path, level string) (io.Closer, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	logger = slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: lvl}))
+	return file, nil
+}
+
+// truncateForLog returns line as a string, cut to maxLoggedLineBytes
+func truncateForLog(line []byte) string {
+	if len(line) <= maxLoggedLineBytes {
+		return string(line)
+	}
+	return strings.ToValidUTF8(string(line[:maxLoggedLineBytes]), "") + "..."
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -12,16 +14,119 @@ import (
 	"github.com/gdamore/tcell/v2"
 )
 
+// How long main waits for reader goroutines to return after done is closed
+// A reader blocked on stdin or an idle port never notices done, so shutdown can't wait forever
+const shutdownTimeout = 2 * time.Second
+
+// waitTimeout waits for wg, giving up after timeout; it reports whether wg finished
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func main() {
+	sessionStart := time.Now()
+
 	// Command-line flags
-	serialPort := flag.String("port", "", "Serial port device (e.g., /dev/ttyUSB0). If not specified, reads from stdin.")
+	var serialPorts portList
+	flag.Var(&serialPorts, "port", "Serial port device (e.g., /dev/ttyUSB0). Repeat or comma-separate to merge several sniffers. If not specified, reads from stdin.")
 	baudRate := flag.Int("baud", 115200, "Baud rate for serial port (default: 115200)")
+	headless := flag.Bool("headless", false, fmt.Sprintf("Run without the TUI, printing a status line every %v; pair with -autosave, -jsonl-out or -http for an unattended sensor", headlessStatusInterval))
 	refreshRate := flag.Int("refresh", 4, "TUI refresh rate in updates per second (default: 4)")
-	gpsPort := flag.String("gps", "", "GPS/GNSS serial port device (e.g., /dev/ttyUSB1). If not specified, no GPS data collected.")
+	staleAfter := flag.Duration("stale-after", defaultStaleAfter, "Move devices to the STALE table when not seen for this long (default: 10s)")
+	staleSort := flag.String("stale-sort", "last-seen", "Initial sort for the STALE table: mac, rssi (last-known, strongest first), last-seen, count, name or distance (cycle with s)")
+	gpsPort := flag.String("gps", "", "GPS/GNSS serial port device (e.g., /dev/ttyUSB1). Must be a different device than -port. If not specified, no GPS data collected.")
+	watchMACs := flag.String("watch", "", "Comma-separated MAC addresses (or a file with one per line) that trigger an alert when seen.")
+	gpsMaxAge := flag.Duration("gps-max-age", defaultFixMaxAge, "Stop tagging devices with a GPS fix older than this; 0 never expires a fix (default: 5s)")
+	maxHDOP := flag.Float64("max-hdop", 0, "Ignore GPS positions with a higher HDOP so devices aren't tagged with imprecise fixes (default: 0 = no limit)")
+	gpsFile := flag.String("gps-file", "", "Play a recorded NMEA log as the GPS source, paced by its RMC timestamps and -replay-speed. Aligned with -replay when both are set.")
+	replayFile := flag.String("replay", "", "Replay a previously exported JSON capture as a live feed instead of reading serial input.")
+	replaySpeed := flag.Float64("replay-speed", 1.0, "Replay speed multiplier for original timestamp gaps in -replay and -gps-file; 0 replays everything at once (default: 1.0)")
+	autosave := flag.Duration("autosave", 0, "Export JSON to a timestamped file at this interval (e.g., 5m). Disabled if not set.")
+	autosaveKML := flag.Bool("autosave-kml", false, "Also export KML on each autosave (requires -autosave)")
+	maxDevices := flag.Int("max-devices", 0, "Maximum devices to track; the least recently seen is evicted beyond this (default: 0 = unlimited)")
+	geoTopN := flag.Int("geo-top-n", defaultGeoTopN, "Strongest RSSIs per device to keep locations for, 0 for all (default: 3)")
+	mfrHistory := flag.Int("mfr-history", 0, "Keep this many distinct Mfr Data values per device, listed in the detail view (default: 0 = latest only)")
+	geoCapacity := flag.Int("geo-capacity", defaultGeoCapacity, "Locations kept per RSSI per device (default: 3)")
+	schemaPath := flag.String("schema", "", "JSON file mapping another firmware's field names to ours, e.g. {\"addr\": \"mac_address\"}")
+	notifySpec := flag.String("notify", "beep", "Where firmware notifications go: beep, desktop, webhook:<url> or none")
+	obsLogPath := flag.String("obs-log", "", "Append every advertisement (timestamp, MAC, RSSI, GPS fix) as a CSV line to this file, for offline timelines")
+	obsLogMaxMB := flag.Int("obs-log-max-mb", defaultObsLogMaxMB, "Rotate the -obs-log file to <file>.1 at this size in MB (default: 100; 0 = never)")
+	rawOut := flag.String("raw-out", "", "Append every line read from the BLE serial input, verbatim, to this file")
+	pruneAfter := flag.Duration("prune-after", 0, "Forget devices not seen for this long (e.g., 30m), freeing memory on long moving surveys. Disabled if not set.")
+	evictLogPath := flag.String("evict-log", "", "Append devices evicted by -max-devices or removed by -prune-after to this JSON Lines file")
+	themeName := flag.String("theme", "dark", "Color theme: dark, light (for light terminals), or mono (no color, high contrast)")
+	jsonlOut := flag.String("jsonl-out", "", "Stream every observation as a JSON line to this file, or - for stdout")
+	heatmapCell := flag.Float64("heatmap-cell", defaultHeatmapCellMeters, "Grid cell size in meters for heatmap exports (default: 10)")
+	minCount := flag.Int("min-count", 0, "Hide devices observed fewer than this many times (toggle with v; default threshold when toggled: 2)")
+	correlateRPA := flag.Bool("correlate-rpa", false, "Merge Apple/Microsoft devices that appear to be rotating their random address (heuristic)")
+	stripes := flag.Bool("stripes", false, "Shade every other device row, to help follow one device across wide tables")
+	classColors := flag.Bool("class-colors", false, "Start with rows tinted by device class (toggle with b, legend with B)")
+	kmlPathSpacingFlag := flag.Float64("kml-path-spacing", defaultKMLPathSpacing, "Minimum meters between KML path points; closer points (GPS jitter) are dropped (default: 2; 0 = keep every point)")
+	kmlRangeRingsFlag := flag.Bool("kml-range-rings", false, "Draw a rough range ring around each device in KML exports, sized from its strongest RSSI (log-distance path-loss model)")
+	kmlTxPower := flag.Int("kml-tx-power", pathLossRefRSSI, "Reference RSSI at 1 m for -kml-range-rings, in dBm (default: -59)")
+	kmlLayoutName := flag.String("kml-layout", kmlLayoutGeometry, "KML export folders: geometry (Points/Paths/Polygons) or device (one folder per device)")
+	timeZone := flag.String("tz", "utc", "Time zone for displayed times and export filenames: utc, local, or an IANA name like Europe/Berlin (stored values stay UTC)")
+	units := flag.String("units", "metric", "Units for the distance column: metric or imperial")
+	hideColumns := flag.String("hide-columns", "", "Comma-separated table columns to hide: seen, count, conn, signal, rssi, location, distance, elevation, name, vendor, class, uuids, mfr-id, mfr-data")
+	fullUUIDs := flag.Bool("full-uuids", false, "Start with standard service UUIDs shown in full 128-bit form rather than 0x180F (toggle with x)")
+	relativeAge := flag.Bool("relative-age", false, "Start with the Last Seen column showing relative ages (toggle with a)")
+	rssiThresholds := flag.String("rssi-bands", "", "Comma-separated RSSI thresholds for the signal bars and KML colors, strongest first (default: -50,-60,-70,-80)")
+	httpAddr := flag.String("http", "", "Serve a read-only JSON API and /ws live feed on this address (e.g., :8080). Disabled if not set.")
+	exportOnQuit := flag.String("export-on-quit", "", "Write a final export when quitting: json, kml, or json,kml. Disabled if not set.")
+	chirpOnNew := flag.Bool("chirp-on-new", false, "Chirp when a device is seen for the first time (debounced)")
+	noSound := flag.Bool("no-sound", false, "Disable all sounds, including the -chirp-on-new chirp (e.g., on headless or SSH sessions)")
+	outDir := flag.String("out-dir", "", "Directory for exports, autosaves and merged KML, created if needed (default: current directory)")
+	exportPrefix := flag.String("prefix", defaultExportPrefix, "Filename prefix for device exports and autosaves (default: ble_devices)")
+	logPath := flag.String("log", "", "Write diagnostics (malformed input, reconnects, GPS baud detection, exports) to this file. Disabled if not set.")
+	logLevel := flag.String("log-level", "info", "Minimum level written to -log: debug, info, warn, or error")
 	mergeKML := flag.Bool("merge-kml", false, "Merge KML files and exit. Provide KML files as remaining arguments.")
 	updateKML := flag.String("update-kml", "", "Update existing KML file with new features (styling, etc.) and save in place.")
 	flag.Parse()
 
+	if *noSound {
+		disableAudio()
+	}
+
+	// Logging goes to a file only; the TUI owns the terminal
+	if *logPath != "" {
+		logFile, err := openLog(*logPath, *logLevel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to open log: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		logger.Info("ble_monitor starting", "args", os.Args[1:])
+	}
+
+	quitExports, err := parseQuitExports(*exportOnQuit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -export-on-quit: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Displayed times follow -tz; set before any mode that names or writes files
+	if err := setDisplayZone(*timeZone); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -tz: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Resolve where exports go before any mode that writes files
+	exports, err := NewExportPaths(*outDir, *exportPrefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Handle update-kml mode (update and exit, no TUI)
 	if *updateKML != "" {
 		if err := updateKMLAndExit(*updateKML); err != nil {
@@ -41,7 +146,7 @@ func main() {
 			os.Exit(1)
 		}
 
-		if err := mergeKMLAndExit(kmlFiles); err != nil {
+		if err := mergeKMLAndExit(kmlFiles, exports); err != nil {
 			fmt.Fprintf(os.Stderr, "Error merging KML files: %v\n", err)
 			os.Exit(1)
 		}
@@ -51,52 +156,366 @@ func main() {
 	// Calculate refresh interval from refresh rate
 	refreshInterval := time.Second / time.Duration(*refreshRate)
 
+	// Validate stale threshold
+	if *staleAfter <= 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -stale-after must be positive, using default %v\n", defaultStaleAfter)
+		*staleAfter = defaultStaleAfter
+	}
+
+	// Override signal band thresholds before anything is drawn or exported
+	if *rssiThresholds != "" {
+		if err := setRSSIThresholds(*rssiThresholds); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -rssi-bands: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Parse the initial stale table order
+	initialStaleSort, err := parseSortOrder(*staleSort)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: -stale-sort: %v, using last-seen\n", err)
+		initialStaleSort = defaultStaleSort
+	}
+
+	// Validate the prune window; pruning before devices even go stale is allowed but probably a mistake
+	if *pruneAfter < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -prune-after must not be negative, ignoring it\n")
+		*pruneAfter = 0
+	} else if *pruneAfter > 0 && *pruneAfter <= *staleAfter {
+		fmt.Fprintf(os.Stderr, "Warning: -prune-after %v is within -stale-after %v; devices will be removed before they reach the STALE table\n", *pruneAfter, *staleAfter)
+	}
+
+	// Validate heatmap cell size
+	if *heatmapCell <= 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -heatmap-cell must be positive, using default %v\n", defaultHeatmapCellMeters)
+		*heatmapCell = defaultHeatmapCellMeters
+	}
+
+	// Parse the hidden table columns
+	hiddenColumns, err := parseHiddenColumns(*hideColumns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -hide-columns: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Select the KML folder layout before any export can run
+	if err := setKMLLayout(*kmlLayoutName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: -kml-layout: %v, using %s\n", err, kmlLayoutGeometry)
+	}
+	kmlRangeRings = *kmlRangeRingsFlag
+	if *kmlTxPower >= 0 || *kmlTxPower < -127 {
+		fmt.Fprintf(os.Stderr, "Warning: -kml-tx-power must be between -127 and -1 dBm, using %d\n", kmlRangeTxPower)
+	} else {
+		kmlRangeTxPower = *kmlTxPower
+	}
+	if *kmlPathSpacingFlag < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -kml-path-spacing must not be negative, using %v\n", defaultKMLPathSpacing)
+	} else {
+		kmlPathSpacing = *kmlPathSpacingFlag
+	}
+
+	// Validate distance units
+	if *units != "metric" && *units != "imperial" {
+		fmt.Fprintf(os.Stderr, "Warning: -units must be metric or imperial, using metric\n")
+		*units = "metric"
+	}
+
+	// Select the color theme before anything is drawn
+	selected, ok := themes[strings.ToLower(*themeName)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown -theme %q (choose one of: %s)\n", *themeName, strings.Join(themeNames(), ", "))
+		os.Exit(1)
+	}
+	theme = selected
+
+	if *gpsFile != "" && *gpsPort != "" {
+		fmt.Fprintf(os.Stderr, "Error: -gps and -gps-file cannot be used together\n")
+		os.Exit(1)
+	}
+
+	// GPS and BLE scanner must be separate devices; sharing one port would interleave NMEA and JSON
+	for i, port := range serialPorts {
+		if *gpsPort != "" && sameDevicePath(*gpsPort, port) {
+			fmt.Fprintf(os.Stderr, "Error: -gps and -port must be different devices (both refer to %s)\n", *gpsPort)
+			os.Exit(1)
+		}
+		for _, other := range serialPorts[:i] {
+			if sameDevicePath(port, other) {
+				fmt.Fprintf(os.Stderr, "Error: -port %s is given more than once\n", port)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// Load watchlist
+	watchlist := NewWatchlist()
+	if *watchMACs != "" {
+		macs, err := parseWatchFlag(*watchMACs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading watchlist: %v\n", err)
+			os.Exit(1)
+		}
+		for _, mac := range macs {
+			watchlist.Add(mac)
+		}
+	}
+
 	// Initialize aggregator
-	agg := NewAggregator()
+	agg := NewAggregator(*staleAfter)
+	agg.SetGeoLimits(*geoTopN, *geoCapacity)
+	if *mfrHistory < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -mfr-history must not be negative, ignoring it\n")
+		*mfrHistory = 0
+	}
+	agg.SetMfrHistory(*mfrHistory)
+
+	// Cap and prune tracked devices, optionally logging the ones dropped
+	var onEvict func(*BLEDevice)
+	if *evictLogPath != "" && (*maxDevices > 0 || *pruneAfter > 0) {
+		evictLog, err := openEvictionLog(*evictLogPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer evictLog.Close()
+		onEvict = evictLog.Write
+	}
+	if *maxDevices > 0 {
+		agg.SetMaxDevices(*maxDevices, onEvict)
+	}
+	if *pruneAfter > 0 {
+		agg.SetPruneAfter(*pruneAfter, onEvict)
+	}
+
+	// Ambient presence: chirp whenever a new MAC turns up
+	if *chirpOnNew {
+		agg.SetOnNewDevice(func(*BLEDevice) { playNewDeviceSound() })
+	}
+
+	// Open the JSON Lines stream if requested
+	var stream *JSONLStream
+	if *jsonlOut != "" {
+		var err error
+		stream, err = openJSONLStream(*jsonlOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer stream.Close()
+	}
+
+	// Optional field name mapping for other sniffer firmwares
+	var schema *FieldSchema
+	if *schemaPath != "" {
+		var err error
+		schema, err = loadFieldSchema(*schemaPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -schema: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Optional verbatim capture of the serial input, for reprocessing with future parsers
+	var raw *rawTee
+	if *rawOut != "" {
+		var err error
+		raw, err = openRawTee(*rawOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer raw.Close()
+	}
+
+	// Optional per-advertisement log, rotated so a long survey can't fill the disk
+	var obsLog *observationLog
+	if *obsLogPath != "" {
+		if *obsLogMaxMB < 0 {
+			fmt.Fprintf(os.Stderr, "Warning: -obs-log-max-mb must not be negative, using %d\n", defaultObsLogMaxMB)
+			*obsLogMaxMB = defaultObsLogMaxMB
+		}
+		var err error
+		obsLog, err = openObservationLog(*obsLogPath, int64(*obsLogMaxMB)<<20)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer obsLog.Close()
+	}
+
+	// Backend for firmware notification messages
+	notifier, err := parseNotifier(*notifySpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -notify: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Paused state
-	var paused bool
-	var pauseMu sync.RWMutex
+	// Keeps the firmware's notification text for the status line and panel
+	notices := NewNotificationLog()
+
+	// Flags tracker-like devices that follow the user between GPS fixes
+	trackers := NewTrackerDetector()
+
+	// Shared TUI state
+	app := &App{agg: agg, watchlist: watchlist, stream: stream, heatmapCellMeters: *heatmapCell, exports: exports, trackers: trackers, notices: notices, session: NewSessionStats(sessionStart)}
+	if *minCount < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -min-count must not be negative, ignoring it\n")
+		*minCount = 0
+	}
+	app.filter.SetMinCount(*minCount)
 
 	// Done channel for graceful shutdown
 	done := make(chan struct{})
+	var workers sync.WaitGroup // Goroutines that return once done is closed
 
-	// Initialize connection state
-	connState := &ConnectionState{
-		connected: false,
-	}
+	// Connection state for each BLE input
+	connState := &ConnectionSet{}
 
 	// Initialize location state
 	locState := NewLocationState()
+	if *gpsMaxAge < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -gps-max-age must not be negative, using default %v\n", defaultFixMaxAge)
+		*gpsMaxAge = defaultFixMaxAge
+	}
+	locState.SetFixMaxAge(*gpsMaxAge)
+	if *maxHDOP < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -max-hdop must not be negative, ignoring it\n")
+		*maxHDOP = 0
+	}
+	locState.SetMaxHDOP(*maxHDOP)
 
 	// Start GPS reading if -gps flag is provided
 	if *gpsPort != "" {
-		go readGPS(*gpsPort, locState, done)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			readGPS(*gpsPort, locState, done)
+		}()
 	}
 
-	// Start reading from input source (handles reconnection internally)
-	go readSerial(*serialPort, *baudRate, agg, &paused, &pauseMu, connState, locState, done)
+	// Or play back a recorded NMEA log, lined up with the start of a -replay capture
+	if *gpsFile != "" {
+		file, err := os.Open(*gpsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to open GPS file: %v\n", err)
+			os.Exit(1)
+		}
+		var origin time.Time
+		if *replayFile != "" {
+			origin = replayStartTime(*replayFile)
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			readGPSFile(file, newNMEAPacer(*replaySpeed, origin), locState, done)
+		}()
+	}
 
-	// Initialize screen
-	s, err := tcell.NewScreen()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating screen: %v\n", err)
-		os.Exit(1)
+	// Select input sources: replay file, one or more serial ports, or stdin
+	type namedSource struct {
+		name   string
+		source DeviceSource
 	}
-	if err := s.Init(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing screen: %v\n", err)
-		os.Exit(1)
+	var sources []namedSource
+	addSource := func(name string, source DeviceSource) {
+		sources = append(sources, namedSource{name, source})
+	}
+	switch {
+	case *replayFile != "":
+		addSource(*replayFile, &replaySource{filename: *replayFile, speed: *replaySpeed})
+	case len(serialPorts) == 0:
+		addSource("stdin", &serialSource{baudRate: *baudRate})
+	default:
+		for _, port := range serialPorts {
+			addSource(port, &serialSource{portPath: port, baudRate: *baudRate})
+		}
 	}
-	defer s.Fini()
 
-	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite))
-	s.EnableMouse() // Enable mouse support for scrolling
+	// WebSocket fan-out, only needed when the HTTP API is enabled
+	var hub *WSHub
+	if *httpAddr != "" {
+		hub = NewWSHub()
+	}
+
+	// Ingestion pipeline shared by all sources
+	ing := &Ingester{
+		agg:       agg,
+		locState:  locState,
+		watchlist: watchlist,
+		stream:    stream,
+		hub:       hub,
+		trackers:  trackers,
+		raw:       raw,
+		obsLog:    obsLog,
+		schema:    schema,
+		notifier:  notifier,
+		notices:   notices,
+	}
+
+	// Start reading from each input source (each handles its own reconnection)
+	// With several inputs, each gets its own ingester copy so observations are tagged by source
+	for _, src := range sources {
+		srcIng := ing
+		if len(sources) > 1 {
+			tagged := *ing
+			tagged.source = src.name
+			srcIng = &tagged
+		}
+		srcConn := connState.Add(src.name)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			src.source.Run(srcIng, srcConn, done)
+		}()
+	}
+
+	// Start the HTTP API if requested
+	if *httpAddr != "" {
+		api, err := newAPIServer(*httpAddr, agg, connState, locState, hub)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer api.Close()
+		go api.Run()
+	}
+
+	// Interactive exports are written in the background; quitting waits for them
+	exporter := NewExporter()
+	app.exporter = exporter
+
+	// Start pruning devices past -prune-after
+	if *pruneAfter > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			agg.RunPruner(done)
+		}()
+	}
+
+	// Start periodic autosave if requested
+	if *autosave > 0 {
+		app.autosaver = NewAutosaver(agg, *autosave, *autosaveKML, exports, exporter)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			app.autosaver.Run(done)
+		}()
+	}
 
 	// Initialize table state
 	tableState := &TableState{
 		nearScrollOffset: 0,
 		farScrollOffset:  0,
 		focusedTable:     "near",
+		nearSort:         defaultRecentSort,
+		farSort:          initialStaleSort,
+		relativeAge:      *relativeAge,
+		fullUUIDs:        *fullUUIDs,
+		imperial:         *units == "imperial",
+		classColors:      *classColors,
+		stripes:          *stripes,
+		hiddenColumns:    hiddenColumns,
 	}
 
 	// Initialize export modal state
@@ -105,48 +524,141 @@ func main() {
 		selectedOption: 0,
 	}
 
+	// Initialize device detail and watchlist modal state
+	detailModal := &DetailModalState{}
+	watchModal := &WatchModalState{}
+	proximity := &ProximityState{}
+	clearModal := &ClearModalState{}
+	mfrModal := &MfrFilterModalState{}
+
+	app.connState = connState
+	app.locState = locState
+	app.tableState = tableState
+	app.exportModal = exportModal
+	app.detailModal = detailModal
+	app.watchModal = watchModal
+	app.mfrModal = mfrModal
+	app.proximity = proximity
+	app.density = NewDensityGraph()
+	app.noticesModal = &NoticesModalState{}
+	app.helpModal = &HelpModalState{}
+	if *correlateRPA {
+		app.correlator = NewCorrelator()
+	}
+	app.clearModal = clearModal
+
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	if *headless {
+		// No screen: just collect, autosave and stream until interrupted
+		var statusOut io.Writer = os.Stdout
+		if *jsonlOut == "-" {
+			statusOut = os.Stderr // Keep stdout for the JSON Lines stream
+		}
+		runHeadless(statusOut, app, sigChan)
+	} else {
+		app.screen = newScreen()
+		defer app.screen.Fini()
+		runTUI(app, sigChan, refreshInterval, done)
+	}
+
+	// Let readers and the autosaver finish before the final export and screen teardown
+	close(done)
+	waitTimeout(&workers, shutdownTimeout)
+
+	// Don't tear down under an export that is still writing; a slow one is reported rather than awaited forever
+	exporter.Close()
+	exportsFinished := exporter.Wait(exportDrainTimeout)
+
+	// Write the final snapshot, then restore the terminal so the results are visible
+	results := quitExports.write(agg, exports)
+	if app.screen != nil {
+		app.screen.Fini()
+	}
+	if !exportsFinished {
+		fmt.Fprintln(os.Stderr, "Warning: an export was still being written at exit and may be incomplete")
+		logger.Warn("export still in progress at exit")
+	}
+	for _, result := range results {
+		fmt.Fprintln(os.Stderr, result)
+	}
+
+	// Session summary, on the terminal now that any TUI is gone and in the log
+	summary := "Session: " + formatElapsed(app.session.Elapsed(time.Now())) + "\n" + agg.Summary()
+	fmt.Fprintln(os.Stderr, summary)
+	logger.Info("session summary", "summary", summary)
+}
+
+// newScreen initializes the terminal for the TUI, exiting if there is none to use
+func newScreen() tcell.Screen {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating screen: %v\n", err)
+		os.Exit(1)
+	}
+	if err := s.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing screen: %v\n", err)
+		os.Exit(1)
+	}
+
+	s.SetStyle(theme.Base)
+	s.EnableMouse() // Enable mouse support for scrolling
+	return s
+}
+
+// runTUI redraws and handles input on app.screen until the user quits or a signal arrives
+func runTUI(app *App, sigChan <-chan os.Signal, refreshInterval time.Duration, done <-chan struct{}) {
 	// Ticker for refresh
 	ticker := time.NewTicker(refreshInterval)
 	defer ticker.Stop()
 
+	// Screen events arrive on a channel so the loop blocks instead of polling
+	events := make(chan tcell.Event, 16)
+	go app.screen.ChannelEvents(events, done)
+
 	// Initial draw
-	drawTable(s, agg.GetSorted(), paused, tableState, connState, locState, exportModal)
+	app.redraw()
 
-	// Event loop
+	// Event loop: input, refresh and signals are handled as they arrive
 	quit := false
 	for !quit {
 		select {
 		case <-ticker.C:
-			pauseMu.RLock()
-			isPaused := paused
-			pauseMu.RUnlock()
-			drawTable(s, agg.GetSorted(), isPaused, tableState, connState, locState, exportModal)
+			// The density history keeps sampling whatever is on screen
+			deviceCount, _ := app.agg.Stats()
+			app.density.Sample(time.Now(), deviceCount, app.locState)
+
+			// While paused the display stays frozen; ingestion continues in the background
+			// Snapshot mode keeps redrawing, since only its tables are frozen
+			if !app.IsPaused() {
+				app.redraw()
+			}
 
 		case <-sigChan:
 			quit = true
 
-		default:
-			// Check for key events (non-blocking)
-			if s.HasPendingEvent() {
-				ev := s.PollEvent()
-				switch ev := ev.(type) {
-				case *tcell.EventKey:
-					if handleKeyboardEvent(ev, agg, &paused, &pauseMu, tableState, connState, locState, exportModal, s) {
-						quit = true
-					}
-				case *tcell.EventMouse:
-					handleMouseEvent(ev, tableState, agg, paused, s, connState, locState, exportModal)
-				case *tcell.EventResize:
-					handleResizeEvent(s, agg, &paused, &pauseMu, tableState, connState, locState, exportModal)
+		case result := <-app.exporter.Results():
+			reportExport(app, result.filename, result.err)
+			app.redraw()
+
+		case ev, ok := <-events:
+			if !ok {
+				// The screen has been finalized
+				quit = true
+				break
+			}
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				if handleKeyboardEvent(ev, app) {
+					quit = true
 				}
+			case *tcell.EventMouse:
+				handleMouseEvent(ev, app)
+			case *tcell.EventResize:
+				handleResizeEvent(app)
 			}
-			time.Sleep(10 * time.Millisecond)
 		}
 	}
-
-	close(done)
 }
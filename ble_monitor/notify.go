@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	json "github.com/goccy/go-json"
+)
+
+// How long a webhook POST may take before it is abandoned
+const webhookTimeout = 5 * time.Second
+
+// Title shown on desktop notifications
+const notificationTitle = "BLE Monitor"
+
+// Most recent firmware notifications kept for the notifications panel
+const notificationLogCapacity = 200
+
+// notificationEntry is one firmware notification and when it arrived
+type notificationEntry struct {
+	At   time.Time
+	Text string
+}
+
+// NotificationLog keeps the most recent firmware notifications
+// Serial readers add to it while the TUI reads it, so access is locked
+type NotificationLog struct {
+	mu      sync.Mutex
+	entries *RingBuffer[notificationEntry]
+}
+
+// NewNotificationLog creates an empty log holding up to notificationLogCapacity entries
+func NewNotificationLog() *NotificationLog {
+	return &NotificationLog{entries: NewRingBuffer[notificationEntry](notificationLogCapacity)}
+}
+
+// Add records a notification received at now
+func (l *NotificationLog) Add(now time.Time, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries.Push(notificationEntry{At: now, Text: text})
+}
+
+// All returns the logged notifications, oldest first
+func (l *NotificationLog) All() []notificationEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries.GetAll()
+}
+
+// Latest returns the most recent notification, if any
+func (l *NotificationLog) Latest() (notificationEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries.Latest()
+}
+
+// Notifier delivers the text of a firmware notification message to the user
+type Notifier interface {
+	Notify(text string)
+}
+
+// parseNotifier builds the -notify backend: "beep", "desktop", "webhook:<url>" or "none"
+func parseNotifier(spec string) (Notifier, error) {
+	switch {
+	case spec == "beep":
+		return bellNotifier{}, nil
+	case spec == "desktop":
+		return desktopNotifier{}, nil
+	case spec == "none":
+		return noNotifier{}, nil
+	case strings.HasPrefix(spec, "webhook:"):
+		url := strings.TrimPrefix(spec, "webhook:")
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("webhook URL must start with http:// or https://, got %q", url)
+		}
+		return &webhookNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q (want beep, desktop, webhook:<url> or none)", spec)
+}
+
+// bellNotifier rings the terminal bell; the text has nowhere to go on screen
+type bellNotifier struct{}
+
+// Notify rings the bell
+func (bellNotifier) Notify(string) {
+	fmt.Print("\a")
+}
+
+// desktopNotifier shows the text as a desktop notification
+type desktopNotifier struct{}
+
+// Notify pops up text without blocking the caller
+func (desktopNotifier) Notify(text string) {
+	go func() {
+		if err := beeep.Notify(notificationTitle, text, ""); err != nil {
+			logger.Warn("desktop notification failed", "error", err)
+		}
+	}()
+}
+
+// noNotifier drops notifications
+type noNotifier struct{}
+
+// Notify does nothing
+func (noNotifier) Notify(string) {}
+
+// webhookNotifier POSTs each notification as JSON to a URL
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// webhookPayload is the body of each webhook POST
+type webhookPayload struct {
+	Notification string    `json:"notification"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Notify sends text to the webhook; failures are logged
+func (w *webhookNotifier) Notify(text string) {
+	body, err := json.Marshal(webhookPayload{Notification: text, Timestamp: time.Now().UTC()})
+	if err != nil {
+		return
+	}
+	// Posted in the background so a slow endpoint never stalls ingestion
+	go func() {
+		resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			logger.Warn("webhook notification failed", "url", w.url, "error", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.Warn("webhook notification rejected", "url", w.url, "status", resp.Status)
+		}
+	}()
+}
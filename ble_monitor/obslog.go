@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default size at which the -obs-log file is rotated (-obs-log-max-mb)
+const defaultObsLogMaxMB = 100
+
+// Header line written at the top of each new observation log file
+const obsLogHeader = "timestamp,mac_address,rssi,latitude,longitude,elevation"
+
+// observationLog appends one CSV line per processed advertisement (-obs-log), for offline timelines
+// Once the file passes maxBytes it is renamed to <path>.1, replacing any older one, and a new file is started
+type observationLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64 // 0 = never rotate
+	file     *os.File
+	w        *bufio.Writer
+	size     int64 // Bytes in the current file, buffered ones included
+}
+
+// openObservationLog opens (or creates) the observation log for appending
+func openObservationLog(path string, maxBytes int64) (*observationLog, error) {
+	l := &observationLog{path: path, maxBytes: maxBytes}
+	if err := l.open(); err != nil {
+		return nil, fmt.Errorf("failed to open observation log: %w", err)
+	}
+	return l, nil
+}
+
+// open starts writing to path, adding the header if the file is new or empty
+func (l *observationLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.w, l.size = file, bufio.NewWriter(file), info.Size()
+	if l.size == 0 {
+		n, _ := l.w.WriteString(obsLogHeader + "\n")
+		l.size += int64(n)
+	}
+	return nil
+}
+
+// Write appends one observation; loc is the GPS fix at the time, or nil without one
+func (l *observationLog) Write(at time.Time, mac string, rssi int, loc *GeoLocation) {
+	line := make([]byte, 0, 96)
+	line = at.UTC().AppendFormat(line, time.RFC3339Nano)
+	line = append(line, ',')
+	line = append(line, mac...)
+	line = append(line, ',')
+	line = strconv.AppendInt(line, int64(rssi), 10)
+	if loc != nil {
+		line = append(line, ',')
+		line = strconv.AppendFloat(line, loc.Latitude, 'f', 7, 64)
+		line = append(line, ',')
+		line = strconv.AppendFloat(line, loc.Longitude, 'f', 7, 64)
+		line = append(line, ',')
+		line = strconv.AppendFloat(line, loc.Elevation, 'f', 1, 64)
+	} else {
+		line = append(line, ",,,"...)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return // A failed rotation left no file to write to
+	}
+	n, _ := l.w.Write(line)
+	l.size += int64(n)
+	if l.maxBytes > 0 && l.size >= l.maxBytes {
+		l.rotate()
+	}
+}
+
+// rotate moves the full file aside to <path>.1 and starts a new one; l.mu must be held
+func (l *observationLog) rotate() {
+	l.w.Flush()
+	l.file.Close()
+	l.file, l.w = nil, nil
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		logger.Warn("failed to rotate observation log", "file", l.path, "error", err)
+	}
+	if err := l.open(); err != nil {
+		logger.Error("failed to reopen observation log, observations are no longer logged", "file", l.path, "error", err)
+	}
+}
+
+// Close flushes buffered lines and closes the file
+func (l *observationLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	if err := l.w.Flush(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// ouiData is a trimmed copy of the IEEE MA-L registry: one "XXXXXX<TAB>Organization" entry per line
+//
+//go:embed oui.txt
+var ouiData string
+
+var (
+	ouiOnce  sync.Once
+	ouiTable map[string]string
+)
+
+// loadOUITable parses the embedded OUI registry into a prefix -> vendor map
+func loadOUITable() {
+	ouiTable = make(map[string]string, strings.Count(ouiData, "\n")+1)
+	for _, line := range strings.Split(ouiData, "\n") {
+		prefix, vendor, ok := strings.Cut(line, "\t")
+		if !ok || len(prefix) != 6 {
+			continue
+		}
+		ouiTable[prefix] = strings.TrimSpace(vendor)
+	}
+}
+
+// lookupVendor resolves the first three octets of a MAC address to the registered hardware vendor
+// Locally-administered (randomized) addresses are labeled "(random)" since their OUI is meaningless
+// Returns an empty string if the address is malformed or the prefix is unknown
+func lookupVendor(mac string) string {
+	// Strip separators, keeping only hex digits
+	var hex strings.Builder
+	for _, ch := range mac {
+		switch {
+		case ch >= '0' && ch <= '9', ch >= 'A' && ch <= 'F':
+			hex.WriteRune(ch)
+		case ch >= 'a' && ch <= 'f':
+			hex.WriteRune(ch - 'a' + 'A')
+		}
+		if hex.Len() == 6 {
+			break
+		}
+	}
+	prefix := hex.String()
+	if len(prefix) < 6 {
+		return ""
+	}
+
+	// Second-least-significant bit of the first octet marks a locally-administered address
+	if hexDigitValue(prefix[1])&0x2 != 0 {
+		return "(random)"
+	}
+
+	ouiOnce.Do(loadOUITable)
+	return ouiTable[prefix]
+}
+
+// hexDigitValue converts a single uppercase hex digit to its numeric value
+func hexDigitValue(ch byte) byte {
+	if ch >= 'A' {
+		return ch - 'A' + 10
+	}
+	return ch - '0'
+}
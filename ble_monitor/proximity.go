@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Proximity mode tuning
+const (
+	proximitySmoothWindow = 5                       // Readings averaged for the displayed RSSI
+	proximityTrendDelta   = 1.5                     // dB change between windows that counts as a trend
+	proximitySlowClick    = 1500 * time.Millisecond // Click interval at the weakest signal
+	proximityFastClick    = 80 * time.Millisecond   // Click interval at the strongest signal
+	proximityWeakRSSI     = -100
+	proximityStrongRSSI   = -30
+)
+
+// ProximityState tracks the full-screen "hot/cold" view for locating a single device
+type ProximityState struct {
+	showing bool
+	mac     string
+	stop    chan struct{} // Closed to stop the click goroutine
+}
+
+// Show enters proximity mode for the given device and starts the click goroutine
+func (p *ProximityState) Show(mac string, agg *Aggregator) {
+	p.Hide()
+	p.showing = true
+	p.mac = mac
+	p.stop = make(chan struct{})
+	go runProximityClicks(mac, agg, p.stop)
+}
+
+// Hide leaves proximity mode and silences the clicks
+func (p *ProximityState) Hide() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	p.showing = false
+}
+
+// IsShowing returns whether the proximity view is active
+func (p *ProximityState) IsShowing() bool {
+	return p.showing
+}
+
+// rssiTrend compares the latest smoothing window against the one before it
+// Returns 1 if the signal is getting stronger (closer), -1 if weaker (farther), 0 if steady
+func rssiTrend(history []int) int {
+	if len(history) < proximitySmoothWindow*2 {
+		return 0
+	}
+	current := smoothRSSI(history, proximitySmoothWindow)
+	previous := smoothRSSI(history[:len(history)-proximitySmoothWindow], proximitySmoothWindow)
+	switch {
+	case current-previous > proximityTrendDelta:
+		return 1
+	case previous-current > proximityTrendDelta:
+		return -1
+	}
+	return 0
+}
+
+// clickInterval maps a smoothed RSSI to the delay between Geiger clicks (stronger = faster)
+func clickInterval(rssi float64) time.Duration {
+	fraction := (rssi - proximityWeakRSSI) / (proximityStrongRSSI - proximityWeakRSSI)
+	fraction = clampFloat(fraction, 0, 1)
+	return proximitySlowClick - time.Duration(fraction*float64(proximitySlowClick-proximityFastClick))
+}
+
+// clampFloat limits v to the range [lo, hi]
+func clampFloat(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// runProximityClicks plays clicks at a rate driven by the device's smoothed RSSI until stop is closed
+// The counter goes quiet while the device is stale
+func runProximityClicks(mac string, agg *Aggregator, stop <-chan struct{}) {
+	interval := proximitySlowClick
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		history, lastSeen, ok := agg.GetRSSIHistory(mac)
+		if !ok || len(history) == 0 || time.Since(lastSeen) > agg.staleAfter {
+			interval = proximitySlowClick
+			continue
+		}
+		interval = clickInterval(smoothRSSI(history, proximitySmoothWindow))
+		playProximityClick()
+	}
+}
+
+// bigGlyphs is a 3x5 block font for rendering the RSSI value
+var bigGlyphs = map[rune][5]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	'-': {"   ", "   ", "███", "   ", "   "},
+}
+
+// drawBigText draws text centered on row y using bigGlyphs, each cell doubled horizontally
+func drawBigText(s tcell.Screen, y, width int, style tcell.Style, text string) {
+	const glyphWidth = 3*2 + 2 // Doubled glyph plus spacing
+	x := max(0, (width-len(text)*glyphWidth)/2)
+	for _, ch := range text {
+		glyph, ok := bigGlyphs[ch]
+		if !ok {
+			continue
+		}
+		for row, line := range glyph {
+			col := 0
+			for _, cell := range line {
+				setCell(s, x+col, y+row, cell, style)
+				setCell(s, x+col+1, y+row, cell, style)
+				col += 2
+			}
+		}
+		x += glyphWidth
+	}
+}
+
+// sparkline renders RSSI readings as a one-line bar chart scaled between the weak and strong limits
+func sparkline(history []int) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	var sb strings.Builder
+	for _, rssi := range history {
+		fraction := clampFloat(float64(rssi-proximityWeakRSSI)/float64(proximityStrongRSSI-proximityWeakRSSI), 0, 1)
+		sb.WriteRune(bars[int(fraction*float64(len(bars)-1))])
+	}
+	return sb.String()
+}
+
+// drawProximityView draws the full-screen proximity view for the tracked device
+func drawProximityView(s tcell.Screen, prox *ProximityState, agg *Aggregator) {
+	width, height := s.Size()
+	bgStyle := theme.Base
+	titleStyle := theme.Base.Reverse(true).Bold(true)
+
+	// Title bar with the device identity
+	title := " PROXIMITY: " + prox.mac
+	if dev := agg.Get(prox.mac); dev != nil && dev.DeviceName != "" {
+		title += " (" + dev.DeviceName + ")"
+	}
+	drawText(s, 0, 0, width, titleStyle, title)
+
+	centerY := height/2 - 4
+	history, lastSeen, ok := agg.GetRSSIHistory(prox.mac)
+	if !ok || len(history) == 0 {
+		drawCenteredText(s, 0, centerY, width, bgStyle, "Waiting for signal...")
+	} else {
+		smoothed := smoothRSSI(history, proximitySmoothWindow)
+		_, signalColor := getSignalIndicator(int(smoothed))
+		stale := time.Since(lastSeen) > agg.staleAfter
+		if stale {
+			signalColor = theme.LostColor
+		}
+
+		// Large smoothed RSSI
+		drawBigText(s, centerY, width, bgStyle.Foreground(signalColor), fmt.Sprintf("%.0f", smoothed))
+		drawCenteredText(s, 0, centerY+6, width, bgStyle, fmt.Sprintf("dBm (raw %d)", history[len(history)-1]))
+
+		// Trend arrow
+		trendText, trendColor := "● STEADY", theme.SteadyColor
+		switch rssiTrend(history) {
+		case 1:
+			trendText, trendColor = "▲ GETTING CLOSER", theme.CloserColor
+		case -1:
+			trendText, trendColor = "▼ GETTING FARTHER", theme.FartherColor
+		}
+		if stale {
+			trendText = fmt.Sprintf("○ LOST (last seen %v ago)", time.Since(lastSeen).Round(time.Second))
+			trendColor = theme.LostColor
+		}
+		drawCenteredText(s, 0, centerY+8, width, bgStyle.Foreground(trendColor).Bold(true), trendText)
+
+		// Recent history
+		drawCenteredText(s, 0, centerY+10, width, bgStyle.Foreground(signalColor), sparkline(history))
+	}
+
+	// Draw navigation hint
+	drawCenteredText(s, 0, height-1, width, bgStyle, "ESC: Back to table")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rawTee appends every line read from the BLE input, verbatim, to a capture file
+// It is shared by all serial readers and stays open across reconnections
+type rawTee struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// openRawTee opens (or creates) the capture file for appending
+func openRawTee(path string) (*rawTee, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw capture: %w", err)
+	}
+	return &rawTee{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Write appends one line followed by a newline
+func (t *rawTee) Write(line []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.w.Write(line)
+	t.w.WriteByte('\n')
+}
+
+// Close flushes buffered lines and closes the file
+func (t *rawTee) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.w.Flush(); err != nil {
+		t.file.Close()
+		return err
+	}
+	return t.file.Close()
+}
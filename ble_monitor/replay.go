@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// replayRecord mirrors the fields of an exported BLEDevice that are replayed
+// GeoData is deliberately omitted: exports carry no per-RSSI location history
+type replayRecord struct {
+	MacAddress   string
+	RSSI         int
+	DeviceName   string
+	MfrCode      int
+	MfrData      string
+	ServiceUUIDs []string
+	ServiceData  map[string]string
+	Connectable  *bool  // Missing from captures made before it was recorded
+	Protocol     string // Likewise, and only when the firmware sends it
+	Source       string // Only set in captures merged from several inputs
+	LastSeen     time.Time
+}
+
+// replaySource feeds a previously exported JSON device array into the aggregator as a live feed
+type replaySource struct {
+	filename string
+	speed    float64 // Playback multiplier for original timestamp gaps; 0 replays everything at once
+}
+
+// loadReplayFile reads an ExportJSON capture and returns its records ordered by LastSeen
+func loadReplayFile(filename string) ([]replayRecord, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+
+	var records []replayRecord
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse replay file: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].LastSeen.Before(records[j].LastSeen)
+	})
+
+	return records, nil
+}
+
+// replayStartTime returns the LastSeen of the earliest record in a capture, or the zero time if it can't be read
+func replayStartTime(filename string) time.Time {
+	records, err := loadReplayFile(filename)
+	if err != nil || len(records) == 0 {
+		return time.Time{}
+	}
+	return records[0].LastSeen
+}
+
+// Run replays the capture, pacing records by their original LastSeen gaps divided by speed
+// Devices are stamped with the current time so they age from RECENT into STALE as the replay advances
+func (src *replaySource) Run(ing *Ingester, connState *ConnectionState, done <-chan struct{}) {
+	records, err := loadReplayFile(src.filename)
+	if err != nil {
+		connState.SetConnected(false)
+		connState.SetError(err)
+		return
+	}
+
+	connState.SetConnected(true)
+
+	for i, rec := range records {
+		// Wait for the (scaled) gap since the previous record
+		if i > 0 && src.speed > 0 {
+			gap := rec.LastSeen.Sub(records[i-1].LastSeen)
+			if gap > 0 {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Duration(float64(gap) / src.speed)):
+				}
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		mac := normalizeMAC(rec.MacAddress)
+		if mac == "" {
+			continue
+		}
+
+		ing.ingestDevice(&BLEDevice{
+			MacAddress:   mac,
+			RSSI:         rec.RSSI,
+			DeviceName:   rec.DeviceName,
+			MfrCode:      rec.MfrCode,
+			MfrData:      rec.MfrData,
+			ServiceUUIDs: rec.ServiceUUIDs,
+			ServiceData:  rec.ServiceData,
+			Connectable:  rec.Connectable,
+			Protocol:     rec.Protocol,
+			Source:       rec.Source,
+			LastSeen:     time.Now().UTC(),
+		})
+	}
+}
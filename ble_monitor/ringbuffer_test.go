@@ -0,0 +1,105 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// pushAll pushes 1..n into a new ring buffer of the given capacity
+func pushAll(capacity, n int) *RingBuffer[int] {
+	rb := NewRingBuffer[int](capacity)
+	for i := 1; i <= n; i++ {
+		rb.Push(i)
+	}
+	return rb
+}
+
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		pushed   int
+		all      []int // GetAll, oldest first
+	}{
+		{name: "empty", capacity: 4, pushed: 0, all: []int{}},
+		{name: "partial", capacity: 4, pushed: 3, all: []int{1, 2, 3}},
+		{name: "full", capacity: 4, pushed: 4, all: []int{1, 2, 3, 4}},
+		{name: "wrapped", capacity: 4, pushed: 6, all: []int{3, 4, 5, 6}},
+		{name: "wrapped twice", capacity: 4, pushed: 9, all: []int{6, 7, 8, 9}},
+		{name: "capacity 1 empty", capacity: 1, pushed: 0, all: []int{}},
+		{name: "capacity 1", capacity: 1, pushed: 1, all: []int{1}},
+		{name: "capacity 1 wrapped", capacity: 1, pushed: 5, all: []int{5}},
+		{name: "capacity 0 is 1", capacity: 0, pushed: 3, all: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb := pushAll(tt.capacity, tt.pushed)
+
+			if got := rb.GetAll(); !slices.Equal(got, tt.all) {
+				t.Errorf("GetAll() = %v, want %v", got, tt.all)
+			}
+			if got := rb.Size(); got != len(tt.all) {
+				t.Errorf("Size() = %d, want %d", got, len(tt.all))
+			}
+
+			latest, ok := rb.Latest()
+			if len(tt.all) == 0 {
+				if ok {
+					t.Errorf("Latest() = %d, true on an empty buffer", latest)
+				}
+			} else if !ok || latest != tt.all[len(tt.all)-1] {
+				t.Errorf("Latest() = %d, %v, want %d, true", latest, ok, tt.all[len(tt.all)-1])
+			}
+
+			// Peek returns the newest n, oldest first, for every n up to beyond the size
+			for n := -1; n <= len(tt.all)+1; n++ {
+				want := tt.all[len(tt.all)-min(max(n, 0), len(tt.all)):]
+				if got := rb.Peek(n); !slices.Equal(got, want) {
+					t.Errorf("Peek(%d) = %v, want %v", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBufferResultsAreCopies(t *testing.T) {
+	rb := pushAll(3, 3)
+	rb.GetAll()[0] = 100
+	rb.Peek(2)[0] = 100
+	if got := rb.GetAll(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("GetAll() = %v after modifying returned slices, want [1 2 3]", got)
+	}
+}
+
+func TestRingBufferClone(t *testing.T) {
+	rb := pushAll(3, 4)
+	clone := rb.Clone()
+	rb.Push(5)
+	if got := clone.GetAll(); !slices.Equal(got, []int{2, 3, 4}) {
+		t.Errorf("clone GetAll() = %v, want [2 3 4]", got)
+	}
+	clone.Push(6)
+	if got := rb.GetAll(); !slices.Equal(got, []int{3, 4, 5}) {
+		t.Errorf("GetAll() = %v after pushing to the clone, want [3 4 5]", got)
+	}
+}
+
+func TestRingBufferEvictsOldest(t *testing.T) {
+	rb := NewRingBuffer[int](5)
+	for i := 1; i <= 12; i++ {
+		rb.Push(i)
+
+		// Once full, each push drops exactly the oldest item and keeps the rest in order
+		want := make([]int, 0, 5)
+		for v := max(1, i-4); v <= i; v++ {
+			want = append(want, v)
+		}
+		if got := rb.GetAll(); !slices.Equal(got, want) {
+			t.Fatalf("after pushing %d: GetAll() = %v, want %v", i, got, want)
+		}
+	}
+	if got := rb.Size(); got != 5 {
+		t.Errorf("Size() = %d, want capacity 5", got)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	json "github.com/goccy/go-json"
+)
+
+// FieldSchema renames another firmware's JSON fields to the names Message expects
+// Only names are mapped; values must already have the types Message uses
+type FieldSchema struct {
+	aliases map[string]string // Firmware field name -> Message JSON field name
+}
+
+// loadFieldSchema reads a JSON object mapping firmware field names to Message field names,
+// e.g. {"addr": "mac_address", "manufacturer": "mfr_code"}
+func loadFieldSchema(path string) (*FieldSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var aliases map[string]string
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("schema must be a JSON object of field names: %w", err)
+	}
+
+	known := messageFieldNames()
+	for from, to := range aliases {
+		if !known[to] {
+			names := make([]string, 0, len(known))
+			for name := range known {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("%q maps to unknown field %q (expected one of %s)", from, to, strings.Join(names, ", "))
+		}
+	}
+	return &FieldSchema{aliases: aliases}, nil
+}
+
+// messageFieldNames returns the JSON field names Message decodes, taken from its struct tags
+func messageFieldNames() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(Message{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// Remap rewrites line with firmware field names replaced by their Message names
+// A mapped field overrides a native field of the same name on the same line
+func (fs *FieldSchema) Remap(line []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, err
+	}
+	for from, to := range fs.aliases {
+		if value, ok := fields[from]; ok {
+			delete(fields, from)
+			fields[to] = value
+		}
+	}
+	return json.Marshal(fields)
+}
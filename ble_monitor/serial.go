@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,13 +14,17 @@ import (
 	"go.bug.st/serial"
 )
 
+// A dismissed CONNECTION LOST modal comes back if the input is still down this long afterwards
+const disconnectModalReshow = time.Minute
+
 // ConnectionState tracks serial connection status
 type ConnectionState struct {
 	mu            sync.RWMutex
 	connected     bool
 	lastErrorTime time.Time
 	totalAttempts int
-	modalShown    bool // Track if disconnection modal is currently displayed
+	modalShown    bool      // Track if disconnection modal is currently displayed
+	dismissedAt   time.Time // When the disconnection modal was last dismissed; zero once reconnected
 }
 
 func (cs *ConnectionState) SetConnected(connected bool) {
@@ -26,6 +32,7 @@ func (cs *ConnectionState) SetConnected(connected bool) {
 	cs.connected = connected
 	if connected {
 		cs.totalAttempts = 0
+		cs.dismissedAt = time.Time{}
 	}
 	cs.mu.Unlock()
 }
@@ -55,6 +62,117 @@ func (cs *ConnectionState) IsModalShown() bool {
 	return cs.modalShown
 }
 
+// DismissModal hides the disconnection modal until disconnectModalReshow passes or the input reconnects
+func (cs *ConnectionState) DismissModal(now time.Time) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.modalShown = false
+	cs.dismissedAt = now
+}
+
+// modalDue reports whether the disconnection modal should be up at now, were the input down
+func (cs *ConnectionState) modalDue(now time.Time) bool {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.dismissedAt.IsZero() || now.Sub(cs.dismissedAt) >= disconnectModalReshow
+}
+
+// namedConnection is one BLE input and its connection state
+type namedConnection struct {
+	name  string
+	state *ConnectionState
+}
+
+// ConnectionSet tracks the connection state of every BLE input
+// Each input reconnects on its own; the set only summarizes them for display
+type ConnectionSet struct {
+	conns []namedConnection
+}
+
+// Add registers a new input under name and returns its connection state
+// Inputs are added before ingestion starts, so the slice itself needs no lock
+func (cs *ConnectionSet) Add(name string) *ConnectionState {
+	state := &ConnectionState{}
+	cs.conns = append(cs.conns, namedConnection{name: name, state: state})
+	return state
+}
+
+// GetStatus reports whether any input is connected, plus the most recent failure across
+// disconnected inputs and their highest attempt count
+func (cs *ConnectionSet) GetStatus() (bool, time.Time, int) {
+	anyConnected := false
+	var lastErrTime time.Time
+	attempts := 0
+	for _, c := range cs.conns {
+		connected, errTime, tries := c.state.GetStatus()
+		if connected {
+			anyConnected = true
+			continue
+		}
+		if errTime.After(lastErrTime) {
+			lastErrTime = errTime
+		}
+		if tries > attempts {
+			attempts = tries
+		}
+	}
+	return anyConnected, lastErrTime, attempts
+}
+
+// UpdateModal decides whether the CONNECTION LOST modal is displayed at now and records it on every input
+// It is shown only while no input is connected, and not within disconnectModalReshow of being dismissed
+func (cs *ConnectionSet) UpdateModal(now time.Time) bool {
+	connected, _, _ := cs.GetStatus()
+	shown := !connected
+	for _, c := range cs.conns {
+		shown = shown && c.state.modalDue(now)
+	}
+	for _, c := range cs.conns {
+		c.state.SetModalShown(shown)
+	}
+	return shown
+}
+
+// IsModalShown reports whether the CONNECTION LOST modal was displayed by the last UpdateModal
+func (cs *ConnectionSet) IsModalShown() bool {
+	for _, c := range cs.conns {
+		if c.state.IsModalShown() {
+			return true
+		}
+	}
+	return false
+}
+
+// DismissModal hides the CONNECTION LOST modal, leaving the tables usable while the inputs reconnect
+func (cs *ConnectionSet) DismissModal(now time.Time) {
+	for _, c := range cs.conns {
+		c.state.DismissModal(now)
+	}
+}
+
+// Summary returns a compact per-input status such as "✓ ttyUSB0 ✗ ttyUSB1(3)"
+func (cs *ConnectionSet) Summary() string {
+	parts := make([]string, 0, len(cs.conns))
+	for _, c := range cs.conns {
+		connected, _, attempts := c.state.GetStatus()
+		name := filepath.Base(c.name)
+		switch {
+		case connected:
+			parts = append(parts, "✓ "+name)
+		case attempts > 0:
+			parts = append(parts, fmt.Sprintf("✗ %s(%d)", name, attempts))
+		default:
+			parts = append(parts, "○ "+name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Len returns the number of inputs being tracked
+func (cs *ConnectionSet) Len() int {
+	return len(cs.conns)
+}
+
 // openSerialPort attempts to open a serial port with the given configuration
 func openSerialPort(portPath string, baudRate int) (io.ReadCloser, error) {
 	mode := &serial.Mode{
@@ -66,9 +184,25 @@ func openSerialPort(portPath string, baudRate int) (io.ReadCloser, error) {
 	return serial.Open(portPath, mode)
 }
 
+// Wait before the first reconnection attempt; nextReconnectDelay doubles it from there
+const initialReconnectDelay = 1 * time.Second
+
+// nextReconnectDelay returns the wait before the next reconnection attempt
+// The delay doubles per failure, capped at maxDelay; a zero or negative current starts at initialReconnectDelay
+func nextReconnectDelay(current, maxDelay time.Duration) time.Duration {
+	next := current * 2
+	if current <= 0 {
+		next = initialReconnectDelay
+	}
+	if next > maxDelay {
+		return maxDelay
+	}
+	return next
+}
+
 // readSerial reads from reader and processes lines, with automatic reconnection for serial ports
 // Reconnection attempts continue indefinitely with exponential backoff until success or app quit
-func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pauseMu *sync.RWMutex, connState *ConnectionState, locState *LocationState, done <-chan struct{}) {
+func readSerial(portPath string, baudRate int, ing *Ingester, connState *ConnectionState, done <-chan struct{}) {
 	var reader io.ReadCloser
 	var err error
 
@@ -76,12 +210,12 @@ func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pa
 	if portPath == "" {
 		reader = os.Stdin
 		connState.SetConnected(true)
-		readSerialLoop(reader, agg, paused, pauseMu, connState, locState, done)
+		readSerialLoop(reader, ing, connState, done)
 		return
 	}
 
 	// For serial ports, implement reconnection logic
-	reconnectDelay := 1 * time.Second
+	reconnectDelay := initialReconnectDelay
 	maxReconnectDelay := 5 * time.Second
 
 	for {
@@ -104,6 +238,8 @@ func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pa
 
 			connState.SetConnected(false)
 			connState.SetError(err)
+			_, _, attempts := connState.GetStatus()
+			logger.Warn("serial port open failed", "port", portPath, "attempt", attempts, "error", err)
 
 			// Play disconnect sound only on first failure (not repeated attempts)
 			if wasConnected {
@@ -118,24 +254,21 @@ func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pa
 			case <-done:
 				return
 			case <-time.After(reconnectDelay):
-				// Exponential backoff, max 30 seconds
-				reconnectDelay += 1
-				if reconnectDelay > maxReconnectDelay {
-					reconnectDelay = maxReconnectDelay
-				}
+				reconnectDelay = nextReconnectDelay(reconnectDelay, maxReconnectDelay)
 			}
 			continue
 		}
 
 		// Successfully connected
 		connState.SetConnected(true)
-		reconnectDelay = 1 * time.Second // Reset backoff
+		reconnectDelay = initialReconnectDelay // Reset backoff
+		logger.Info("serial port connected", "port", portPath, "baud", baudRate)
 
 		// Play success sound
 		playConnectedSound()
 
 		// Read from the port until error or done
-		err = readSerialLoop(reader, agg, paused, pauseMu, connState, locState, done)
+		err = readSerialLoop(reader, ing, connState, done)
 
 		// Close the port
 		reader.Close()
@@ -148,6 +281,7 @@ func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pa
 		}
 
 		// Connection lost, mark as disconnected and retry
+		logger.Warn("serial connection lost", "port", portPath, "error", err)
 		connState.SetConnected(false)
 		if err != nil {
 			connState.SetError(err)
@@ -163,7 +297,7 @@ func readSerial(portPath string, baudRate int, agg *Aggregator, paused *bool, pa
 }
 
 // readSerialLoop performs the actual reading and processing
-func readSerialLoop(reader io.ReadCloser, agg *Aggregator, paused *bool, pauseMu *sync.RWMutex, connState *ConnectionState, locState *LocationState, done <-chan struct{}) error {
+func readSerialLoop(reader io.ReadCloser, ing *Ingester, connState *ConnectionState, done <-chan struct{}) error {
 	scanner := bufio.NewScanner(reader)
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Increase buffer for large lines
 
@@ -175,8 +309,12 @@ func readSerialLoop(reader io.ReadCloser, agg *Aggregator, paused *bool, pauseMu
 			if scanner.Scan() {
 				// Use Bytes() instead of Text() to avoid allocation
 				line := scanner.Bytes()
+				// Keep the exact bytes before parsing so captures can be reprocessed later
+				if ing.raw != nil {
+					ing.raw.Write(line)
+				}
 				// Process immediately in this goroutine for minimal latency
-				processSerialLine(line, agg, paused, pauseMu, locState)
+				ing.processSerialLine(line)
 			} else {
 				if err := scanner.Err(); err != nil {
 					// Scanner error (likely connection issue)
@@ -189,53 +327,149 @@ func readSerialLoop(reader io.ReadCloser, agg *Aggregator, paused *bool, pauseMu
 	}
 }
 
-// processSerialLine processes a single line of JSON
-func processSerialLine(line []byte, agg *Aggregator, paused *bool, pauseMu *sync.RWMutex, locState *LocationState) {
-	// Check if paused
-	pauseMu.RLock()
-	isPaused := *paused
-	pauseMu.RUnlock()
+// Ingester turns incoming observations into aggregator updates and their side effects
+// (GPS tagging, watchlist alerts); it is shared by every DeviceSource
+type Ingester struct {
+	agg       *Aggregator
+	locState  *LocationState
+	watchlist *Watchlist
+	stream    *JSONLStream // nil unless -jsonl-out is set
+	hub       *WSHub       // nil unless -http is set
+	trackers  *TrackerDetector
+	source    string       // Tags observations with their input when several are merged; empty otherwise
+	raw       *rawTee      // nil unless -raw-out is set
+	schema    *FieldSchema // nil unless -schema is set
+	notifier  Notifier     // Receives firmware notifications; nil rings the terminal bell
+	notices   *NotificationLog
+	obsLog    *observationLog // nil unless -obs-log is set
+}
 
-	if isPaused {
-		return // Discard when paused
+// processSerialLine processes a single line of JSON
+func (ing *Ingester) processSerialLine(line []byte) {
+	// Other firmwares' field names are translated before decoding
+	decoded := line
+	if ing.schema != nil {
+		remapped, err := ing.schema.Remap(line)
+		if err != nil {
+			logger.Warn("malformed JSON from BLE input", "source", ing.source, "error", err, "line", truncateForLog(line))
+			return
+		}
+		decoded = remapped
 	}
 
 	var msg Message
-	if err := json.Unmarshal(line, &msg); err != nil {
-		return // Silently ignore malformed JSON
+	if err := json.Unmarshal(decoded, &msg); err != nil {
+		// Ignored on screen; the log keeps a copy for diagnosing firmware issues
+		logger.Warn("malformed JSON from BLE input", "source", ing.source, "error", err, "line", truncateForLog(line))
+		return
 	}
 
 	// Handle notification
 	if msg.Notification != nil {
-		// Just beep
-		fmt.Print("\a")
+		if ing.notices != nil {
+			ing.notices.Add(time.Now(), *msg.Notification)
+		}
+		notifier := ing.notifier
+		if notifier == nil {
+			notifier = bellNotifier{}
+		}
+		notifier.Notify(*msg.Notification)
 		return
 	}
 
 	// Handle BLE device
 	if msg.MacAddress != "" {
-		device := &BLEDevice{
-			MacAddress:   msg.MacAddress,
+		// Firmwares disagree on case and separators; one physical device must map to one row
+		mac := normalizeMAC(msg.MacAddress)
+		if mac == "" {
+			logger.Warn("invalid MAC address from BLE input", "source", ing.source, "mac", msg.MacAddress)
+			return
+		}
+		source := msg.Source
+		if source == "" {
+			source = ing.source
+		}
+		ing.ingestDevice(&BLEDevice{
+			MacAddress:   mac,
 			RSSI:         msg.RSSI,
 			DeviceName:   msg.DeviceName,
 			MfrCode:      msg.MfrCode,
 			MfrData:      msg.MfrData,
 			ServiceUUIDs: msg.ServiceUUIDs,
+			ServiceData:  msg.ServiceData,
+			Connectable:  msg.Connectable,
+			Protocol:     msg.Protocol,
+			Source:       source,
 			LastSeen:     time.Now().UTC(),
-			GeoData:      NewRSSILocationMap(),
+		})
+	}
+}
+
+// ingestDevice adds a device observation to the aggregator and tags it with the current GPS location
+func (ing *Ingester) ingestDevice(device *BLEDevice) {
+	agg := ing.agg
+
+	// Add or update the device in the aggregator
+	agg.AddOrUpdate(device)
+
+	// Now push current GPS location to the stored device (after it's been added/updated)
+	currentLoc := ing.locState.GetCurrent()
+	count := 0
+	newTracker := false
+	agg.mu.Lock()
+	if storedDev, exists := agg.devices[device.MacAddress]; exists {
+		// Not while GPS tagging is paused; the fix still goes to the streams below
+		if currentLoc != nil && storedDev.GeoData != nil && !ing.locState.IsGPSPaused() {
+			storedDev.GeoData.Push(device.RSSI, *currentLoc)
+			agg.geoTagged++
+			// Only a new fix can change whether the device is following
+			if ing.trackers != nil {
+				newTracker = ing.trackers.Check(storedDev)
+			}
 		}
+		count = storedDev.Count
+	}
+	agg.mu.Unlock()
 
-		// Add or update the device in the aggregator
-		agg.AddOrUpdate(device)
+	// Every advertisement, unaggregated, for -obs-log
+	if ing.obsLog != nil {
+		ing.obsLog.Write(device.LastSeen, device.MacAddress, device.RSSI, currentLoc)
+	}
 
-		// Now push current GPS location to the stored device (after it's been added/updated)
-		if currentLoc := locState.GetCurrent(); currentLoc != nil {
-			// Get the device from aggregator to push location to the actual stored instance
-			agg.mu.Lock()
-			if storedDev, exists := agg.devices[msg.MacAddress]; exists && storedDev.GeoData != nil {
-				storedDev.GeoData.Push(msg.RSSI, *currentLoc)
-			}
-			agg.mu.Unlock()
+	// Stream the enriched observation to -jsonl-out and WebSocket clients
+	if ing.stream != nil || ing.hub != nil {
+		rec := StreamRecord{
+			Timestamp:    device.LastSeen,
+			MacAddress:   device.MacAddress,
+			RSSI:         device.RSSI,
+			DeviceName:   device.DeviceName,
+			Vendor:       lookupVendor(device.MacAddress),
+			MfrCode:      device.MfrCode,
+			Company:      bluetoothCompanies[device.MfrCode],
+			MfrData:      device.MfrData,
+			ServiceUUIDs: device.ServiceUUIDs,
+			ServiceData:  device.ServiceData,
+			Connectable:  device.Connectable,
+			Protocol:     device.Protocol,
+			Source:       device.Source,
+			Count:        count,
+			Location:     currentLoc,
+		}
+		if ing.stream != nil {
+			ing.stream.Send(rec)
+		}
+		if ing.hub != nil {
+			ing.hub.Broadcast(rec)
 		}
 	}
+
+	// Alert once when a tracker is found following the user
+	if newTracker {
+		playAlertSound()
+	}
+
+	// Alert on watched devices (debounced per MAC)
+	if ing.watchlist != nil && ing.watchlist.ShouldAlert(device.MacAddress, time.Now()) {
+		playAlertSound()
+	}
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextReconnectDelay(t *testing.T) {
+	const maxDelay = 5 * time.Second
+	tests := []struct {
+		name    string
+		current time.Duration
+		want    time.Duration
+	}{
+		{name: "doubles from initial", current: initialReconnectDelay, want: 2 * time.Second},
+		{name: "doubles", current: 2 * time.Second, want: 4 * time.Second},
+		{name: "sub-second doubles", current: 300 * time.Millisecond, want: 600 * time.Millisecond},
+		{name: "capped", current: 4 * time.Second, want: maxDelay},
+		{name: "at cap", current: maxDelay, want: maxDelay},
+		{name: "above cap", current: time.Minute, want: maxDelay},
+		{name: "zero starts at initial", current: 0, want: initialReconnectDelay},
+		{name: "negative starts at initial", current: -3 * time.Second, want: initialReconnectDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextReconnectDelay(tt.current, maxDelay); got != tt.want {
+				t.Errorf("nextReconnectDelay(%v, %v) = %v, want %v", tt.current, maxDelay, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextReconnectDelayGrowsToMax(t *testing.T) {
+	const maxDelay = 30 * time.Second
+	delay := initialReconnectDelay
+	for attempt := 1; attempt <= 10; attempt++ {
+		next := nextReconnectDelay(delay, maxDelay)
+		if next < delay || next > maxDelay {
+			t.Fatalf("attempt %d: %v -> %v, want growth capped at %v", attempt, delay, next, maxDelay)
+		}
+		delay = next
+	}
+	if delay != maxDelay {
+		t.Errorf("delay after 10 failures = %v, want %v", delay, maxDelay)
+	}
+	if got := nextReconnectDelay(initialReconnectDelay, initialReconnectDelay/2); got != initialReconnectDelay/2 {
+		t.Errorf("maxDelay below initial = %v, want %v", got, initialReconnectDelay/2)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bluetoothBaseUUIDSuffix is the tail of the Bluetooth Base UUID that 16-bit assigned numbers expand into
+const bluetoothBaseUUIDSuffix = "-0000-1000-8000-00805f9b34fb"
+
+// bluetoothServices maps 16-bit Bluetooth SIG service UUIDs to names
+// Source: Bluetooth SIG Assigned Numbers, "GATT Services" and "Member UUIDs" (trimmed to services seen in the field)
+var bluetoothServices = map[uint16]string{
+	0x1800: "Generic Access",
+	0x1801: "Generic Attribute",
+	0x1802: "Immediate Alert",
+	0x1803: "Link Loss",
+	0x1804: "Tx Power",
+	0x1805: "Current Time",
+	0x1806: "Reference Time Update",
+	0x1807: "Next DST Change",
+	0x1808: "Glucose",
+	0x1809: "Health Thermometer",
+	0x180A: "Device Information",
+	0x180D: "Heart Rate",
+	0x180E: "Phone Alert Status",
+	0x180F: "Battery Service",
+	0x1810: "Blood Pressure",
+	0x1811: "Alert Notification",
+	0x1812: "Human Interface Device",
+	0x1813: "Scan Parameters",
+	0x1814: "Running Speed and Cadence",
+	0x1815: "Automation IO",
+	0x1816: "Cycling Speed and Cadence",
+	0x1818: "Cycling Power",
+	0x1819: "Location and Navigation",
+	0x181A: "Environmental Sensing",
+	0x181B: "Body Composition",
+	0x181C: "User Data",
+	0x181D: "Weight Scale",
+	0x181E: "Bond Management",
+	0x181F: "Continuous Glucose Monitoring",
+	0x1820: "Internet Protocol Support",
+	0x1821: "Indoor Positioning",
+	0x1822: "Pulse Oximeter",
+	0x1823: "HTTP Proxy",
+	0x1824: "Transport Discovery",
+	0x1825: "Object Transfer",
+	0x1826: "Fitness Machine",
+	0x1827: "Mesh Provisioning",
+	0x1828: "Mesh Proxy",
+	0x183B: "Binary Sensor",
+	0x183E: "Physical Activity Monitor",
+	0x1843: "Audio Input Control",
+	0x1844: "Volume Control",
+	0x1848: "Media Control",
+	0x184E: "Audio Stream Control",
+	0x184F: "Broadcast Audio Scan",
+	0x1850: "Published Audio Capabilities",
+	0x1851: "Basic Audio Announcement",
+	0x1852: "Broadcast Audio Announcement",
+	0x1853: "Common Audio",
+	0x1854: "Hearing Access",
+	0xFD5A: "Samsung SmartTag",
+	0xFD44: "Find My Network",
+	0xFD6F: "Exposure Notification",
+	0xFE07: "Sonos",
+	0xFE2C: "Google Fast Pair",
+	0xFE59: "Nordic Secure DFU",
+	0xFE95: "Xiaomi",
+	0xFE9F: "Google",
+	0xFEAA: "Eddystone",
+	0xFEB9: "LG Electronics",
+	0xFEBE: "Bose",
+	0xFEC7: "Apple",
+	0xFED8: "Google",
+	0xFEED: "Tile",
+	0xFEEC: "Tile",
+	0xFEF3: "Google",
+	0xFEFD: "Gimbal",
+}
+
+// vendorServices maps well-known 128-bit vendor service UUIDs (lowercase) to names
+var vendorServices = map[string]string{
+	"6e400001-b5a3-f393-e0a9-e50e24dcca9e": "Nordic UART",
+}
+
+// shortServiceUUID extracts the 16-bit value from a short ("180f", "0x180F") or Bluetooth Base UUID form
+// Returns false for other 128-bit UUIDs
+func shortServiceUUID(uuid string) (uint16, bool) {
+	u := strings.ToLower(strings.TrimSpace(uuid))
+	u = strings.TrimPrefix(u, "0x")
+	if len(u) == 36 && strings.HasPrefix(u, "0000") && strings.HasSuffix(u, bluetoothBaseUUIDSuffix) {
+		u = u[4:8]
+	}
+	if len(u) != 4 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(u, 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(value), true
+}
+
+// shortUUID collapses a Bluetooth Base UUID to its 16-bit form, e.g. "0x180F"
+// Other 128-bit UUIDs are returned unchanged
+func shortUUID(uuid string) string {
+	if short, ok := shortServiceUUID(uuid); ok {
+		return fmt.Sprintf("0x%04X", short)
+	}
+	return uuid
+}
+
+// lookupServiceName resolves a service UUID to its assigned or well-known name
+func lookupServiceName(uuid string) (string, bool) {
+	if short, ok := shortServiceUUID(uuid); ok {
+		name, ok := bluetoothServices[short]
+		return name, ok
+	}
+	name, ok := vendorServices[strings.ToLower(strings.TrimSpace(uuid))]
+	return name, ok
+}
+
+// formatServiceUUID formats a service UUID with its name when known, e.g. "180f (Battery Service)"
+func formatServiceUUID(uuid string) string {
+	if name, ok := lookupServiceName(uuid); ok {
+		return fmt.Sprintf("%s (%s)", uuid, name)
+	}
+	return uuid
+}
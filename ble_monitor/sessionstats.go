@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionStats tracks how long the current capture has run; the peak device count is kept by the Aggregator
+// It is read by whichever loop prints the status (TUI or headless) and reset only by the TUI's clear and
+// undo, all on that one goroutine, so it needs no locking
+type SessionStats struct {
+	start        time.Time
+	clearedStart time.Time // start before the last Reset, brought back by Restore
+}
+
+// NewSessionStats starts a session at start
+func NewSessionStats(start time.Time) *SessionStats {
+	return &SessionStats{start: start}
+}
+
+// Elapsed returns how long the session has run as of now
+func (s *SessionStats) Elapsed(now time.Time) time.Duration {
+	return now.Sub(s.start)
+}
+
+// Reset starts a new session at now, used when the aggregator is cleared
+func (s *SessionStats) Reset(now time.Time) {
+	s.clearedStart, s.start = s.start, now
+}
+
+// Restore returns to the session in progress before the last Reset, used when a clear is undone
+func (s *SessionStats) Restore() {
+	if !s.clearedStart.IsZero() {
+		s.start, s.clearedStart = s.clearedStart, time.Time{}
+	}
+}
+
+// formatElapsed formats a duration as hh:mm:ss; hours are not wrapped at 24
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	secs := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
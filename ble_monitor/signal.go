@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// rssiBand is one signal-strength tier shared by the TUI signal column and KML styling
+type rssiBand struct {
+	threshold int    // RSSI must be above this to fall in the band; ignored for the last band
+	bars      int    // Filled blocks in the signal indicator
+	color     int    // Index into theme.SignalRamp
+	styleID   string // KML Style id (without the leading #)
+	kmlColor  color.RGBA
+}
+
+// rssiBands lists the tiers strongest first; the last band catches everything weaker
+// Thresholds can be overridden with -rssi-bands
+var rssiBands = []rssiBand{
+	{threshold: -50, bars: 7, color: 0, styleID: "rssi-blue", kmlColor: color.RGBA{B: 0xff, A: 0xff}},            // Excellent
+	{threshold: -60, bars: 5, color: 1, styleID: "rssi-green", kmlColor: color.RGBA{G: 0xff, A: 0xff}},           // Good
+	{threshold: -70, bars: 3, color: 2, styleID: "rssi-yellow", kmlColor: color.RGBA{R: 0xff, G: 0xff, A: 0xff}}, // Fair
+	{threshold: -80, bars: 2, color: 3, styleID: "rssi-orange", kmlColor: color.RGBA{R: 0xff, G: 0x80, A: 0xff}}, // Poor
+	{bars: 1, color: 4, styleID: "rssi-red", kmlColor: color.RGBA{R: 0xff, A: 0xff}},                             // Very poor
+}
+
+// bandForRSSI returns the strongest band whose threshold rssi exceeds
+func bandForRSSI(rssi int) rssiBand {
+	for _, band := range rssiBands[:len(rssiBands)-1] {
+		if rssi > band.threshold {
+			return band
+		}
+	}
+	return rssiBands[len(rssiBands)-1]
+}
+
+// setRSSIThresholds replaces the band thresholds from a comma-separated list, strongest first (e.g. "-50,-60,-70,-80")
+func setRSSIThresholds(spec string) error {
+	parts := strings.Split(spec, ",")
+	if len(parts) != len(rssiBands)-1 {
+		return fmt.Errorf("expected %d comma-separated thresholds, got %d", len(rssiBands)-1, len(parts))
+	}
+
+	thresholds := make([]int, len(parts))
+	for i, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return fmt.Errorf("invalid threshold %q", part)
+		}
+		if i > 0 && value >= thresholds[i-1] {
+			return fmt.Errorf("thresholds must be strictly decreasing (%d follows %d)", value, thresholds[i-1])
+		}
+		thresholds[i] = value
+	}
+
+	for i, value := range thresholds {
+		rssiBands[i].threshold = value
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKey selects the device field a table is ordered by
+type SortKey int
+
+const (
+	SortByMAC SortKey = iota
+	SortByRSSI
+	SortByLastSeen
+	SortByCount
+	SortByName
+	SortByDistance
+	sortKeyCount // Number of sort keys (for cycling)
+)
+
+// sortKeyNames are the display labels for each SortKey
+var sortKeyNames = []string{"MAC", "RSSI", "Last Seen", "Count", "Name", "Distance"}
+
+// SortOrder is a sort key plus direction
+type SortOrder struct {
+	Key        SortKey
+	Descending bool
+	Origin     *GeoLocation // Where SortByDistance measures from; set at query time by withOrigin
+}
+
+// Default orderings: recent devices alphabetically, stale devices most recently seen first
+var (
+	defaultRecentSort = SortOrder{Key: SortByMAC}
+	defaultStaleSort  = SortOrder{Key: SortByLastSeen, Descending: true}
+)
+
+// String formats the order for table titles, e.g. "RSSI ↓"
+func (o SortOrder) String() string {
+	arrow := "↑"
+	if o.Descending {
+		arrow = "↓"
+	}
+	return sortKeyNames[o.Key] + " " + arrow
+}
+
+// Next returns the order for the next sort key, using that key's natural direction
+func (o SortOrder) Next() SortOrder {
+	return naturalOrder((o.Key + 1) % sortKeyCount)
+}
+
+// naturalOrder returns key in its natural direction
+// Numeric and time keys default to descending (strongest/newest/most first), text keys and distance to ascending
+func naturalOrder(key SortKey) SortOrder {
+	return SortOrder{
+		Key:        key,
+		Descending: key == SortByRSSI || key == SortByLastSeen || key == SortByCount,
+	}
+}
+
+// parseSortOrder parses a sort key name for -stale-sort (e.g. "rssi" or "last-seen") in its natural direction
+// Case, spaces and dashes are ignored, so the table labels ("Last Seen") work too
+func parseSortOrder(name string) (SortOrder, error) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s))
+	}
+	for key, label := range sortKeyNames {
+		if normalize(label) == normalize(name) {
+			return naturalOrder(SortKey(key)), nil
+		}
+	}
+	return SortOrder{}, fmt.Errorf("unknown sort key %q (want mac, rssi, last-seen, count, name or distance)", name)
+}
+
+// Reversed returns the same key with the opposite direction
+func (o SortOrder) Reversed() SortOrder {
+	return SortOrder{Key: o.Key, Descending: !o.Descending}
+}
+
+// withOrigin returns the order with the reference point for SortByDistance
+// Other keys are returned unchanged so the current fix doesn't defeat the stale-order cache
+func (o SortOrder) withOrigin(origin *GeoLocation) SortOrder {
+	if o.Key == SortByDistance {
+		o.Origin = origin
+	}
+	return o
+}
+
+// deviceDistance returns the meters from origin to the device's location, or -1 if either is unknown
+func deviceDistance(dev *BLEDevice, origin *GeoLocation) float64 {
+	if origin == nil || dev.GeoData == nil {
+		return -1
+	}
+	loc := dev.GeoData.GetLocation()
+	if loc == nil {
+		return -1
+	}
+	return haversineMeters(*origin, *loc)
+}
+
+// sortDevices sorts devices in place by the given order
+// Ties always fall back to MAC ascending so rows don't jitter between refreshes
+func sortDevices(devices []*BLEDevice, order SortOrder) {
+	// Pre-compute truncated times to avoid repeated Truncate() calls and sub-second reordering
+	// Distances are likewise computed once, as each needs the device's geo lock
+	type sortEntry struct {
+		dev       *BLEDevice
+		truncTime time.Time
+		distance  float64
+	}
+	entries := make([]sortEntry, len(devices))
+	for i, dev := range devices {
+		entries[i] = sortEntry{
+			dev:       dev,
+			truncTime: dev.LastSeen.Truncate(time.Second),
+		}
+		if order.Key == SortByDistance {
+			entries[i].distance = deviceDistance(dev, order.Origin)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+
+		cmp := 0
+		switch order.Key {
+		case SortByRSSI:
+			cmp = a.dev.RSSI - b.dev.RSSI
+		case SortByLastSeen:
+			cmp = a.truncTime.Compare(b.truncTime)
+		case SortByCount:
+			cmp = a.dev.Count - b.dev.Count
+		case SortByName:
+			// Unnamed devices always sort after named ones
+			switch {
+			case a.dev.DeviceName == "" && b.dev.DeviceName != "":
+				return false
+			case a.dev.DeviceName != "" && b.dev.DeviceName == "":
+				return true
+			}
+			cmp = strings.Compare(strings.ToLower(a.dev.DeviceName), strings.ToLower(b.dev.DeviceName))
+		case SortByDistance:
+			// Devices without a known distance always sort after the rest
+			switch {
+			case a.distance < 0 && b.distance >= 0:
+				return false
+			case a.distance >= 0 && b.distance < 0:
+				return true
+			}
+			switch {
+			case a.distance < b.distance:
+				cmp = -1
+			case a.distance > b.distance:
+				cmp = 1
+			}
+		}
+		if order.Descending {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp < 0
+		}
+
+		// MAC tie-break (or primary key for SortByMAC)
+		if order.Key == SortByMAC && order.Descending {
+			return a.dev.MacAddress > b.dev.MacAddress
+		}
+		return a.dev.MacAddress < b.dev.MacAddress
+	})
+
+	for i, e := range entries {
+		devices[i] = e.dev
+	}
+}
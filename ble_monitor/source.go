@@ -0,0 +1,38 @@
+package main
+
+import "strings"
+
+// DeviceSource feeds device observations into the ingester until done is closed
+// Implementations own their connection lifecycle and report it through connState
+type DeviceSource interface {
+	Run(ing *Ingester, connState *ConnectionState, done <-chan struct{})
+}
+
+// serialSource reads firmware JSON lines from a serial port (or stdin when portPath is empty)
+type serialSource struct {
+	portPath string
+	baudRate int
+}
+
+// Run reads from the serial port with automatic reconnection
+func (src *serialSource) Run(ing *Ingester, connState *ConnectionState, done <-chan struct{}) {
+	readSerial(src.portPath, src.baudRate, ing, connState, done)
+}
+
+// portList collects -port values; the flag may be repeated or given a comma-separated list
+type portList []string
+
+// String returns the ports as a comma-separated list
+func (p *portList) String() string {
+	return strings.Join(*p, ",")
+}
+
+// Set adds each non-empty comma-separated port in value
+func (p *portList) Set(value string) error {
+	for _, port := range strings.Split(value, ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			*p = append(*p, port)
+		}
+	}
+	return nil
+}
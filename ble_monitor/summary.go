@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Manufacturers listed in the exit summary
+const summaryTopMfrs = 5
+
+// Summary returns a short plain-text report of the session's devices, printed when the program exits
+func (a *Aggregator) Summary() string {
+	sorted := a.GetSnapshotBy(defaultRecentSort, defaultStaleSort)
+	devices := sorted.All()
+
+	a.mu.RLock()
+	observations, geoTagged := a.observations, a.geoTagged
+	a.mu.RUnlock()
+
+	var b strings.Builder
+	named := 0
+	var strongest *BLEDevice
+	for _, dev := range devices {
+		if dev.DeviceName != "" {
+			named++
+		}
+		if strongest == nil || dev.RSSIMax > strongest.RSSIMax {
+			strongest = dev
+		}
+	}
+	fmt.Fprintf(&b, "Devices: %d unique (%d named, %d unnamed)\n", len(devices), named, len(devices)-named)
+
+	if codes := countMfrCodes(sorted); len(codes) > 0 {
+		var parts []string
+		for _, c := range codes[:min(summaryTopMfrs, len(codes))] {
+			name := "(none)"
+			if c.Code != 0 {
+				name = lookupCompany(c.Code)
+			}
+			parts = append(parts, fmt.Sprintf("%s %d", name, c.Count))
+		}
+		fmt.Fprintf(&b, "Top manufacturers: %s\n", strings.Join(parts, ", "))
+	}
+
+	if strongest != nil {
+		label := strongest.MacAddress
+		if strongest.DeviceName != "" {
+			label += " (" + strongest.DeviceName + ")"
+		}
+		fmt.Fprintf(&b, "Strongest: %s at %d dBm\n", label, strongest.RSSIMax)
+	}
+
+	if observations > 0 {
+		fmt.Fprintf(&b, "GPS fix: %.0f%% of %d observations\n", 100*float64(geoTagged)/float64(observations), observations)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ModalTheme holds the styles for one kind of modal
+type ModalTheme struct {
+	Border tcell.Style // Frame and title
+	Body   tcell.Style // Background and text
+}
+
+// Theme centralizes every color and style used by the TUI
+type Theme struct {
+	Base tcell.Style // Screen background and default text
+
+	Status           tcell.Style
+	TitleFocused     tcell.Style
+	TitleUnfocused   tcell.Style
+	TitleSnapshot    tcell.Style // Table titles in snapshot mode
+	Header           tcell.Style
+	ScrollIndicator  tcell.Style
+	Row              tcell.Style // Normal table row
+	RowSelected      tcell.Style // Cursor row in the focused table
+	WatchedColor     tcell.Color // Foreground for watchlisted devices
+	TrackerColor     tcell.Color // Foreground for suspected trackers following the user
+	AgeWarningColor  tcell.Color // Last Seen > 40% of the stale window
+	AgeCautionColor  tcell.Color // Last Seen > 60% of the stale window
+	AgeCriticalColor tcell.Color // Last Seen > 80% of the stale window
+
+	// Signal indicator colors from strongest to weakest (excellent, good, fair, poor, very poor)
+	SignalRamp [5]tcell.Color
+
+	// Row backgrounds per class group (see classGroups) when class colors are on
+	// Kept dark (or pale) so the age and watch foreground colors stay readable
+	ClassTints map[string]tcell.Color
+
+	// Row backgrounds alternated between devices with -stripes; the first is normally Row's own
+	RowStripes [2]tcell.Color
+
+	// Proximity view trend colors
+	CloserColor  tcell.Color
+	FartherColor tcell.Color
+	SteadyColor  tcell.Color
+	LostColor    tcell.Color
+
+	// Modals
+	Dim            tcell.Style // Dimmed background behind the disconnection modal
+	Button         tcell.Style
+	ButtonSelected tcell.Style
+	Input          tcell.Style
+	ListSelected   tcell.Style
+	Disconnect     ModalTheme
+	GPSFailure     ModalTheme
+	GPSReconnect   ModalTheme
+	Export         ModalTheme
+	Detail         ModalTheme
+	Watch          ModalTheme
+	Clear          ModalTheme
+}
+
+// modal builds a ModalTheme with a bold border in the given colors
+func modal(fg, bg tcell.Color) ModalTheme {
+	body := tcell.StyleDefault.Foreground(fg).Background(bg)
+	return ModalTheme{Border: body.Bold(true), Body: body}
+}
+
+// Class group row tints for the dark and light themes
+var (
+	darkClassTints = map[string]tcell.Color{
+		groupTracker:    tcell.NewRGBColor(58, 18, 18),
+		groupBeacon:     tcell.NewRGBColor(16, 24, 64),
+		groupWearable:   tcell.NewRGBColor(16, 48, 24),
+		groupPhone:      tcell.NewRGBColor(40, 20, 56),
+		groupPeripheral: tcell.NewRGBColor(48, 40, 12),
+	}
+	lightClassTints = map[string]tcell.Color{
+		groupTracker:    tcell.NewRGBColor(255, 228, 228),
+		groupBeacon:     tcell.NewRGBColor(228, 236, 255),
+		groupWearable:   tcell.NewRGBColor(228, 248, 228),
+		groupPhone:      tcell.NewRGBColor(244, 232, 255),
+		groupPeripheral: tcell.NewRGBColor(255, 248, 220),
+	}
+)
+
+// darkTheme is the original color scheme: light text on a black background
+var darkTheme = &Theme{
+	Base:             tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack),
+	Status:           tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkSlateGray),
+	TitleFocused:     tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkGreen).Bold(true),
+	TitleUnfocused:   tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkSlateGray).Bold(true),
+	TitleSnapshot:    tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkMagenta).Bold(true),
+	Header:           tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorNavy).Bold(true),
+	ScrollIndicator:  tcell.StyleDefault.Foreground(tcell.ColorYellow).Background(tcell.ColorBlack),
+	Row:              tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack),
+	RowSelected:      tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkBlue),
+	WatchedColor:     tcell.ColorFuchsia,
+	TrackerColor:     tcell.ColorRed,
+	AgeWarningColor:  tcell.ColorYellow,
+	AgeCautionColor:  tcell.ColorOrange,
+	AgeCriticalColor: tcell.ColorRed,
+	SignalRamp:       [5]tcell.Color{tcell.ColorBlue, tcell.ColorGreen, tcell.ColorYellow, tcell.ColorOrange, tcell.ColorRed},
+	ClassTints:       darkClassTints,
+	RowStripes:       [2]tcell.Color{tcell.ColorBlack, tcell.NewRGBColor(30, 30, 30)},
+	CloserColor:      tcell.ColorGreen,
+	FartherColor:     tcell.ColorRed,
+	SteadyColor:      tcell.ColorYellow,
+	LostColor:        tcell.ColorGray,
+	Dim:              tcell.StyleDefault.Foreground(tcell.ColorGray).Background(tcell.ColorBlack),
+	Button:           tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite),
+	ButtonSelected:   tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorGreen).Bold(true),
+	Input:            tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite),
+	ListSelected:     tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow).Bold(true),
+	Disconnect: ModalTheme{
+		Border: tcell.StyleDefault.Foreground(tcell.ColorRed).Background(tcell.ColorBlack).Bold(true),
+		Body:   tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkRed),
+	},
+	GPSFailure:   modal(tcell.ColorBlack, tcell.ColorYellow),
+	GPSReconnect: modal(tcell.ColorBlack, tcell.ColorOrange),
+	Export:       modal(tcell.ColorWhite, tcell.ColorBlue),
+	Detail:       modal(tcell.ColorWhite, tcell.ColorDarkCyan),
+	Watch:        modal(tcell.ColorWhite, tcell.ColorDarkMagenta),
+	Clear:        modal(tcell.ColorWhite, tcell.ColorDarkRed),
+}
+
+// lightTheme is for light terminals: dark text on white, with darker accent colors
+var lightTheme = &Theme{
+	Base:             tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite),
+	Status:           tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorLightGray),
+	TitleFocused:     tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkGreen).Bold(true),
+	TitleUnfocused:   tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorLightGray).Bold(true),
+	TitleSnapshot:    tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorPurple).Bold(true),
+	Header:           tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorNavy).Bold(true),
+	ScrollIndicator:  tcell.StyleDefault.Foreground(tcell.ColorDarkRed).Background(tcell.ColorWhite).Bold(true),
+	Row:              tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite),
+	RowSelected:      tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorLightSkyBlue),
+	WatchedColor:     tcell.ColorDarkMagenta,
+	TrackerColor:     tcell.ColorDarkRed,
+	AgeWarningColor:  tcell.ColorOlive,
+	AgeCautionColor:  tcell.ColorDarkOrange,
+	AgeCriticalColor: tcell.ColorDarkRed,
+	SignalRamp:       [5]tcell.Color{tcell.ColorNavy, tcell.ColorDarkGreen, tcell.ColorOlive, tcell.ColorDarkOrange, tcell.ColorDarkRed},
+	ClassTints:       lightClassTints,
+	RowStripes:       [2]tcell.Color{tcell.ColorWhite, tcell.NewRGBColor(236, 236, 236)},
+	CloserColor:      tcell.ColorDarkGreen,
+	FartherColor:     tcell.ColorDarkRed,
+	SteadyColor:      tcell.ColorOlive,
+	LostColor:        tcell.ColorGray,
+	Dim:              tcell.StyleDefault.Foreground(tcell.ColorGray).Background(tcell.ColorLightGray),
+	Button:           tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack),
+	ButtonSelected:   tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkGreen).Bold(true),
+	Input:            tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite),
+	ListSelected:     tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack).Bold(true),
+	Disconnect:       modal(tcell.ColorWhite, tcell.ColorDarkRed),
+	GPSFailure:       modal(tcell.ColorBlack, tcell.ColorKhaki),
+	GPSReconnect:     modal(tcell.ColorBlack, tcell.ColorSandyBrown),
+	Export:           modal(tcell.ColorBlack, tcell.ColorLightSteelBlue),
+	Detail:           modal(tcell.ColorBlack, tcell.ColorLightCyan),
+	Watch:            modal(tcell.ColorBlack, tcell.ColorThistle),
+	Clear:            modal(tcell.ColorWhite, tcell.ColorDarkRed),
+}
+
+// monoTheme uses only the terminal's default colors plus reverse/bold/underline,
+// so nothing depends on telling colors apart
+var monoTheme = func() *Theme {
+	plain := tcell.StyleDefault
+	reverse := plain.Reverse(true)
+	def := tcell.ColorDefault
+	inverse := ModalTheme{Border: reverse.Bold(true), Body: reverse}
+	return &Theme{
+		Base:             plain,
+		Status:           reverse,
+		TitleFocused:     reverse.Bold(true),
+		TitleUnfocused:   plain.Bold(true).Underline(true),
+		TitleSnapshot:    reverse.Bold(true).Underline(true),
+		Header:           plain.Bold(true).Underline(true),
+		ScrollIndicator:  plain.Bold(true),
+		Row:              plain,
+		RowSelected:      reverse,
+		WatchedColor:     def,
+		TrackerColor:     def,
+		AgeWarningColor:  def,
+		AgeCautionColor:  def,
+		AgeCriticalColor: def,
+		SignalRamp:       [5]tcell.Color{def, def, def, def, def},
+		RowStripes:       [2]tcell.Color{def, def}, // No shade to alternate with
+		CloserColor:      def,
+		FartherColor:     def,
+		SteadyColor:      def,
+		LostColor:        def,
+		Dim:              plain.Dim(true),
+		Button:           plain,
+		ButtonSelected:   plain.Bold(true).Underline(true),
+		Input:            plain.Underline(true),
+		ListSelected:     plain.Bold(true).Underline(true),
+		Disconnect:       inverse,
+		GPSFailure:       inverse,
+		GPSReconnect:     inverse,
+		Export:           inverse,
+		Detail:           inverse,
+		Watch:            inverse,
+		Clear:            inverse,
+	}
+}()
+
+// themes maps -theme flag values to themes
+var themes = map[string]*Theme{
+	"dark":  darkTheme,
+	"light": lightTheme,
+	"mono":  monoTheme,
+}
+
+// theme is the active theme used by all drawing code
+var theme = darkTheme
+
+// themeNames returns the available theme names in sorted order
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// classTint returns the row background for a device class, or false when it has none
+// The mono theme defines no tints, so rows there stay plain
+func (t *Theme) classTint(class string) (tcell.Color, bool) {
+	tint, ok := t.ClassTints[classGroups[class]]
+	return tint, ok
+}
+
+// rowStyle returns the style for a table row, honoring selection, watchlist and tracker highlighting
+// Suspected trackers are also underlined so they stand out without color
+func (t *Theme) rowStyle(selected, watched, tracker bool) tcell.Style {
+	style := t.Row
+	if selected {
+		style = t.RowSelected
+	}
+	if tracker {
+		return style.Foreground(t.TrackerColor).Bold(true).Underline(true)
+	}
+	if watched {
+		style = style.Foreground(t.WatchedColor).Bold(true)
+	}
+	return style
+}
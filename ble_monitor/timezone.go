@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layouts for human-facing times; the export one names the zone since it is read without the status line
+const (
+	displayTimeLayout = "2006-01-02 15:04:05"
+	exportTimeLayout  = "2006-01-02 15:04:05 MST"
+)
+
+// displayZone is the zone human-facing times are shown in (-tz)
+// Stored and exported values (JSON, GPX timestamps, -obs-log) stay in UTC
+var displayZone = time.UTC
+
+// setDisplayZone selects the display zone: utc, local, or an IANA name such as Europe/Berlin
+func setDisplayZone(name string) error {
+	switch strings.ToLower(name) {
+	case "", "utc":
+		displayZone = time.UTC
+		return nil
+	case "local":
+		displayZone = time.Local
+		return nil
+	}
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("unknown time zone %q (want utc, local or an IANA name like Europe/Berlin)", name)
+	}
+	displayZone = zone
+	return nil
+}
+
+// formatDisplayTime formats t in the display zone
+func formatDisplayTime(t time.Time, layout string) string {
+	return t.In(displayZone).Format(layout)
+}
+
+// displayZoneName returns the display zone's abbreviation at t, e.g. "UTC" or "CEST", for the status line
+func displayZoneName(t time.Time) string {
+	name, _ := t.In(displayZone).Zone()
+	return name
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Thresholds for deciding that a tracker-like device is following the user
+const (
+	trackerClusterMeters   = 50              // Fixes closer than this to a kept position count as the same place
+	trackerMinPlaces       = 3               // Distinct places the device must have been seen at
+	trackerMinSpreadMeters = 200             // Distance between the farthest two places
+	trackerMinDuration     = 5 * time.Minute // Time between the first and last sighting with a fix
+)
+
+// isTrackerSignature reports whether a device advertises like a location tracker
+// Matches AirTags and other Find My accessories, Tile and Samsung SmartTag
+func isTrackerSignature(dev *BLEDevice) bool {
+	switch classifyDevice(dev) {
+	case "AirTag-like", "Tile tracker", "SmartTag":
+		return true
+	}
+	return hasService(dev, 0xFD44) // Find My network accessory
+}
+
+// isFollowing reports whether a device was seen at several distinct GPS positions spread over time,
+// meaning it moved along with the receiver rather than being passed once
+func isFollowing(dev *BLEDevice) bool {
+	if dev.GeoData == nil {
+		return false
+	}
+	locations := dev.GeoData.GetAllLocations() // Chronological
+	if len(locations) < trackerMinPlaces {
+		return false
+	}
+	if locations[len(locations)-1].Timestamp.Sub(locations[0].Timestamp) < trackerMinDuration {
+		return false
+	}
+
+	// Collapse the fixes into distinct places so lingering at one spot counts once
+	var places []GeoLocation
+	for _, loc := range locations {
+		near := false
+		for _, place := range places {
+			if haversineMeters(place, loc) < trackerClusterMeters {
+				near = true
+				break
+			}
+		}
+		if !near {
+			places = append(places, loc)
+		}
+	}
+	return len(places) >= trackerMinPlaces && spreadMeters(places) >= trackerMinSpreadMeters
+}
+
+// TrackerDetector flags tracker-like devices that appear to follow the user
+//
+// This is a heuristic and has known false positives: a travelling companion's AirTag or
+// Tile (or a lost Apple device in Find My mode) moves with you just like an unwanted one,
+// and a tracker in a vehicle that happens to share your route for a while will also match.
+// Trackers that rotate their MAC address are seen as several shorter-lived devices, so an
+// unwanted tracker may take longer to flag, or not be flagged at all. Without a GPS fix
+// nothing is ever flagged.
+type TrackerDetector struct {
+	mu      sync.RWMutex
+	flagged map[string]bool
+}
+
+// NewTrackerDetector creates a detector with nothing flagged
+func NewTrackerDetector() *TrackerDetector {
+	return &TrackerDetector{flagged: make(map[string]bool)}
+}
+
+// Check evaluates a device and returns true the first time it is flagged as following the user
+func (td *TrackerDetector) Check(dev *BLEDevice) bool {
+	if td.IsFlagged(dev.MacAddress) || !isTrackerSignature(dev) || !isFollowing(dev) {
+		return false
+	}
+
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	if td.flagged[dev.MacAddress] {
+		return false
+	}
+	td.flagged[dev.MacAddress] = true
+	return true
+}
+
+// Count returns how many devices have been flagged
+func (td *TrackerDetector) Count() int {
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+	return len(td.flagged)
+}
+
+// IsFlagged reports whether the device has been flagged as a suspected tracker
+func (td *TrackerDetector) IsFlagged(mac string) bool {
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+	return td.flagged[mac]
+}
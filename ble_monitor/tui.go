@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/adrianmo/go-nmea"
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -12,31 +15,100 @@ const (
 	colWidthLastSeen     = 21 // "YYYY-MM-DD hh:mm:ss" + padding
 	colWidthCount        = 7  // Observation count
 	colWidthMAC          = 19
+	colWidthConnectable  = 3 // Connectable flag
 	colWidthSignal       = 9 // Signal strength indicator
 	colWidthRSSI         = 6
 	colWidthLocation     = 27 // Location (lat, lon) with 5 decimal places
+	colWidthDistance     = 10 // Distance from the current GPS fix to the device's location
+	colWidthElevation    = 9  // Altitude of the device's location, e.g. "12345 ft"
 	colWidthName         = 30
+	colWidthVendor       = 24 // OUI vendor resolved from the MAC prefix
+	colWidthClass        = 17 // Device class guessed by classifyDevice
 	colWidthServiceUUIDs = 38 // Fixed width, moved between Name and MfrCode
 	colWidthMfrCode      = 8
+	colWidthMfrDataMin   = 24 // Mfr Data takes the remaining width, but never less than this
+
+	// maxStatusNoticeRunes caps the latest notification shown in the status line, for statusNoticeDuration
+	maxStatusNoticeRunes = 40
+	statusNoticeDuration = 30 * time.Second
+
+	// statusHelpHint is kept at the right of the status line on terminals at least minHelpHintWidth wide
+	statusHelpHint   = " ?: Help "
+	minHelpHintWidth = 40
+
+	// compactLayoutWidth is the terminal width below which low-priority columns are hidden
+	compactLayoutWidth = 80
 )
 
 // TableState tracks scrolling and focus state for the tables
 type TableState struct {
 	nearScrollOffset int
 	farScrollOffset  int
+	nearSelected     int    // Cursor row index in the recent table
+	farSelected      int    // Cursor row index in the stale table
 	focusedTable     string // "near" or "far"
+	nearSort         SortOrder
+	farSort          SortOrder
+	relativeAge      bool            // Show Last Seen as "3s ago" instead of a timestamp
+	singleTable      bool            // Show only the focused table, using the full height (Tab switches which)
+	fullUUIDs        bool            // Show standard service UUIDs in 128-bit form instead of "0x180F"
+	imperial         bool            // Show distances in feet and miles
+	classColors      bool            // Tint rows by device class group
+	stripes          bool            // Shade alternate device rows
+	classLegend      bool            // Show the class color legend
+	colOffset        int             // Leading columns scrolled off the left edge
+	hiddenColumns    map[string]bool // Column keys turned off with -hide-columns
+	nearLayout       tableLayout
+	farLayout        tableLayout
+}
+
+// tableLayout records where a table was last drawn so mouse clicks can be mapped back to devices
+type tableLayout struct {
+	top, bottom int       // Screen rows covered by the table including title and header (bottom exclusive)
+	rows        []rowSpan // Device rows as drawn, top to bottom
+}
+
+// rowSpan is one drawn device row, which spans several lines when it has multiple service UUIDs
+type rowSpan struct {
+	y, lines, index int
+}
+
+// contains reports whether screen row y falls within the table
+func (l *tableLayout) contains(y int) bool {
+	return y >= l.top && y < l.bottom
+}
+
+// deviceAt returns the index of the device drawn at screen row y, or -1 for titles, headers and blank rows
+func (l *tableLayout) deviceAt(y int) int {
+	for _, span := range l.rows {
+		if y >= span.y && y < span.y+span.lines {
+			return span.index
+		}
+	}
+	return -1
 }
 
+// Number of formats offered by the export modal
+const exportOptionCount = 4
+
 // ExportModalState tracks the export modal state
 type ExportModalState struct {
 	showing        bool
-	selectedOption int // 0 = JSON, 1 = KML
+	selectedOption int  // 0 = JSON, 1 = KML, 2 = Heatmap, 3 = KMZ
+	filtered       bool // Export only the devices passing the display filter
 }
 
 // ShowExportModal displays the export modal
-func (e *ExportModalState) Show() {
+// The scope defaults to the filtered devices whenever a filter is active
+func (e *ExportModalState) Show(filterActive bool) {
 	e.showing = true
 	e.selectedOption = 0 // Default to JSON
+	e.filtered = filterActive
+}
+
+// ToggleScope switches between exporting all devices and only the filtered ones
+func (e *ExportModalState) ToggleScope() {
+	e.filtered = !e.filtered
 }
 
 // Hide hides the export modal
@@ -51,37 +123,203 @@ func (e *ExportModalState) IsShowing() bool {
 
 // SelectNext moves selection to next option (with wrap)
 func (e *ExportModalState) SelectNext() {
-	e.selectedOption = (e.selectedOption + 1) % 2
+	e.selectedOption = (e.selectedOption + 1) % exportOptionCount
 }
 
 // SelectPrev moves selection to previous option (with wrap)
 func (e *ExportModalState) SelectPrev() {
-	e.selectedOption = (e.selectedOption - 1 + 2) % 2
+	e.selectedOption = (e.selectedOption - 1 + exportOptionCount) % exportOptionCount
 }
 
-// GetSelected returns the currently selected option (0 = JSON, 1 = KML)
+// GetSelected returns the currently selected option (0 = JSON, 1 = KML, 2 = Heatmap)
 func (e *ExportModalState) GetSelected() int {
 	return e.selectedOption
 }
 
+// DetailModalState tracks the device detail modal state
+type DetailModalState struct {
+	showing      bool
+	mac          string // MAC address of the device being inspected
+	scrollOffset int
+}
+
+// Show displays the detail modal for the given device
+func (d *DetailModalState) Show(mac string) {
+	d.showing = true
+	d.mac = mac
+	d.scrollOffset = 0
+}
+
+// Hide hides the detail modal
+func (d *DetailModalState) Hide() {
+	d.showing = false
+}
+
+// IsShowing returns whether the modal is currently visible
+func (d *DetailModalState) IsShowing() bool {
+	return d.showing
+}
+
+// ScrollUp scrolls the detail content up by one line
+func (d *DetailModalState) ScrollUp() {
+	if d.scrollOffset > 0 {
+		d.scrollOffset--
+	}
+}
+
+// ScrollDown scrolls the detail content down by one line (clamped when drawn)
+func (d *DetailModalState) ScrollDown() {
+	d.scrollOffset++
+}
+
+// NoticesModalState tracks the firmware notifications panel
+type NoticesModalState struct {
+	showing      bool
+	scrollOffset int
+}
+
+// Show displays the notifications panel, newest first
+func (n *NoticesModalState) Show() {
+	n.showing = true
+	n.scrollOffset = 0
+}
+
+// Hide hides the notifications panel
+func (n *NoticesModalState) Hide() {
+	n.showing = false
+}
+
+// IsShowing returns whether the panel is currently visible
+func (n *NoticesModalState) IsShowing() bool {
+	return n != nil && n.showing
+}
+
+// ScrollUp scrolls the panel up by one line
+func (n *NoticesModalState) ScrollUp() {
+	if n.scrollOffset > 0 {
+		n.scrollOffset--
+	}
+}
+
+// ScrollDown scrolls the panel down by one line (clamped when drawn)
+func (n *NoticesModalState) ScrollDown() {
+	n.scrollOffset++
+}
+
+// ClearModalState tracks the clear confirmation modal state
+type ClearModalState struct {
+	showing bool
+	count   int // Number of devices that would be cleared
+}
+
+// Show displays the clear confirmation for the given device count
+func (c *ClearModalState) Show(count int) {
+	c.showing = true
+	c.count = count
+}
+
+// Hide hides the clear confirmation modal
+func (c *ClearModalState) Hide() {
+	c.showing = false
+}
+
+// IsShowing returns whether the modal is currently visible
+func (c *ClearModalState) IsShowing() bool {
+	return c.showing
+}
+
+// WatchModalState tracks the watchlist editor modal state
+type WatchModalState struct {
+	showing  bool
+	input    string // MAC address being typed
+	selected int    // Index of the highlighted watchlist entry
+}
+
+// Show displays the watchlist modal, pre-filling the input with the given MAC
+func (m *WatchModalState) Show(mac string) {
+	m.showing = true
+	m.input = mac
+	m.selected = 0
+}
+
+// Hide hides the watchlist modal
+func (m *WatchModalState) Hide() {
+	m.showing = false
+}
+
+// IsShowing returns whether the modal is currently visible
+func (m *WatchModalState) IsShowing() bool {
+	return m.showing
+}
+
+// MfrFilterModalState tracks the manufacturer filter picker
+type MfrFilterModalState struct {
+	showing  bool
+	input    string // Manufacturer code being typed
+	selected int    // Index of the highlighted entry; 0 is "show all"
+}
+
+// Show displays the manufacturer filter modal
+func (m *MfrFilterModalState) Show() {
+	m.showing = true
+	m.input = ""
+	m.selected = 0
+}
+
+// Hide hides the manufacturer filter modal
+func (m *MfrFilterModalState) Hide() {
+	m.showing = false
+}
+
+// IsShowing returns whether the modal is currently visible
+func (m *MfrFilterModalState) IsShowing() bool {
+	return m.showing
+}
+
 // drawTable renders near devices, far devices, and special manufacturer tables to the screen
-func drawTable(s tcell.Screen, sorted *SortedDevices, paused bool, state *TableState, connState *ConnectionState, locState *LocationState, exportModal *ExportModalState) {
+func drawTable(app *App, sorted *SortedDevices) {
+	s := app.screen
+	paused, inSnapshot := app.IsPaused(), app.InSnapshot()
+	state := app.tableState
+	connState := app.connState
+	locState := app.locState
+	exportModal := app.exportModal
+
 	s.Clear()
 	width, height := s.Size()
 
-	// Calculate column widths using constants
-	// Order: Last Seen, MAC, Signal, RSSI, Location, Name, Service UUIDs, Mfr ID, Mfr Data (variable)
-	colWidths := []int{
-		colWidthLastSeen,
-		colWidthCount,
-		colWidthMAC,
-		colWidthSignal,
-		colWidthRSSI,
-		colWidthLocation,
-		colWidthName,
-		colWidthServiceUUIDs,
-		colWidthMfrCode,
-		width - colWidthLastSeen - colWidthCount - colWidthMAC - colWidthSignal - colWidthRSSI - colWidthLocation - colWidthName - colWidthServiceUUIDs - colWidthMfrCode,
+	// Proximity mode takes over the whole screen
+	if app.proximity.IsShowing() {
+		drawProximityView(s, app.proximity, app.agg)
+		s.Show()
+		return
+	}
+
+	// So does the device count graph
+	if app.density != nil && app.density.IsShowing() {
+		drawDensityView(s, app.density)
+		s.Show()
+		return
+	}
+
+	// Size the enabled columns; low-priority ones are dropped on a narrow terminal
+	columns := visibleColumns(state.hiddenColumns)
+	colWidths := columnWidths(columns, width)
+
+	// Scroll horizontally by whole columns; Mfr Data fills whatever is left on screen
+	state.colOffset = max(0, min(state.colOffset, len(colWidths)-1))
+	hOffset := 0
+	for _, w := range colWidths[:state.colOffset] {
+		hOffset += w
+	}
+	fixedWidth := 0
+	for _, w := range colWidths {
+		fixedWidth += w
+	}
+	for i, column := range columns {
+		if column.fill {
+			colWidths[i] = max(colWidthMfrDataMin, width+hOffset-fixedWidth)
+		}
 	}
 
 	// Use pre-separated recent and stale devices from GetSorted()
@@ -96,72 +334,172 @@ func drawTable(s tcell.Screen, sorted *SortedDevices, paused bool, state *TableS
 	if availableHeight%2 == 1 {
 		nearTableHeight = (availableHeight / 2) + 1
 	}
+	// Single-table mode gives every row to the focused table
+	showNear, showFar := true, true
+	if state.singleTable {
+		showNear = state.focusedTable == "near"
+		showFar = !showNear
+		nearTableHeight = 0
+		if showNear {
+			nearTableHeight = availableHeight
+		}
+	}
+
+	// Options shared by both tables; distances are measured from the live fix, so without one
+	// the distance column stays blank
+	opts := &tableRenderOpts{
+		columns:     columns,
+		colWidths:   colWidths,
+		hOffset:     hOffset,
+		staleAfter:  sorted.StaleAfter,
+		now:         sorted.Now,
+		watchlist:   app.watchlist,
+		trackers:    app.trackers,
+		origin:      locState.GetCurrent(),
+		relativeAge: state.relativeAge,
+		fullUUIDs:   state.fullUUIDs,
+		classColors: state.classColors,
+		stripes:     state.stripes,
+		imperial:    state.imperial,
+		snapshot:    inSnapshot,
+		paused:      paused,
+	}
+
+	// Draw recent devices table; a hidden table clears its layout so clicks don't land on it
+	row := 0
+	state.nearLayout = tableLayout{}
+	if showNear {
+		isFocused := state.focusedTable == "near"
+		row = drawDeviceTable(s, opts, recentDevices, "RECENT DEVICES", row, nearTableHeight, &state.nearScrollOffset, &state.nearSelected, isFocused, state.nearSort, &state.nearLayout)
+	}
+
+	// Draw stale devices table
+	state.farLayout = tableLayout{}
+	if showFar {
+		isFocused := state.focusedTable == "far"
+		row = drawDeviceTable(s, opts, staleDevices, "STALE DEVICES", row, availableHeight, &state.farScrollOffset, &state.farSelected, isFocused, state.farSort, &state.farLayout)
+	}
 
-	// Draw status line at bottom
-	statusStyle := tcell.StyleDefault.Background(tcell.ColorDarkSlateGray).Foreground(tcell.ColorWhite)
-	statusText := "q: Quit | e: Export | c: Clear | p: Pause | ↑↓/jk: Scroll | Tab: Switch | PgUp/PgDn/Home/End"
-	if paused {
-		statusText += " | [PAUSED]"
+	// Draw status line at bottom, after the tables have clamped their scroll offsets; live state
+	// comes first so narrow terminals cut the least useful fields, and the key list is in the ? overlay
+	statusStyle := theme.Status
+	var status []string
+	if inSnapshot {
+		status = append(status, fmt.Sprintf("[SNAPSHOT %s - still recording, P: back to live]", formatDisplayTime(app.snapshot.Now, "15:04:05")))
+	} else if paused {
+		status = append(status, "[PAUSED - still recording, p: resume]")
+	}
+	if app.exporter != nil && app.exporter.Busy() {
+		status = append(status, "Exporting...")
+	}
+	if msg := app.activeStatusMessage(); msg != "" {
+		status = append(status, msg)
+	}
+	// A fresh firmware notification is shown for a while; i lists them all
+	if app.notices != nil {
+		if latest, ok := app.notices.Latest(); ok && time.Since(latest.At) < statusNoticeDuration {
+			status = append(status, fmt.Sprintf("✉ %s %s", formatDisplayTime(latest.At, "15:04:05"), truncateRunes(latest.Text, maxStatusNoticeRunes)))
+		}
 	}
 
 	// Add connection status
 	connected, lastErrTime, attempts := connState.GetStatus()
-	if connected {
-		statusText += " | ✓ CONNECTED"
+	if connState.Len() > 1 {
+		status = append(status, connState.Summary())
+	} else if connected {
+		status = append(status, "✓ CONNECTED")
 	} else {
 		if attempts > 0 {
 			elapsed := time.Since(lastErrTime).Round(time.Second)
-			statusText += fmt.Sprintf(" | ✗ DISCONNECTED (attempt %d, %v ago)", attempts, elapsed)
+			status = append(status, fmt.Sprintf("✗ DISCONNECTED (attempt %d, %v ago)", attempts, elapsed))
 		} else {
-			statusText += " | ○ CONNECTING..."
+			status = append(status, "○ CONNECTING...")
 		}
 	}
 
-	// Add GPS status
-	gpsStatus, fixQuality, satellites, satellitesInView, _ := locState.GetStatus()
-	switch gpsStatus {
-	case "detecting":
-		statusText += " | GPS: Detecting..."
-	case "failed":
-		statusText += " | GPS: FAILED"
-	case "no_fix":
-		// Always show satellite counts
-		statusText += fmt.Sprintf(" | GPS: No Fix (%d / %d)", satellitesInView, satellites)
-	case "fix":
-		if currentLoc := locState.GetCurrent(); currentLoc != nil {
-			statusText += fmt.Sprintf(" | GPS: Fix (%.4f, %.4f) Q:%d %d / %d",
-				currentLoc.Latitude, currentLoc.Longitude, fixQuality, satellitesInView, satellites)
-		} else {
-			statusText += fmt.Sprintf(" | GPS: Fix Q:%d %d / %d", fixQuality, satellitesInView, satellites)
+	// Warn about trackers that appear to be following the user
+	if app.trackers != nil {
+		if count := app.trackers.Count(); count > 0 {
+			status = append(status, fmt.Sprintf("⚠ %d TRACKER(S) FOLLOWING", count))
 		}
-		// "no_gps" status - don't show anything
+	}
+
+	// Add observation rate
+	deviceCount, advPerSec := app.agg.Stats()
+	devicesText := fmt.Sprintf("%d devices, %d adv/s", deviceCount, advPerSec)
+	if app.correlator != nil {
+		devicesText += fmt.Sprintf(" (%d rotated MACs merged)", app.correlator.Merged())
+	}
+	status = append(status, devicesText)
+	// The filter sits next to the count, ahead of the long GPS field, since it explains missing rows
+	if app.filter.IsActive() {
+		status = append(status, "[filter: "+app.filter.String()+"]")
+	}
+
+	// Add GPS status
+	if gps := gpsStatusText(locState); gps != "" {
+		status = append(status, gps)
+	}
+	if locState.IsGPSPaused() {
+		status = append(status, "GPS TAGGING PAUSED (z)")
 	}
 
 	// Add focus indicator and scroll position
+	focusLabel := "Focus"
+	if state.singleTable {
+		focusLabel = "Showing"
+	}
 	if state.focusedTable == "near" {
-		statusText += fmt.Sprintf(" | Focus: RECENT (row %d-%d of %d)",
+		status = append(status, fmt.Sprintf("%s: RECENT (row %d-%d of %d)", focusLabel,
 			state.nearScrollOffset+1,
 			min(state.nearScrollOffset+nearTableHeight-2, len(recentDevices)),
-			len(recentDevices))
+			len(recentDevices)))
 	} else {
-		statusText += fmt.Sprintf(" | Focus: STALE (row %d-%d of %d)",
+		status = append(status, fmt.Sprintf("%s: STALE (row %d-%d of %d)", focusLabel,
 			state.farScrollOffset+1,
 			min(state.farScrollOffset+(availableHeight-nearTableHeight)-2, len(staleDevices)),
-			len(staleDevices))
+			len(staleDevices)))
 	}
-	drawText(s, 0, height-1, width, statusStyle, statusText)
 
-	// Draw recent devices table
-	row := 0
-	isFocused := state.focusedTable == "near"
-	row = drawDeviceTable(s, recentDevices, colWidths, "RECENT DEVICES", row, nearTableHeight, state.nearScrollOffset, isFocused)
+	// Add autosave status
+	if app.autosaver != nil {
+		if lastSave, err := app.autosaver.Status(); err != nil {
+			status = append(status, "Autosave FAILED")
+		} else if !lastSave.IsZero() {
+			status = append(status, "Saved "+formatDisplayTime(lastSave, "15:04:05"))
+		}
+	}
 
-	// Draw stale devices table
-	isFocused = state.focusedTable == "far"
-	row = drawDeviceTable(s, staleDevices, colWidths, "STALE DEVICES", row, availableHeight, state.farScrollOffset, isFocused)
+	// Report records the JSON Lines consumer couldn't keep up with
+	if app.stream != nil {
+		if dropped := app.stream.Dropped(); dropped > 0 {
+			status = append(status, fmt.Sprintf("JSONL dropped %d", dropped))
+		}
+	}
+
+	if app.session != nil {
+		status = append(status, fmt.Sprintf("elapsed: %s peak: %d", formatElapsed(app.session.Elapsed(time.Now())), app.agg.Peak()))
+	}
+	// Which zone the timestamps above are in (-tz)
+	status = append(status, "Times: "+displayZoneName(time.Now()))
+
+	// The help hint keeps the right edge unless the terminal is too narrow to spare it
+	statusText := strings.Join(status, " | ")
+	statusWidth := width
+	if width >= minHelpHintWidth {
+		statusWidth = width - len(statusHelpHint)
+		drawText(s, statusWidth, height-1, len(statusHelpHint), statusStyle, statusHelpHint)
+	}
+	drawText(s, 0, height-1, statusWidth, statusStyle, statusText)
+
+	// Draw the class color legend in the bottom-right corner, under any modal
+	if state.classLegend {
+		drawClassLegend(s)
+	}
 
-	// Draw disconnection modal overlay if not connected
-	if !connected {
+	// Draw disconnection modal overlay once no input is connected, unless dismissed
+	// The status line keeps showing ✗ DISCONNECTED either way
+	if connState.UpdateModal(time.Now()) {
 		drawDisconnectionModal(s, connState)
 	}
 
@@ -175,40 +513,128 @@ func drawTable(s tcell.Screen, sorted *SortedDevices, paused bool, state *TableS
 		drawGPSReconnectionModal(s, locState)
 	}
 
+	// Draw device detail modal if showing
+	if app.detailModal.IsShowing() {
+		dev := app.deviceSnapshot(app.detailModal.mac)
+		if dev != nil && app.correlator != nil {
+			dev.Aliases = app.correlator.Aliases(dev.MacAddress)
+		}
+		drawDetailModal(s, app.detailModal, dev)
+	}
+
+	// Draw notifications panel if showing
+	if app.noticesModal.IsShowing() && app.notices != nil {
+		drawNoticesModal(s, app.noticesModal, app.notices.All())
+	}
+
+	// Draw watchlist modal if showing
+	if app.watchModal.IsShowing() {
+		drawWatchModal(s, app.watchModal, app.watchlist)
+	}
+
+	// Draw manufacturer filter modal if showing
+	if app.mfrModal.IsShowing() {
+		drawMfrFilterModal(s, app.mfrModal, countMfrCodes(app.unfilteredView()), &app.filter)
+	}
+
 	// Draw export modal if showing
 	if exportModal.IsShowing() {
-		drawExportModal(s, exportModal)
+		// The scope line only appears when there is a filter to honor
+		scope := ""
+		if app.filter.IsActive() {
+			scope = fmt.Sprintf("[A] Scope: all (%d devices)", deviceCount)
+			if exportModal.filtered {
+				scope = fmt.Sprintf("[A] Scope: filtered (%d of %d devices)", len(recentDevices)+len(staleDevices), deviceCount)
+			}
+		}
+		drawExportModal(s, exportModal, scope)
+	}
+
+	// Draw clear confirmation on top of everything
+	if app.clearModal.IsShowing() {
+		drawClearModal(s, app.clearModal)
+	}
+
+	// Draw key help if showing
+	if app.helpModal.IsShowing() {
+		drawHelpModal(s, app.helpModal)
 	}
 
 	s.Show()
 }
 
-// drawDeviceTable renders a single device table with the given title
-func drawDeviceTable(s tcell.Screen, devices []*BLEDevice, colWidths []int, title string, startRow int, maxRow int, scrollOffset int, isFocused bool) int {
+// deviceRowLines returns the number of screen lines a device occupies (one per service UUID)
+// Devices take a single line when the Service UUIDs column isn't shown
+func deviceRowLines(dev *BLEDevice, uuidsShown bool) int {
+	if uuidsShown && len(dev.ServiceUUIDs) > 1 {
+		return len(dev.ServiceUUIDs)
+	}
+	return 1
+}
+
+// tableRenderOpts carries the drawing options shared by both device tables
+type tableRenderOpts struct {
+	columns     []tableColumn
+	colWidths   []int
+	hOffset     int // Screen columns scrolled off to the left
+	staleAfter  time.Duration
+	now         time.Time
+	watchlist   *Watchlist
+	trackers    *TrackerDetector
+	origin      *GeoLocation // Current GPS fix, for the distance column
+	relativeAge bool
+	fullUUIDs   bool
+	classColors bool
+	stripes     bool
+	imperial    bool
+	snapshot    bool // Drawing a captured snapshot rather than live data
+	paused      bool // Drawing the devices as of a pause
+}
+
+// drawDeviceTable renders a single device table with the given title between startRow and maxRow
+// The scroll offset and cursor are clamped in place and the cursor row is kept visible
+func drawDeviceTable(s tcell.Screen, opts *tableRenderOpts, devices []*BLEDevice, title string, startRow, maxRow int, scrollOffsetPtr, selectedPtr *int, isFocused bool, sortOrder SortOrder, layout *tableLayout) int {
+	columns, colWidths, hOffset, now := opts.columns, opts.colWidths, opts.hOffset, opts.now
 	width, _ := s.Size()
+	layout.top, layout.bottom, layout.rows = startRow, maxRow, layout.rows[:0]
 
 	// Draw table title with focus indicator
-	titleStyle := tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite)
-	if isFocused {
-		titleStyle = titleStyle.Background(tcell.ColorDarkGreen)
-	} else {
-		titleStyle = titleStyle.Background(tcell.ColorDarkSlateGray)
+	// A frozen snapshot gets its own color so it can't be mistaken for live data
+	titleStyle := theme.TitleUnfocused
+	if opts.snapshot {
+		titleStyle = theme.TitleSnapshot
+	} else if isFocused {
+		titleStyle = theme.TitleFocused
 	}
 
-	titleText := fmt.Sprintf(" %s ", title)
+	titleText := fmt.Sprintf(" %s (sort: %s) ", title, sortOrder)
+	if opts.snapshot {
+		titleText += fmt.Sprintf("[SNAPSHOT %s] ", formatDisplayTime(now, "15:04:05"))
+	} else if opts.paused {
+		titleText += "[PAUSED] "
+	}
 	if isFocused {
 		titleText += "◀ FOCUSED"
 	}
 	drawText(s, 0, startRow, width, titleStyle, titleText)
 	startRow++
 
-	// Draw header
-	headerStyle := tcell.StyleDefault.Bold(true).Background(tcell.ColorNavy).Foreground(tcell.ColorWhite)
-	headers := []string{"Last Seen", "Count", "MAC Address", "Sig", "RSSI", "Location", "Device Name", "Service UUIDs", "Mfr ID", "Mfr Data"}
+	// Cells are drawn hOffset columns to the left; anything off screen is clipped by the screen
+	drawCell := func(x, y, cellWidth int, style tcell.Style, text string) {
+		drawText(s, x-hOffset, y, cellWidth, style, text)
+	}
 
+	// Draw header
+	headerStyle := theme.Header
 	col := 0
-	for i, header := range headers {
-		drawText(s, col, startRow, colWidths[i], headerStyle, header)
+	uuidsShown := false
+	for i, column := range columns {
+		uuidsShown = uuidsShown || column.key == "uuids" && colWidths[i] > 0
+		header := column.header
+		if column.key == "seen" && opts.relativeAge {
+			header = "Age"
+		}
+		drawCell(col, startRow, colWidths[i], headerStyle, header)
 		col += colWidths[i]
 	}
 	startRow++
@@ -216,7 +642,17 @@ func drawDeviceTable(s tcell.Screen, devices []*BLEDevice, colWidths []int, titl
 	// Calculate available rows for data
 	availableRows := maxRow - startRow
 
+	// Clamp cursor to the current device list
+	selected := *selectedPtr
+	if selected >= len(devices) {
+		selected = len(devices) - 1
+	}
+	if selected < 0 {
+		selected = 0
+	}
+
 	// Clamp scroll offset
+	scrollOffset := *scrollOffsetPtr
 	maxScroll := len(devices)
 	if scrollOffset < 0 {
 		scrollOffset = 0
@@ -225,108 +661,111 @@ func drawDeviceTable(s tcell.Screen, devices []*BLEDevice, colWidths []int, titl
 		scrollOffset = max(0, maxScroll-1)
 	}
 
+	// Keep the cursor row visible
+	if selected < scrollOffset {
+		scrollOffset = selected
+	}
+	if len(devices) > 0 {
+		linesNeeded := 0
+		for i := scrollOffset; i <= selected; i++ {
+			linesNeeded += deviceRowLines(devices[i], uuidsShown)
+		}
+		for scrollOffset < selected && linesNeeded > availableRows {
+			linesNeeded -= deviceRowLines(devices[scrollOffset], uuidsShown)
+			scrollOffset++
+		}
+	}
+
+	*selectedPtr = selected
+	*scrollOffsetPtr = scrollOffset
+
 	// Draw devices starting from scrollOffset
 	row := startRow
-	normalStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack)
 
 	for i := scrollOffset; i < len(devices) && row < maxRow; i++ {
 		dev := devices[i]
 
 		// Calculate number of lines needed for service UUIDs
-		uuidLines := 1
-		if len(dev.ServiceUUIDs) > 1 {
-			uuidLines = len(dev.ServiceUUIDs)
-		}
+		uuidLines := deviceRowLines(dev, uuidsShown)
 
 		// Skip if this device won't fit
 		if row+uuidLines > maxRow {
 			break
 		}
 
-		// Draw Last Seen timestamp (first column)
-		lastSeenStr := dev.LastSeen.Format("2006-01-02 15:04:05")
-
-		// For recent devices table, color Last Seen based on age
-		lastSeenStyle := normalStyle
-		if title == "RECENT DEVICES" {
-			age := time.Since(dev.LastSeen).Seconds()
-			if age > 8 {
-				// Bright red for > 8 seconds
-				lastSeenStyle = tcell.StyleDefault.Foreground(tcell.ColorRed).Background(tcell.ColorBlack)
-			} else if age > 6 {
-				// Orange for > 6 seconds
-				lastSeenStyle = tcell.StyleDefault.Foreground(tcell.ColorOrange).Background(tcell.ColorBlack)
-			} else if age > 4 {
-				// Yellow for > 4 seconds
-				lastSeenStyle = tcell.StyleDefault.Foreground(tcell.ColorYellow).Background(tcell.ColorBlack)
+		// Highlight the cursor row in the focused table
+		isSelected := isFocused && i == selected
+		baseStyle := theme.Row
+		if isSelected {
+			baseStyle = theme.RowSelected
+		}
+		// Watched devices stand out in the theme's watch color, suspected trackers in the tracker color
+		tracker := opts.trackers != nil && opts.trackers.IsFlagged(dev.MacAddress)
+		normalStyle := theme.rowStyle(isSelected, opts.watchlist != nil && opts.watchlist.Contains(dev.MacAddress), tracker)
+
+		// Tint unselected rows by class group so similar devices read as a block
+		class := classifyDevice(dev)
+		tinted := false
+		if opts.classColors && !isSelected {
+			if tint, ok := theme.classTint(class); ok {
+				baseStyle = baseStyle.Background(tint)
+				normalStyle = normalStyle.Background(tint)
+				tinted = true
 			}
 		}
-
-		drawText(s, 0, row, colWidths[0], lastSeenStyle, lastSeenStr)
-
-		// Draw Count (second column)
-		countStr := fmt.Sprintf("%d", dev.Count)
-		drawText(s, colWidths[0], row, colWidths[1], normalStyle, countStr)
-
-		// Draw MAC address
-		drawText(s, colWidths[0]+colWidths[1], row, colWidths[2], normalStyle, dev.MacAddress)
-
-		// Draw Signal strength indicator
-		signalIndicator, signalColor := getSignalIndicator(dev.RSSI)
-		signalStyle := tcell.StyleDefault.Foreground(signalColor).Background(tcell.ColorBlack)
-		drawText(s, colWidths[0]+colWidths[1]+colWidths[2], row, colWidths[3], signalStyle, signalIndicator)
-
-		// Draw RSSI
-		drawText(s, colWidths[0]+colWidths[1]+colWidths[2]+colWidths[3], row, colWidths[4], normalStyle, fmt.Sprintf("%d", dev.RSSI))
-
-		// Draw Location (averaged from highest RSSI's geo data)
-		locationStr := ""
-		if dev.GeoData != nil {
-			if loc := dev.GeoData.GetLocation(); loc != nil {
-				// Format: "lat, lon" with 5 decimal places (≈1.1m precision)
-				locationStr = fmt.Sprintf("%.5f, %.5f", loc.Latitude, loc.Longitude)
+		// Otherwise alternate the background per device, so a row's UUID lines share its shade
+		if opts.stripes && !isSelected && !tinted {
+			stripe := theme.RowStripes[i%2]
+			baseStyle = baseStyle.Background(stripe)
+			normalStyle = normalStyle.Background(stripe)
+			tinted = true
+		}
+		if isSelected || tinted {
+			for j := 0; j < uuidLines; j++ {
+				drawText(s, 0, row+j, width, normalStyle, "")
 			}
 		}
-		drawText(s, colWidths[0]+colWidths[1]+colWidths[2]+colWidths[3]+colWidths[4], row, colWidths[5], normalStyle, locationStr)
-
-		// Draw device name
-		drawText(s, colWidths[0]+colWidths[1]+colWidths[2]+colWidths[3]+colWidths[4]+colWidths[5], row, colWidths[6], normalStyle, dev.DeviceName)
 
-		// Draw service UUIDs (multi-line with ellipsis support) - now fixed width at 38 chars
-		uuidCol := colWidths[0] + colWidths[1] + colWidths[2] + colWidths[3] + colWidths[4] + colWidths[5] + colWidths[6]
-		if len(dev.ServiceUUIDs) == 0 {
-			drawText(s, uuidCol, row, colWidths[7], normalStyle, "")
-		} else {
-			for j, uuid := range dev.ServiceUUIDs {
+		// Draw each enabled column; multi-line values (service UUIDs) continue on the rows below
+		ctx := &rowContext{
+			now:         now,
+			staleAfter:  opts.staleAfter,
+			recent:      title == "RECENT DEVICES",
+			relativeAge: opts.relativeAge,
+			fullUUIDs:   opts.fullUUIDs,
+			imperial:    opts.imperial,
+			origin:      opts.origin,
+			tracker:     tracker,
+			class:       class,
+			baseStyle:   baseStyle,
+			normalStyle: normalStyle,
+		}
+		col := 0
+		for c, column := range columns {
+			cellWidth := colWidths[c]
+			if column.gap {
+				cellWidth--
+			}
+			style := normalStyle
+			if column.style != nil {
+				style = column.style(dev, ctx)
+			}
+			for j, line := range strings.Split(column.text(dev, ctx), "\n") {
 				if row+j >= maxRow {
 					break
 				}
-				// Ellipsize if UUID is longer than column width
-				displayUUID := uuid
-				if len(uuid) > colWidths[7] && colWidths[7] > 3 {
-					displayUUID = uuid[:colWidths[7]-3] + "..."
-				}
-				drawText(s, uuidCol, row+j, colWidths[7], normalStyle, displayUUID)
+				drawCell(col, row+j, cellWidth, style, line)
 			}
+			col += colWidths[c]
 		}
 
-		// Draw Mfr Code (as integer)
-		mfrCodeStr := ""
-		if dev.MfrCode != 0 {
-			mfrCodeStr = fmt.Sprintf("%d", dev.MfrCode)
-		}
-		drawText(s, colWidths[0]+colWidths[1]+colWidths[2]+colWidths[3]+colWidths[4]+colWidths[5]+colWidths[6]+colWidths[7], row, colWidths[8], normalStyle, mfrCodeStr)
-
-		// Draw Mfr Data (variable width - fills remaining space)
-		mfrDataCol := colWidths[0] + colWidths[1] + colWidths[2] + colWidths[3] + colWidths[4] + colWidths[5] + colWidths[6] + colWidths[7] + colWidths[8]
-		drawText(s, mfrDataCol, row, colWidths[9], normalStyle, dev.MfrData)
-
+		layout.rows = append(layout.rows, rowSpan{y: row, lines: uuidLines, index: i})
 		row += uuidLines
 	}
 
 	// Draw scroll indicators if needed
 	if isFocused && len(devices) > 0 {
-		indicatorStyle := tcell.StyleDefault.Foreground(tcell.ColorYellow).Background(tcell.ColorBlack)
+		indicatorStyle := theme.ScrollIndicator
 		if scrollOffset > 0 {
 			// More content above
 			drawText(s, width-10, startRow, 10, indicatorStyle, "▲ MORE ▲")
@@ -340,22 +779,110 @@ func drawDeviceTable(s tcell.Screen, devices []*BLEDevice, colWidths []int, titl
 	return row
 }
 
+// formatAge formats how long ago something happened in its largest whole unit, e.g. "3s ago" or "2m ago"
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		if age < 0 {
+			age = 0
+		}
+		return fmt.Sprintf("%ds ago", int(age/time.Second))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+	}
+}
+
+// gpsStatusText describes the GPS receiver for the status line, or "" when there is none
+func gpsStatusText(locState *LocationState) string {
+	gpsStatus, fixQuality, satellites, satellitesInView, _ := locState.GetStatus()
+	switch gpsStatus {
+	case "detecting":
+		return "GPS: Detecting..."
+	case "failed":
+		return "GPS: FAILED"
+	case "no_fix":
+		// Say whether satellites are being acquired or none can be heard at all
+		reason := locState.NoFixReason()
+		if satellitesInView > 0 {
+			return fmt.Sprintf("GPS: %s (%d / %d)", reason, satellitesInView, satellites)
+		}
+		return "GPS: " + reason
+	case "poor_fix":
+		hdop, maxHDOP, _ := locState.GetHDOP()
+		return fmt.Sprintf("GPS: Poor Fix HDOP:%.1f > %.1f (%d / %d)", hdop, maxHDOP, satellitesInView, satellites)
+	case "fix":
+		var text string
+		if age, stale := locState.FixAge(); stale {
+			text = fmt.Sprintf("GPS: STALE (%v)", age.Round(time.Second))
+		} else {
+			// HDOP is shown alongside fix quality when the receiver reports it
+			quality := fmt.Sprintf("Q:%d", fixQuality)
+			if source := locState.GetFixSource(); source != "" {
+				quality = source + " " + quality
+			}
+			switch fixType, _, _ := locState.GetDOP(); fixType {
+			case nmea.Fix2D:
+				quality += " 2D"
+			case nmea.Fix3D:
+				quality += " 3D"
+			}
+			if hdop, _, _ := locState.GetHDOP(); hdop > 0 {
+				quality += fmt.Sprintf(" HDOP:%.1f", hdop)
+			}
+			if currentLoc := locState.GetCurrent(); currentLoc != nil {
+				text = fmt.Sprintf("GPS: Fix (%.4f, %.4f) %s %d / %d",
+					currentLoc.Latitude, currentLoc.Longitude, quality, satellitesInView, satellites)
+			} else {
+				text = fmt.Sprintf("GPS: Fix %s %d / %d", quality, satellitesInView, satellites)
+			}
+		}
+		if speedKPH, _, ok := locState.GetVelocity(); ok {
+			text += fmt.Sprintf(" %.1f km/h", speedKPH)
+		}
+		return text
+	}
+	// "no_gps" status - don't show anything
+	return ""
+}
+
+// truncateRunes cuts text to at most n runes, ending in "..." when shortened
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:max(0, n-3)]) + "..."
+}
+
 // drawText draws text at a specific position
 func drawText(s tcell.Screen, x, y, width int, style tcell.Style, text string) {
+	// Clip to the screen: tables scrolled sideways start left of it and wide columns run past it
+	screenWidth, screenHeight := s.Size()
+	if width <= 0 || y < 0 || y >= screenHeight {
+		return
+	}
+	first, end := max(0, -x), min(width, screenWidth-x)
+
 	// Convert string to runes to properly handle UTF-8 multi-byte characters
 	runes := []rune(text)
-	col := 0
-
-	// Draw each rune
-	for i := 0; i < len(runes) && col < width; i++ {
-		s.SetContent(x+col, y, runes[i], nil, style)
-		col++
+	for col := first; col < end; col++ {
+		// Fill past the end of the text with blanks
+		r := ' '
+		if col < len(runes) {
+			r = runes[col]
+		}
+		s.SetContent(x+col, y, r, nil, style)
 	}
+}
 
-	// Fill remaining space with blanks
-	for col < width {
-		s.SetContent(x+col, y, ' ', nil, style)
-		col++
+// setCell sets one screen cell, ignoring positions off the screen
+func setCell(s tcell.Screen, x, y int, r rune, style tcell.Style) {
+	if width, height := s.Size(); x >= 0 && y >= 0 && x < width && y < height {
+		s.SetContent(x, y, r, nil, style)
 	}
 }
 
@@ -373,34 +900,56 @@ func max(a, b int) int {
 	return b
 }
 
+// renderSparkline draws the most recent width values as block characters scaled between their min and max
+// Fewer values than width are right-aligned; a flat or empty series renders as low bars or blanks
+func renderSparkline(values []int, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	bars := []rune("▁▂▃▄▅▆▇█")
+	lo, hi := 0, 0
+	for i, v := range values {
+		if i == 0 || v < lo {
+			lo = v
+		}
+		if i == 0 || v > hi {
+			hi = v
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", width-len(values)))
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = (v - lo) * (len(bars) - 1) / (hi - lo)
+		}
+		sb.WriteRune(bars[level])
+	}
+	return sb.String()
+}
+
+// connectableFlag returns the table icon for a device's connectable state: ● connectable, ○ not, blank if unknown
+func connectableFlag(connectable *bool) string {
+	if connectable == nil {
+		return ""
+	}
+	if *connectable {
+		return "●"
+	}
+	return "○"
+}
+
 // getSignalIndicator returns a visual signal strength indicator based on RSSI
-// Returns the indicator string and the color to use
+// Returns the indicator string and the active theme's color for that strength
 func getSignalIndicator(rssi int) (string, tcell.Color) {
-	var bars int
-	var color tcell.Color
-
-	// Determine color and number of bars based on RSSI thresholds
-	if rssi > -50 {
-		// Excellent - Blue - 7 bars
-		bars = 7
-		color = tcell.ColorBlue
-	} else if rssi > -60 {
-		// Good - Green - 5 bars
-		bars = 5
-		color = tcell.ColorGreen
-	} else if rssi > -70 {
-		// Fair - Yellow - 3 bars
-		bars = 3
-		color = tcell.ColorYellow
-	} else if rssi > -80 {
-		// Poor - Orange - 2 bars
-		bars = 2
-		color = tcell.ColorOrange
-	} else {
-		// Very Poor - Red - 1 bar
-		bars = 1
-		color = tcell.ColorRed
-	}
+	band := bandForRSSI(rssi)
+	bars := band.bars
+	color := theme.SignalRamp[band.color]
 
 	// Build the indicator string using gradient blocks
 	// Full block: █ (U+2588) for filled
@@ -417,66 +966,66 @@ func getSignalIndicator(rssi int) (string, tcell.Color) {
 }
 
 // drawDisconnectionModal draws a centered modal overlay showing connection status
-func drawDisconnectionModal(s tcell.Screen, connState *ConnectionState) {
+func drawDisconnectionModal(s tcell.Screen, connState *ConnectionSet) {
 	width, height := s.Size()
 
 	// Modal dimensions
 	modalWidth := 50
 	modalHeight := 8
-	modalX := (width - modalWidth) / 2
-	modalY := (height - modalHeight) / 2
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
 
 	// Get connection status
 	_, lastErrTime, attempts := connState.GetStatus()
 	elapsed := time.Since(lastErrTime).Round(time.Second)
 
 	// Styles
-	borderStyle := tcell.StyleDefault.Foreground(tcell.ColorRed).Background(tcell.ColorBlack).Bold(true)
-	bgStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkRed)
-	textStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorDarkRed)
-	buttonStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite).Bold(true)
+	borderStyle := theme.Disconnect.Border
+	bgStyle := theme.Disconnect.Body
+	textStyle := theme.Disconnect.Body
+	buttonStyle := theme.Button.Bold(true)
 
 	// Draw background overlay (dim the screen)
-	dimStyle := tcell.StyleDefault.Foreground(tcell.ColorGray).Background(tcell.ColorBlack)
+	dimStyle := theme.Dim
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			if y >= modalY && y < modalY+modalHeight && x >= modalX && x < modalX+modalWidth {
 				continue // Skip modal area
 			}
 			// Dim the background by setting space with dim style
-			s.SetContent(x, y, ' ', nil, dimStyle)
+			setCell(s, x, y, ' ', dimStyle)
 		}
 	}
 
 	// Draw modal background
 	for y := modalY; y < modalY+modalHeight; y++ {
 		for x := modalX; x < modalX+modalWidth; x++ {
-			s.SetContent(x, y, ' ', nil, bgStyle)
+			setCell(s, x, y, ' ', bgStyle)
 		}
 	}
 
 	// Draw border
 	// Top and bottom borders
 	for x := modalX; x < modalX+modalWidth; x++ {
-		s.SetContent(x, modalY, '═', nil, borderStyle)
-		s.SetContent(x, modalY+modalHeight-1, '═', nil, borderStyle)
+		setCell(s, x, modalY, '═', borderStyle)
+		setCell(s, x, modalY+modalHeight-1, '═', borderStyle)
 	}
 	// Side borders
 	for y := modalY; y < modalY+modalHeight; y++ {
-		s.SetContent(modalX, y, '║', nil, borderStyle)
-		s.SetContent(modalX+modalWidth-1, y, '║', nil, borderStyle)
+		setCell(s, modalX, y, '║', borderStyle)
+		setCell(s, modalX+modalWidth-1, y, '║', borderStyle)
 	}
 	// Corners
-	s.SetContent(modalX, modalY, '╔', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY, '╗', nil, borderStyle)
-	s.SetContent(modalX, modalY+modalHeight-1, '╚', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY+modalHeight-1, '╝', nil, borderStyle)
+	setCell(s, modalX, modalY, '╔', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY, '╗', borderStyle)
+	setCell(s, modalX, modalY+modalHeight-1, '╚', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY+modalHeight-1, '╝', borderStyle)
 
 	// Draw title
 	title := " CONNECTION LOST "
 	titleX := modalX + (modalWidth-len(title))/2
 	for i, ch := range title {
-		s.SetContent(titleX+i, modalY+1, ch, nil, borderStyle)
+		setCell(s, titleX+i, modalY+1, ch, borderStyle)
 	}
 
 	// Draw status text
@@ -489,19 +1038,20 @@ func drawDisconnectionModal(s tcell.Screen, connState *ConnectionState) {
 	drawCenteredText(s, modalX, modalY+5, modalWidth, textStyle, line3)
 
 	// Draw button
-	button := " [Q] Quit "
+	button := " [ESC] Dismiss  [Q] Quit "
 	buttonX := modalX + (modalWidth-len(button))/2
 	for i, ch := range button {
-		s.SetContent(buttonX+i, modalY+modalHeight-2, ch, nil, buttonStyle)
+		setCell(s, buttonX+i, modalY+modalHeight-2, ch, buttonStyle)
 	}
 }
 
 // drawCenteredText draws text centered within a given width
 func drawCenteredText(s tcell.Screen, x, y, width int, style tcell.Style, text string) {
-	textX := x + (width-len(text))/2
-	for i, ch := range text {
+	runes := []rune(text)
+	textX := x + (width-len(runes))/2
+	for i, ch := range runes {
 		if textX+i >= x && textX+i < x+width {
-			s.SetContent(textX+i, y, ch, nil, style)
+			setCell(s, textX+i, y, ch, style)
 		}
 	}
 }
@@ -513,43 +1063,43 @@ func drawGPSFailureModal(s tcell.Screen) {
 	// Modal dimensions
 	modalWidth := 60
 	modalHeight := 7
-	modalX := (width - modalWidth) / 2
-	modalY := (height - modalHeight) / 2
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
 
 	// Styles
-	borderStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow).Bold(true)
-	bgStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow)
-	textStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow)
+	borderStyle := theme.GPSFailure.Border
+	bgStyle := theme.GPSFailure.Body
+	textStyle := theme.GPSFailure.Body
 
 	// Draw modal background
 	for y := modalY; y < modalY+modalHeight; y++ {
 		for x := modalX; x < modalX+modalWidth; x++ {
-			s.SetContent(x, y, ' ', nil, bgStyle)
+			setCell(s, x, y, ' ', bgStyle)
 		}
 	}
 
 	// Draw border
 	// Top and bottom borders
 	for x := modalX; x < modalX+modalWidth; x++ {
-		s.SetContent(x, modalY, '═', nil, borderStyle)
-		s.SetContent(x, modalY+modalHeight-1, '═', nil, borderStyle)
+		setCell(s, x, modalY, '═', borderStyle)
+		setCell(s, x, modalY+modalHeight-1, '═', borderStyle)
 	}
 	// Side borders
 	for y := modalY; y < modalY+modalHeight; y++ {
-		s.SetContent(modalX, y, '║', nil, borderStyle)
-		s.SetContent(modalX+modalWidth-1, y, '║', nil, borderStyle)
+		setCell(s, modalX, y, '║', borderStyle)
+		setCell(s, modalX+modalWidth-1, y, '║', borderStyle)
 	}
 	// Corners
-	s.SetContent(modalX, modalY, '╔', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY, '╗', nil, borderStyle)
-	s.SetContent(modalX, modalY+modalHeight-1, '╚', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY+modalHeight-1, '╝', nil, borderStyle)
+	setCell(s, modalX, modalY, '╔', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY, '╗', borderStyle)
+	setCell(s, modalX, modalY+modalHeight-1, '╚', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY+modalHeight-1, '╝', borderStyle)
 
 	// Draw title
 	title := " GPS AUTO-DETECTION FAILED "
 	titleX := modalX + (modalWidth-len(title))/2
 	for i, ch := range title {
-		s.SetContent(titleX+i, modalY+1, ch, nil, borderStyle)
+		setCell(s, titleX+i, modalY+1, ch, borderStyle)
 	}
 
 	// Draw message
@@ -569,47 +1119,47 @@ func drawGPSReconnectionModal(s tcell.Screen, locState *LocationState) {
 	// Modal dimensions
 	modalWidth := 60
 	modalHeight := 8
-	modalX := (width - modalWidth) / 2
-	modalY := (height - modalHeight) / 2
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
 
 	// Get reconnection info
 	attempts, elapsed := locState.GetGPSReconnectInfo()
 	elapsed = elapsed.Round(time.Second)
 
 	// Styles
-	borderStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorOrange).Bold(true)
-	bgStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorOrange)
-	textStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorOrange)
+	borderStyle := theme.GPSReconnect.Border
+	bgStyle := theme.GPSReconnect.Body
+	textStyle := theme.GPSReconnect.Body
 
 	// Draw modal background
 	for y := modalY; y < modalY+modalHeight; y++ {
 		for x := modalX; x < modalX+modalWidth; x++ {
-			s.SetContent(x, y, ' ', nil, bgStyle)
+			setCell(s, x, y, ' ', bgStyle)
 		}
 	}
 
 	// Draw border
 	// Top and bottom borders
 	for x := modalX; x < modalX+modalWidth; x++ {
-		s.SetContent(x, modalY, '═', nil, borderStyle)
-		s.SetContent(x, modalY+modalHeight-1, '═', nil, borderStyle)
+		setCell(s, x, modalY, '═', borderStyle)
+		setCell(s, x, modalY+modalHeight-1, '═', borderStyle)
 	}
 	// Side borders
 	for y := modalY; y < modalY+modalHeight; y++ {
-		s.SetContent(modalX, y, '║', nil, borderStyle)
-		s.SetContent(modalX+modalWidth-1, y, '║', nil, borderStyle)
+		setCell(s, modalX, y, '║', borderStyle)
+		setCell(s, modalX+modalWidth-1, y, '║', borderStyle)
 	}
 	// Corners
-	s.SetContent(modalX, modalY, '╔', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY, '╗', nil, borderStyle)
-	s.SetContent(modalX, modalY+modalHeight-1, '╚', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY+modalHeight-1, '╝', nil, borderStyle)
+	setCell(s, modalX, modalY, '╔', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY, '╗', borderStyle)
+	setCell(s, modalX, modalY+modalHeight-1, '╚', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY+modalHeight-1, '╝', borderStyle)
 
 	// Draw title
 	title := " GPS CONNECTION LOST "
 	titleX := modalX + (modalWidth-len(title))/2
 	for i, ch := range title {
-		s.SetContent(titleX+i, modalY+1, ch, nil, borderStyle)
+		setCell(s, titleX+i, modalY+1, ch, borderStyle)
 	}
 
 	// Draw status text
@@ -625,47 +1175,50 @@ func drawGPSReconnectionModal(s tcell.Screen, locState *LocationState) {
 }
 
 // drawExportModal draws the export options modal
-func drawExportModal(s tcell.Screen, exportModal *ExportModalState) {
+func drawExportModal(s tcell.Screen, exportModal *ExportModalState, scope string) {
 	width, height := s.Size()
 
-	// Modal dimensions
+	// Modal dimensions (two extra rows for the scope line)
 	modalWidth := 50
-	modalHeight := 10
-	modalX := (width - modalWidth) / 2
-	modalY := (height - modalHeight) / 2
+	modalHeight := 14
+	if scope != "" {
+		modalHeight += 2
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
 
 	// Styles
-	borderStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlue).Bold(true)
-	bgStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlue)
-	buttonNormal := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorWhite)
-	buttonSelected := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorGreen).Bold(true)
+	borderStyle := theme.Export.Border
+	bgStyle := theme.Export.Body
+	buttonNormal := theme.Button
+	buttonSelected := theme.ButtonSelected
 
 	// Draw modal background
 	for y := modalY; y < modalY+modalHeight; y++ {
 		for x := modalX; x < modalX+modalWidth; x++ {
-			s.SetContent(x, y, ' ', nil, bgStyle)
+			setCell(s, x, y, ' ', bgStyle)
 		}
 	}
 
 	// Draw border
 	for x := modalX; x < modalX+modalWidth; x++ {
-		s.SetContent(x, modalY, '═', nil, borderStyle)
-		s.SetContent(x, modalY+modalHeight-1, '═', nil, borderStyle)
+		setCell(s, x, modalY, '═', borderStyle)
+		setCell(s, x, modalY+modalHeight-1, '═', borderStyle)
 	}
 	for y := modalY; y < modalY+modalHeight; y++ {
-		s.SetContent(modalX, y, '║', nil, borderStyle)
-		s.SetContent(modalX+modalWidth-1, y, '║', nil, borderStyle)
+		setCell(s, modalX, y, '║', borderStyle)
+		setCell(s, modalX+modalWidth-1, y, '║', borderStyle)
 	}
-	s.SetContent(modalX, modalY, '╔', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY, '╗', nil, borderStyle)
-	s.SetContent(modalX, modalY+modalHeight-1, '╚', nil, borderStyle)
-	s.SetContent(modalX+modalWidth-1, modalY+modalHeight-1, '╝', nil, borderStyle)
+	setCell(s, modalX, modalY, '╔', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY, '╗', borderStyle)
+	setCell(s, modalX, modalY+modalHeight-1, '╚', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY+modalHeight-1, '╝', borderStyle)
 
 	// Draw title
 	title := " EXPORT OPTIONS "
 	titleX := modalX + (modalWidth-len(title))/2
 	for i, ch := range title {
-		s.SetContent(titleX+i, modalY+1, ch, nil, borderStyle)
+		setCell(s, titleX+i, modalY+1, ch, borderStyle)
 	}
 
 	// Draw instructions
@@ -685,7 +1238,7 @@ func drawExportModal(s tcell.Screen, exportModal *ExportModalState) {
 	}
 	jsonX := modalX + (modalWidth-len(jsonButton))/2
 	for i, ch := range jsonButton {
-		s.SetContent(jsonX+i, buttonY, ch, nil, jsonStyle)
+		setCell(s, jsonX+i, buttonY, ch, jsonStyle)
 	}
 
 	// KML button
@@ -697,10 +1250,478 @@ func drawExportModal(s tcell.Screen, exportModal *ExportModalState) {
 	}
 	kmlX := modalX + (modalWidth-len(kmlButton))/2
 	for i, ch := range kmlButton {
-		s.SetContent(kmlX+i, buttonY+2, ch, nil, kmlStyle)
+		setCell(s, kmlX+i, buttonY+2, ch, kmlStyle)
+	}
+
+	// Heatmap button
+	heatmapButton := "[H] Export Heatmap"
+	heatmapStyle := buttonNormal
+	if selected == 2 {
+		heatmapStyle = buttonSelected
+		heatmapButton = "► [H] Export Heatmap ◄"
+	}
+	heatmapX := modalX + (modalWidth-len([]rune(heatmapButton)))/2
+	for i, ch := range []rune(heatmapButton) {
+		setCell(s, heatmapX+i, buttonY+4, ch, heatmapStyle)
+	}
+
+	// KMZ button
+	kmzButton := "[Z] Export KMZ"
+	kmzStyle := buttonNormal
+	if selected == 3 {
+		kmzStyle = buttonSelected
+		kmzButton = "► [Z] Export KMZ ◄"
+	}
+	kmzX := modalX + (modalWidth-len([]rune(kmzButton)))/2
+	for i, ch := range []rune(kmzButton) {
+		setCell(s, kmzX+i, buttonY+6, ch, kmzStyle)
+	}
+
+	// Export scope, toggled with 'a'
+	if scope != "" {
+		drawCenteredText(s, modalX, buttonY+8, modalWidth, bgStyle, scope)
 	}
 
 	// Draw navigation hint
 	hint := "↑↓/Tab: Navigate | Enter: Select | ESC: Cancel"
 	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
 }
+
+// drawModalBox draws a bordered modal frame with a centered title on row 1
+func drawModalBox(s tcell.Screen, modalX, modalY, modalWidth, modalHeight int, borderStyle, bgStyle tcell.Style, title string) {
+	// Draw modal background
+	for y := modalY; y < modalY+modalHeight; y++ {
+		for x := modalX; x < modalX+modalWidth; x++ {
+			setCell(s, x, y, ' ', bgStyle)
+		}
+	}
+
+	// Draw border
+	for x := modalX; x < modalX+modalWidth; x++ {
+		setCell(s, x, modalY, '═', borderStyle)
+		setCell(s, x, modalY+modalHeight-1, '═', borderStyle)
+	}
+	for y := modalY; y < modalY+modalHeight; y++ {
+		setCell(s, modalX, y, '║', borderStyle)
+		setCell(s, modalX+modalWidth-1, y, '║', borderStyle)
+	}
+	setCell(s, modalX, modalY, '╔', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY, '╗', borderStyle)
+	setCell(s, modalX, modalY+modalHeight-1, '╚', borderStyle)
+	setCell(s, modalX+modalWidth-1, modalY+modalHeight-1, '╝', borderStyle)
+
+	// Draw title
+	titleX := modalX + (modalWidth-len([]rune(title)))/2
+	for i, ch := range []rune(title) {
+		setCell(s, titleX+i, modalY+1, ch, borderStyle)
+	}
+}
+
+// drawClassLegend draws a small key of the class group row tints above the status line
+func drawClassLegend(s tcell.Screen) {
+	width, height := s.Size()
+	legendWidth := 22
+	legendHeight := len(classGroupOrder) + 4
+	legendX := width - legendWidth - 1
+	legendY := height - legendHeight - 1
+	if legendX < 0 || legendY < 0 {
+		return
+	}
+
+	borderStyle := theme.Detail.Border
+	bgStyle := theme.Detail.Body
+	drawModalBox(s, legendX, legendY, legendWidth, legendHeight, borderStyle, bgStyle, " CLASSES ")
+
+	for i, group := range classGroupOrder {
+		style := theme.Row
+		if tint, ok := theme.ClassTints[group]; ok {
+			style = style.Background(tint)
+		}
+		drawText(s, legendX+2, legendY+3+i, legendWidth-4, style, " "+group)
+	}
+}
+
+// wrapText splits text into lines of at most width runes
+func wrapText(text string, width int) []string {
+	runes := []rune(text)
+	if width <= 0 || len(runes) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
+
+// buildDetailLines builds the full, untruncated detail text for a device, wrapped to width
+func buildDetailLines(dev *BLEDevice, width int) []string {
+	var lines []string
+	add := func(label, value string) {
+		lines = append(lines, wrapText(fmt.Sprintf("%-14s %s", label+":", value), width)...)
+	}
+
+	add("MAC Address", dev.MacAddress)
+	if vendor := lookupVendor(dev.MacAddress); vendor != "" {
+		add("Vendor", vendor)
+	}
+	name := dev.DeviceName
+	if name == "" {
+		name = "(unnamed)"
+	}
+	add("Device Name", name)
+	if class := classifyDevice(dev); class != "" {
+		add("Class", class+" (heuristic)")
+	}
+	add("RSSI", fmt.Sprintf("%d dBm (avg %d dBm)", dev.RSSI, dev.SmoothedRSSI()))
+	add("RSSI Range", fmt.Sprintf("%d to %d dBm (mean %.1f dBm over %d)", dev.RSSIMin, dev.RSSIMax, dev.RSSIMean, dev.Count))
+	if len(dev.SourceRSSI) > 0 {
+		sources := make([]string, 0, len(dev.SourceRSSI))
+		for source := range dev.SourceRSSI {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		lines = append(lines, "RSSI by source:")
+		for _, source := range sources {
+			line := fmt.Sprintf("  %-20s %d dBm", source, dev.SourceRSSI[source])
+			if source == dev.Source {
+				line += " (latest)"
+			}
+			lines = append(lines, wrapText(line, width)...)
+		}
+	}
+	if len(dev.Aliases) > 0 {
+		add("Also Seen As", strings.Join(dev.Aliases, ", ")+" (rotated, heuristic)")
+	}
+	if dev.Protocol != "" {
+		add("Protocol", dev.Protocol)
+	}
+	if dev.Connectable != nil {
+		add("Connectable", map[bool]string{true: "yes", false: "no"}[*dev.Connectable])
+	}
+	add("Count", fmt.Sprintf("%d", dev.Count))
+	add("Rate", fmt.Sprintf("%d adv/s", dev.AdvRate()))
+	if dev.RSSIHistory != nil {
+		add("RSSI History", renderSparkline(dev.RSSIHistory.Peek(rssiHistoryCapacity), rssiHistoryCapacity))
+	}
+	add("First Seen", formatDisplayTime(dev.FirstSeen, displayTimeLayout))
+	add("Last Seen", formatDisplayTime(dev.LastSeen, displayTimeLayout))
+
+	mfrCode := "(none)"
+	if dev.MfrCode != 0 {
+		mfrCode = formatMfrCode(dev.MfrCode)
+	}
+	add("Mfr ID", mfrCode)
+
+	mfrData := dev.MfrData
+	if mfrData == "" {
+		mfrData = "(none)"
+	}
+	add("Mfr Data", mfrData)
+	if dev.MfrHistory != nil && dev.MfrHistory.Size() > 1 {
+		// Newest first, so the latest changes are visible without scrolling
+		history := dev.MfrHistory.GetAll()
+		lines = append(lines, "Mfr Data history (newest first):")
+		for i := len(history) - 1; i >= 0; i-- {
+			lines = append(lines, wrapText(fmt.Sprintf("  %s  %s", formatDisplayTime(history[i].At, "15:04:05"), history[i].Data), width)...)
+		}
+	}
+	if dev.MfrCode == appleCompanyID {
+		if beacon, ok := decodeIBeacon(dev.MfrData); ok {
+			add("iBeacon UUID", beacon.UUID)
+			add("iBeacon", fmt.Sprintf("major %d, minor %d, measured power %d dBm", beacon.Major, beacon.Minor, beacon.MeasuredPower))
+		}
+	}
+
+	// Service UUIDs, one per line
+	lines = append(lines, "")
+	if len(dev.ServiceUUIDs) == 0 {
+		add("Service UUIDs", "(none)")
+	} else {
+		lines = append(lines, "Service UUIDs:")
+		for _, uuid := range dev.ServiceUUIDs {
+			lines = append(lines, wrapText("  "+formatServiceUUID(uuid), width)...)
+		}
+	}
+
+	// Eddystone frame decoded from FEAA service data
+	if payload, ok := findEddystoneData(dev); ok {
+		lines = append(lines, "")
+		if frame, ok := decodeEddystone(payload); ok {
+			lines = append(lines, "Eddystone:")
+			switch frame.FrameType {
+			case "UID":
+				add("  Namespace", frame.Namespace)
+				add("  Instance", frame.Instance)
+				add("  TX Power", fmt.Sprintf("%d dBm", frame.TxPower))
+			case "URL":
+				add("  URL", frame.URL)
+				add("  TX Power", fmt.Sprintf("%d dBm", frame.TxPower))
+			case "TLM":
+				add("  Battery", fmt.Sprintf("%.3f V", float64(frame.BatteryMV)/1000))
+				add("  Temperature", fmt.Sprintf("%.1f °C", frame.TemperatureC))
+				add("  Adv Count", fmt.Sprintf("%d", frame.AdvCount))
+				add("  Uptime", (time.Duration(frame.Uptime) * time.Second).String())
+			case "EID":
+				add("  Ephemeral ID", frame.EphemeralID)
+			}
+		} else {
+			add("Eddystone", payload+" (undecoded)")
+		}
+	}
+
+	// Mean location for each of the strongest stored RSSIs
+	lines = append(lines, "")
+	var topLocations []RSSILocation
+	if dev.GeoData != nil {
+		topLocations = dev.GeoData.GetTopLocations(3)
+	}
+	if len(topLocations) == 0 {
+		add("Locations", "(none)")
+	} else {
+		lines = append(lines, "Locations (strongest RSSI first):")
+		for _, rl := range topLocations {
+			lines = append(lines, wrapText(fmt.Sprintf("  %4d dBm  %.6f, %.6f  (%d samples)",
+				rl.RSSI, rl.Location.Latitude, rl.Location.Longitude, rl.Samples), width)...)
+		}
+	}
+
+	return lines
+}
+
+// drawDetailModal draws a modal with the full details of a single device
+func drawDetailModal(s tcell.Screen, detailModal *DetailModalState, dev *BLEDevice) {
+	width, height := s.Size()
+
+	// Modal dimensions (as large as fits, up to 90 columns)
+	modalWidth := min(90, width-4)
+	modalHeight := height - 4
+	if modalWidth < 20 || modalHeight < 6 {
+		return
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	// Styles
+	borderStyle := theme.Detail.Border
+	bgStyle := theme.Detail.Body
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, " DEVICE DETAILS ")
+
+	// Content area sits inside the border, below the title, above the hint
+	contentX := modalX + 2
+	contentWidth := modalWidth - 4
+	contentY := modalY + 3
+	contentHeight := modalHeight - 5
+
+	var lines []string
+	if dev == nil {
+		lines = []string{"Device no longer present (cleared)."}
+	} else {
+		lines = buildDetailLines(dev, contentWidth)
+	}
+
+	// Clamp scroll to content
+	maxScroll := max(0, len(lines)-contentHeight)
+	if detailModal.scrollOffset > maxScroll {
+		detailModal.scrollOffset = maxScroll
+	}
+
+	for i := 0; i < contentHeight && detailModal.scrollOffset+i < len(lines); i++ {
+		drawText(s, contentX, contentY+i, contentWidth, bgStyle, lines[detailModal.scrollOffset+i])
+	}
+
+	// Draw navigation hint
+	hint := "↑↓/jk: Scroll | ESC: Close"
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
+
+// drawNoticesModal draws the firmware notifications, newest first, with timestamps
+func drawNoticesModal(s tcell.Screen, noticesModal *NoticesModalState, entries []notificationEntry) {
+	width, height := s.Size()
+
+	// Modal dimensions (as large as fits, up to 90 columns)
+	modalWidth := min(90, width-4)
+	modalHeight := height - 4
+	if modalWidth < 20 || modalHeight < 6 {
+		return
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	borderStyle := theme.Detail.Border
+	bgStyle := theme.Detail.Body
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, fmt.Sprintf(" NOTIFICATIONS (%d) ", len(entries)))
+
+	contentX := modalX + 2
+	contentWidth := modalWidth - 4
+	contentY := modalY + 3
+	contentHeight := modalHeight - 5
+
+	var lines []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		lines = append(lines, wrapText(formatDisplayTime(entry.At, "15:04:05")+"  "+entry.Text, contentWidth)...)
+	}
+	if len(lines) == 0 {
+		lines = []string{"No notifications received."}
+	}
+
+	// Clamp scroll to content
+	maxScroll := max(0, len(lines)-contentHeight)
+	if noticesModal.scrollOffset > maxScroll {
+		noticesModal.scrollOffset = maxScroll
+	}
+
+	for i := 0; i < contentHeight && noticesModal.scrollOffset+i < len(lines); i++ {
+		drawText(s, contentX, contentY+i, contentWidth, bgStyle, lines[noticesModal.scrollOffset+i])
+	}
+
+	hint := "↑↓/jk: Scroll | ESC: Close"
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
+
+// drawWatchModal draws the watchlist editor: an input line for adding a MAC and the current entries
+func drawWatchModal(s tcell.Screen, watchModal *WatchModalState, watchlist *Watchlist) {
+	width, height := s.Size()
+
+	macs := watchlist.List()
+
+	// Modal dimensions (grow with the list, up to the screen height)
+	modalWidth := min(60, width-4)
+	modalHeight := min(max(len(macs), 1)+9, height-4)
+	if modalWidth < 30 || modalHeight < 10 {
+		return
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	// Styles
+	borderStyle := theme.Watch.Border
+	bgStyle := theme.Watch.Body
+	inputStyle := theme.Input
+	selectedStyle := theme.ListSelected
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, " WATCHLIST ")
+
+	contentX := modalX + 2
+	contentWidth := modalWidth - 4
+
+	// Draw input line with a trailing cursor
+	drawText(s, contentX, modalY+3, 5, bgStyle, "Add:")
+	drawText(s, contentX+5, modalY+3, contentWidth-5, inputStyle, watchModal.input+"_")
+
+	// Clamp selection to the list
+	if watchModal.selected >= len(macs) {
+		watchModal.selected = max(0, len(macs)-1)
+	}
+
+	// Draw watched MACs, scrolled so the selection stays visible
+	listY := modalY + 5
+	listHeight := modalHeight - 8
+	if len(macs) == 0 {
+		drawText(s, contentX, listY, contentWidth, bgStyle, "(empty)")
+	}
+	start := max(0, watchModal.selected-listHeight+1)
+	for i := 0; i < listHeight && start+i < len(macs); i++ {
+		style := bgStyle
+		if start+i == watchModal.selected {
+			style = selectedStyle
+		}
+		drawText(s, contentX, listY+i, contentWidth, style, macs[start+i])
+	}
+
+	// Draw navigation hint
+	hint := "Enter: Add | ↑↓: Select | Del: Remove | ESC: Close"
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
+
+// drawMfrFilterModal draws the manufacturer code picker
+// The first list entry clears the filter; the rest are the codes currently present with their device counts
+func drawMfrFilterModal(s tcell.Screen, mfrModal *MfrFilterModalState, codes []mfrCodeCount, filter *DeviceFilter) {
+	width, height := s.Size()
+
+	// Modal dimensions (grow with the list, up to the screen height)
+	modalWidth := min(60, width-4)
+	modalHeight := min(len(codes)+9, height-4)
+	if modalWidth < 30 || modalHeight < 10 {
+		return
+	}
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	// Styles
+	borderStyle := theme.Watch.Border
+	bgStyle := theme.Watch.Body
+	inputStyle := theme.Input
+	selectedStyle := theme.ListSelected
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, " FILTER BY MANUFACTURER ")
+
+	contentX := modalX + 2
+	contentWidth := modalWidth - 4
+
+	// Draw input line with a trailing cursor
+	drawText(s, contentX, modalY+3, 6, bgStyle, "Code:")
+	drawText(s, contentX+6, modalY+3, contentWidth-6, inputStyle, mfrModal.input+"_")
+
+	// Entry 0 clears the filter
+	entries := make([]string, 0, len(codes)+1)
+	entries = append(entries, "(all manufacturers)")
+	for _, c := range codes {
+		entry := fmt.Sprintf("%s - %d devices", formatMfrCode(c.Code), c.Count)
+		if filter.mfrActive && filter.mfrCode == c.Code {
+			entry += " *"
+		}
+		entries = append(entries, entry)
+	}
+
+	// Clamp selection to the list
+	if mfrModal.selected >= len(entries) {
+		mfrModal.selected = len(entries) - 1
+	}
+
+	// Draw entries, scrolled so the selection stays visible
+	listY := modalY + 5
+	listHeight := modalHeight - 8
+	start := max(0, mfrModal.selected-listHeight+1)
+	for i := 0; i < listHeight && start+i < len(entries); i++ {
+		style := bgStyle
+		if start+i == mfrModal.selected {
+			style = selectedStyle
+		}
+		drawText(s, contentX, listY+i, contentWidth, style, entries[start+i])
+	}
+
+	// Draw navigation hint
+	hint := "Enter: Apply | ↑↓: Select | ESC: Close"
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
+
+// drawClearModal draws a red confirmation modal before clearing all devices
+func drawClearModal(s tcell.Screen, clearModal *ClearModalState) {
+	width, height := s.Size()
+
+	// Modal dimensions
+	modalWidth := 50
+	modalHeight := 8
+	modalX := max(0, (width-modalWidth)/2)
+	modalY := max(0, (height-modalHeight)/2)
+
+	// Styles
+	borderStyle := theme.Clear.Border
+	bgStyle := theme.Clear.Body
+
+	drawModalBox(s, modalX, modalY, modalWidth, modalHeight, borderStyle, bgStyle, " CLEAR DEVICES ")
+
+	// Draw question
+	question := fmt.Sprintf("Clear all %d devices? [y/N]", clearModal.count)
+	drawCenteredText(s, modalX, modalY+3, modalWidth, borderStyle, question)
+
+	// Draw navigation hint
+	hint := "y: Clear (u undoes) | any other key: Cancel"
+	drawCenteredText(s, modalX, modalY+modalHeight-2, modalWidth, bgStyle, hint)
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	json "github.com/goccy/go-json"
+)
+
+// boundsScreen is a simulation screen that counts cells written outside it
+type boundsScreen struct {
+	tcell.SimulationScreen
+	outside []string
+}
+
+func (s *boundsScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if width, height := s.Size(); x < 0 || y < 0 || x >= width || y >= height {
+		s.outside = append(s.outside, fmt.Sprintf("(%d,%d)", x, y))
+	}
+	s.SimulationScreen.SetContent(x, y, primary, combining, style)
+}
+
+// newTestApp returns an App drawing to a width x height simulation screen, with one connected input
+func newTestApp(t *testing.T, width, height int) (*App, *boundsScreen) {
+	t.Helper()
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sim.Fini)
+	sim.SetSize(width, height)
+	s := &boundsScreen{SimulationScreen: sim}
+
+	connState := &ConnectionSet{}
+	connState.Add("stdin").SetConnected(true)
+	app := &App{
+		screen:       s,
+		agg:          NewAggregator(defaultStaleAfter),
+		connState:    connState,
+		locState:     NewLocationState(),
+		tableState:   &TableState{focusedTable: "near"},
+		exportModal:  &ExportModalState{},
+		detailModal:  &DetailModalState{},
+		watchModal:   &WatchModalState{},
+		watchlist:    NewWatchlist(),
+		proximity:    &ProximityState{},
+		clearModal:   &ClearModalState{},
+		mfrModal:     &MfrFilterModalState{},
+		noticesModal: &NoticesModalState{},
+		helpModal:    &HelpModalState{},
+	}
+	return app, s
+}
+
+// feedDevices ingests one advertisement per line, as read from the serial port
+func feedDevices(app *App, lines ...string) {
+	ing := &Ingester{agg: app.agg, locState: app.locState, watchlist: app.watchlist}
+	for _, line := range lines {
+		ing.processSerialLine([]byte(line))
+	}
+}
+
+// screenText returns the screen contents, one line per row
+func screenText(s tcell.SimulationScreen) string {
+	cells, width, height := s.GetContents()
+	var b strings.Builder
+	for y := range height {
+		for x := range width {
+			if runes := cells[y*width+x].Runes; len(runes) > 0 {
+				b.WriteRune(runes[0])
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// statusLine returns the bottom row of the screen with trailing blanks trimmed
+func statusLine(s tcell.SimulationScreen) string {
+	lines := strings.Split(strings.TrimSuffix(screenText(s), "\n"), "\n")
+	return strings.TrimRight(lines[len(lines)-1], " ")
+}
+
+// pressKey handles one key press as the event loop would
+func pressKey(app *App, key tcell.Key, r rune) {
+	handleKeyboardEvent(tcell.NewEventKey(key, r, tcell.ModNone), app)
+}
+
+func TestDrawTable(t *testing.T) {
+	app, s := newTestApp(t, 120, 40)
+	feedDevices(app, `{"mac_address":"28:6F:B9:00:00:01","rssi":-55,"device_name":"phone","mfr_code":76}`)
+
+	app.redraw()
+
+	text := screenText(s)
+	for _, want := range []string{"RECENT DEVICES", "STALE DEVICES", "28:6F:B9:00:00:01", "phone"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("screen is missing %q", want)
+		}
+	}
+}
+
+func TestDrawTableTinyScreen(t *testing.T) {
+	views := []struct {
+		name  string
+		setup func(app *App)
+	}{
+		{name: "default", setup: func(app *App) {}},
+		{name: "scrolled right", setup: func(app *App) { app.tableState.colOffset = 100 }},
+		{name: "stale focused", setup: func(app *App) { app.tableState.focusedTable = "far" }},
+		{name: "single table", setup: func(app *App) { app.tableState.singleTable = true }},
+		{name: "scrolled down", setup: func(app *App) { app.tableState.nearScrollOffset, app.tableState.nearSelected = 50, 50 }},
+		{name: "details", setup: func(app *App) { pressKey(app, tcell.KeyEnter, 0) }},
+		{name: "export", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'e') }},
+		{name: "clear", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'c') }},
+		{name: "find", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'f') }},
+		{name: "graph", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'd') }},
+		{name: "watchlist", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'w') }},
+		{name: "mfr filter", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'm') }},
+		{name: "class legend", setup: func(app *App) { pressKey(app, tcell.KeyRune, 'B') }},
+		{name: "disconnected", setup: func(app *App) { app.connState.Add("/dev/ttyUSB0").SetError(fmt.Errorf("gone")) }},
+	}
+
+	for _, size := range [][2]int{{20, 10}, {1, 1}, {5, 3}, {79, 4}} {
+		for _, view := range views {
+			t.Run(fmt.Sprintf("%dx%d %s", size[0], size[1], view.name), func(t *testing.T) {
+				app, s := newTestApp(t, size[0], size[1])
+				feedDevices(app,
+					`{"mac_address":"28:6f:b9:00:00:01","rssi":-55,"device_name":"a rather long device name","mfr_code":76,"mfr_data":"0215aabbccddeeff","service_uuids":["180f","fd44","feaa"]}`,
+					`{"mac_address":"2a:00:00:00:00:02","rssi":-75}`,
+					`{"mac_address":"f4:ea:b5:00:00:03","rssi":-90,"mfr_code":6}`,
+				)
+				view.setup(app)
+
+				app.redraw()
+
+				if len(s.outside) > 0 {
+					t.Errorf("%d cells written outside the screen, first %s", len(s.outside), s.outside[0])
+				}
+			})
+		}
+	}
+}
+
+func TestStatusLineLeadsWithLiveFields(t *testing.T) {
+	app, s := newTestApp(t, 120, 30)
+	feedDevices(app,
+		`{"mac_address":"28:6f:b9:00:00:01","rssi":-55}`,
+		`{"mac_address":"2a:00:00:00:00:02","rssi":-75}`,
+	)
+	app.locState.ToggleGPSPaused()
+
+	app.redraw()
+
+	status := statusLine(s)
+	for _, want := range []string{"✓ CONNECTED", "GPS TAGGING PAUSED (z)", "2 devices, 0 adv/s", "Focus: RECENT"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status line %q is missing %q", status, want)
+		}
+	}
+	if !strings.HasSuffix(status, "?: Help") {
+		t.Errorf("status line %q doesn't end with the help hint", status)
+	}
+	if strings.Contains(status, "q: Quit") {
+		t.Errorf("status line %q still lists keys", status)
+	}
+}
+
+func TestStatusLineNarrowKeepsConnection(t *testing.T) {
+	app, s := newTestApp(t, 30, 10)
+
+	app.redraw()
+
+	if status := statusLine(s); !strings.HasPrefix(status, "✓ CONNECTED") {
+		t.Errorf("status line %q doesn't start with the connection state", status)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	app, s := newTestApp(t, 100, 50)
+
+	pressKey(app, tcell.KeyRune, '?')
+	if !app.helpModal.IsShowing() {
+		t.Fatal("? didn't open the help overlay")
+	}
+	text := screenText(s)
+	for _, want := range []string{"KEYS", "Quit", "Manufacturer code", "Named devices only"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("help overlay is missing %q", want)
+		}
+	}
+
+	// Keys go to the overlay, not the tables, until it is closed
+	pressKey(app, tcell.KeyRune, 'n')
+	if app.filter.IsActive() {
+		t.Error("n changed the filter while the help overlay was open")
+	}
+	pressKey(app, tcell.KeyRune, '?')
+	if app.helpModal.IsShowing() {
+		t.Error("? didn't close the help overlay")
+	}
+}
+
+func TestStatusLineShowsMfrFilterWithGPSFix(t *testing.T) {
+	app, s := newTestApp(t, 80, 20)
+	feedDevices(app,
+		`{"mac_address":"28:6f:b9:00:00:01","rssi":-55,"mfr_code":76}`,
+		`{"mac_address":"f4:ea:b5:00:00:03","rssi":-90,"mfr_code":6}`,
+	)
+	parseNMEASentence(nmeaGGAFix, app.locState, &nmeaReaderState{})
+	app.filter.SetMfrCode(76)
+
+	app.redraw()
+
+	status := statusLine(s)
+	if !strings.Contains(status, "[filter: mfr=76]") {
+		t.Errorf("status line %q is missing the manufacturer filter", status)
+	}
+	if filterAt, gpsAt := strings.Index(status, "[filter:"), strings.Index(status, "GPS: Fix"); gpsAt < 0 || filterAt > gpsAt {
+		t.Errorf("status line %q doesn't show the filter ahead of the GPS fix", status)
+	}
+}
+
+func TestStatusLineNamedFilterClampsRows(t *testing.T) {
+	app, s := newTestApp(t, 120, 20)
+	feedDevices(app,
+		`{"mac_address":"28:6f:b9:00:00:01","rssi":-55,"device_name":"phone"}`,
+		`{"mac_address":"2a:00:00:00:00:02","rssi":-75}`,
+		`{"mac_address":"2a:00:00:00:00:03","rssi":-80}`,
+	)
+	// Scrolled to the last row before the filter shrinks the list to one device
+	app.tableState.nearScrollOffset, app.tableState.nearSelected = 2, 2
+	app.filter.ToggleNamedOnly()
+
+	app.redraw()
+
+	status := statusLine(s)
+	if !strings.Contains(status, "[filter: named]") {
+		t.Errorf("status line %q is missing the named filter", status)
+	}
+	if !strings.Contains(status, "Focus: RECENT (row 1-1 of 1)") {
+		t.Errorf("status line %q doesn't report the clamped rows", status)
+	}
+	if state := app.tableState; state.nearScrollOffset != 0 || state.nearSelected != 0 {
+		t.Errorf("scroll offset, cursor = %d, %d, want 0, 0", state.nearScrollOffset, state.nearSelected)
+	}
+}
+
+func TestSnapshotMode(t *testing.T) {
+	app, s := newTestApp(t, 120, 20)
+	feedDevices(app, `{"mac_address":"28:6f:b9:00:00:01","rssi":-55}`)
+
+	pressKey(app, tcell.KeyRune, 'P')
+	feedDevices(app, `{"mac_address":"2a:00:00:00:00:02","rssi":-75}`)
+	app.redraw()
+
+	if got := len(app.view().All()); got != 1 {
+		t.Errorf("snapshot shows %d devices, want the 1 captured", got)
+	}
+	status := statusLine(s)
+	if !strings.HasPrefix(status, "[SNAPSHOT ") || !strings.Contains(status, "2 devices") {
+		t.Errorf("status line %q doesn't lead with the snapshot and count live devices", status)
+	}
+	cells, _, _ := s.GetContents()
+	if cells[0].Style != theme.TitleSnapshot {
+		t.Error("table title isn't drawn in the snapshot style")
+	}
+
+	pressKey(app, tcell.KeyRune, 'P')
+	if app.InSnapshot() || app.IsPaused() {
+		t.Fatal("P didn't return to live")
+	}
+	if got := len(app.view().All()); got != 2 {
+		t.Errorf("live view shows %d devices, want 2", got)
+	}
+}
+
+func TestPauseIsNotSnapshot(t *testing.T) {
+	app, s := newTestApp(t, 120, 20)
+	feedDevices(app, `{"mac_address":"28:6f:b9:00:00:01","rssi":-55}`)
+
+	pressKey(app, tcell.KeyRune, 'p')
+
+	status := statusLine(s)
+	if !strings.HasPrefix(status, "[PAUSED") {
+		t.Errorf("status line %q doesn't lead with the pause", status)
+	}
+	if app.InSnapshot() || strings.Contains(screenText(s), "SNAPSHOT") {
+		t.Error("pausing entered snapshot mode")
+	}
+	cells, _, _ := s.GetContents()
+	if cells[0].Style == theme.TitleSnapshot {
+		t.Error("paused table title is drawn in the snapshot style")
+	}
+
+	// Taking a snapshot replaces the pause
+	pressKey(app, tcell.KeyRune, 'P')
+	if !app.InSnapshot() || app.IsPaused() {
+		t.Errorf("in snapshot, paused = %v, %v, want true, false", app.InSnapshot(), app.IsPaused())
+	}
+}
+
+func TestSnapshotExport(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      rune
+		devices  int
+		snapshot bool
+	}{
+		{name: "snapshot", key: 'P', devices: 1, snapshot: true},
+		{name: "paused", key: 'p', devices: 2, snapshot: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _ := newTestApp(t, 120, 20)
+			app.exports = ExportPaths{dir: t.TempDir()}
+			feedDevices(app, `{"mac_address":"28:6f:b9:00:00:01","rssi":-55}`)
+			pressKey(app, tcell.KeyRune, tt.key)
+			feedDevices(app, `{"mac_address":"2a:00:00:00:00:02","rssi":-75}`)
+
+			handleExport(app, false)
+
+			matches, _ := filepath.Glob(filepath.Join(app.exports.dir, "*.json"))
+			if len(matches) != 1 {
+				t.Fatalf("exported files = %v, want one", matches)
+			}
+			if got := strings.Contains(filepath.Base(matches[0]), "_snapshot_"); got != tt.snapshot {
+				t.Errorf("export %s named as a snapshot = %v, want %v", matches[0], got, tt.snapshot)
+			}
+			data, err := os.ReadFile(matches[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var devices []map[string]any
+			if err := json.Unmarshal(data, &devices); err != nil {
+				t.Fatal(err)
+			}
+			if len(devices) != tt.devices {
+				t.Errorf("exported %d devices, want %d", len(devices), tt.devices)
+			}
+		})
+	}
+}
+
+func TestUndoClearRestoresSession(t *testing.T) {
+	app, s := newTestApp(t, 120, 20)
+	app.session = NewSessionStats(time.Now().Add(-time.Hour))
+	feedDevices(app,
+		`{"mac_address":"28:6f:b9:00:00:01","rssi":-55}`,
+		`{"mac_address":"2a:00:00:00:00:02","rssi":-75}`,
+	)
+
+	pressKey(app, tcell.KeyRune, 'c')
+	pressKey(app, tcell.KeyRune, 'y')
+	if elapsed := app.session.Elapsed(time.Now()); elapsed > time.Minute {
+		t.Errorf("elapsed after clear = %v, want a new session", elapsed)
+	}
+
+	pressKey(app, tcell.KeyRune, 'u')
+	if elapsed := app.session.Elapsed(time.Now()); elapsed < time.Hour {
+		t.Errorf("elapsed after undo = %v, want the hour-old session back", elapsed)
+	}
+	if status := statusLine(s); !strings.Contains(status, "elapsed: 01:00:") || !strings.Contains(status, "peak: 2") {
+		t.Errorf("status line %q doesn't show the restored session", status)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimum time between alert sounds for the same watched MAC
+const watchAlertInterval = 3 * time.Second
+
+// Watchlist holds MAC addresses that trigger an alert when observed
+type Watchlist struct {
+	mu        sync.RWMutex
+	macs      map[string]bool
+	lastAlert map[string]time.Time
+}
+
+// NewWatchlist creates an empty watchlist
+func NewWatchlist() *Watchlist {
+	return &Watchlist{
+		macs:      make(map[string]bool),
+		lastAlert: make(map[string]time.Time),
+	}
+}
+
+// normalizeMAC canonicalizes a MAC address to uppercase colon-separated form (AA:BB:CC:DD:EE:FF)
+// Accepts ':', '-' or '.' separators, or none; returns "" if mac isn't 12 hex digits or is all zeros
+func normalizeMAC(mac string) string {
+	mac = strings.TrimSpace(mac)
+	digits := make([]byte, 0, 12)
+	for i := 0; i < len(mac); i++ {
+		ch := mac[i]
+		switch {
+		case ch >= '0' && ch <= '9', ch >= 'A' && ch <= 'F':
+		case ch >= 'a' && ch <= 'f':
+			ch -= 'a' - 'A'
+		case ch == ':' || ch == '-' || ch == '.':
+			continue
+		default:
+			return ""
+		}
+		digits = append(digits, ch)
+	}
+	if len(digits) != 12 || strings.Trim(string(digits), "0") == "" {
+		return ""
+	}
+
+	var canonical strings.Builder
+	canonical.Grow(17)
+	for i := 0; i < 12; i += 2 {
+		if i > 0 {
+			canonical.WriteByte(':')
+		}
+		canonical.Write(digits[i : i+2])
+	}
+	return canonical.String()
+}
+
+// Add adds a MAC address to the watchlist
+func (w *Watchlist) Add(mac string) {
+	mac = normalizeMAC(mac)
+	if mac == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.macs[mac] = true
+}
+
+// Remove removes a MAC address from the watchlist
+func (w *Watchlist) Remove(mac string) {
+	mac = normalizeMAC(mac)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.macs, mac)
+	delete(w.lastAlert, mac)
+}
+
+// Contains reports whether a MAC address is being watched
+func (w *Watchlist) Contains(mac string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.macs[normalizeMAC(mac)]
+}
+
+// List returns the watched MAC addresses in sorted order
+func (w *Watchlist) List() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	macs := make([]string, 0, len(w.macs))
+	for mac := range w.macs {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// ShouldAlert reports whether an alert should sound for a sighting of mac at now
+// Alerts for the same MAC are debounced to at most one per watchAlertInterval
+func (w *Watchlist) ShouldAlert(mac string, now time.Time) bool {
+	mac = normalizeMAC(mac)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.macs[mac] {
+		return false
+	}
+	if last, ok := w.lastAlert[mac]; ok && now.Sub(last) < watchAlertInterval {
+		return false
+	}
+	w.lastAlert[mac] = now
+	return true
+}
+
+// parseWatchFlag parses the -watch flag value
+// If the value names an existing file, MACs are read from it one per line ('#' starts a comment);
+// otherwise the value is treated as a comma-separated list
+func parseWatchFlag(value string) ([]string, error) {
+	var entries []string
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			entries = append(entries, line)
+		}
+	} else {
+		entries = strings.Split(value, ",")
+	}
+
+	var macs []string
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		mac := normalizeMAC(entry)
+		if mac == "" {
+			return nil, fmt.Errorf("invalid MAC address %q", strings.TrimSpace(entry))
+		}
+		macs = append(macs, mac)
+	}
+	return macs, nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// Messages queued per WebSocket client before new ones are dropped for that client
+const wsClientBufferSize = 256
+
+// Time allowed to write one frame before a client is considered dead
+const wsWriteTimeout = 10 * time.Second
+
+// GUID appended to the client key during the WebSocket handshake (RFC 6455 section 1.3)
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes used by the server
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// WSHub fans observations out to connected WebSocket clients
+// Each client has its own buffered queue, so a slow client only loses its own messages
+type WSHub struct {
+	mu      sync.RWMutex
+	clients map[*wsClient]struct{}
+	dropped atomic.Uint64
+}
+
+// wsClient is one connected WebSocket client
+type wsClient struct {
+	conn net.Conn
+	send chan []byte
+	pong chan []byte // Ping payloads awaiting a pong, sent by the writer so frames never interleave
+	once sync.Once
+	done chan struct{} // Closed when the client disconnects
+}
+
+// NewWSHub creates a hub with no clients
+func NewWSHub() *WSHub {
+	return &WSHub{clients: make(map[*wsClient]struct{})}
+}
+
+// Broadcast encodes rec once and queues it for every client, dropping it for clients whose queue is full
+func (h *WSHub) Broadcast(rec StreamRecord) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.clients) == 0 {
+		return
+	}
+	msg, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	for client := range h.clients {
+		select {
+		case client.send <- msg:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+// ClientCount returns the number of connected clients
+func (h *WSHub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Dropped returns how many messages were discarded across all clients because their queue was full
+func (h *WSHub) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// ServeHTTP upgrades the request to a WebSocket and pushes observations until the client goes away
+func (h *WSHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	accept, err := wsAcceptKey(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := wsUpgrade(w, accept)
+	if err != nil {
+		return
+	}
+
+	client := &wsClient{
+		conn: conn,
+		send: make(chan []byte, wsClientBufferSize),
+		pong: make(chan []byte, 1),
+		done: make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, client)
+		h.mu.Unlock()
+		conn.Close()
+	}()
+
+	go client.readLoop(rw.Reader)
+	client.writeLoop()
+}
+
+// Close disconnects every client
+func (h *WSHub) Close() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		client.close()
+	}
+}
+
+// close marks the client done; safe to call more than once
+func (c *wsClient) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// writeLoop sends queued messages as text frames until the client disconnects
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			c.writeFrame(wsOpClose, nil)
+			return
+		case payload := <-c.pong:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				c.close()
+				return
+			}
+		case msg := <-c.send:
+			if err := c.writeFrame(wsOpText, msg); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// readLoop consumes client frames, answering pings and stopping on close or error
+// Data sent by the client is ignored; the endpoint is push-only
+func (c *wsClient) readLoop(r *bufio.Reader) {
+	defer c.close()
+	for {
+		opcode, payload, err := readWSFrame(r)
+		if err != nil || opcode == wsOpClose {
+			return
+		}
+		if opcode == wsOpPing {
+			select {
+			case c.pong <- payload:
+			default: // A pong is already pending
+			}
+		}
+	}
+}
+
+// writeFrame writes a single unmasked, unfragmented frame
+func (c *wsClient) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode} // FIN + opcode
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readWSFrame reads one frame from a client, unmasking its payload
+func readWSFrame(r *bufio.Reader) (opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > 1<<16 {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// wsAcceptKey validates a WebSocket opening handshake and returns the Sec-WebSocket-Accept value for it
+func wsAcceptKey(r *http.Request) (string, error) {
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return "", errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return "", errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return "", errors.New("missing Sec-WebSocket-Key")
+	}
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// wsUpgrade hijacks the connection and completes the handshake with the given accept value
+func wsUpgrade(w http.ResponseWriter, accept string) (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be upgraded")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// headerContainsToken reports whether a comma-separated header contains token (case-insensitive)
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}